@@ -0,0 +1,469 @@
+// Package ann 提供说话人嵌入向量的近似最近邻索引
+//
+// 说话人库超过几十万人后，逐个计算余弦相似度的线性扫描无法满足延迟要求。
+// 本包实现了HNSW（分层可导航小世界图）索引，支持增量插入、删除以及序列化到磁盘。
+package ann
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// Config HNSW索引参数
+type Config struct {
+	// M 每个节点在非底层的最大连接数，底层为2*M。
+	// 值越大召回率越高，但内存占用和插入耗时也越高，一般取8~48
+	M int
+	// EfConstruction 构建时的候选集大小，值越大图质量越好，构建越慢
+	EfConstruction int
+	// EfSearch 查询时的候选集大小，值越大召回率越高，查询越慢，可通过SetEfSearch调整
+	EfSearch int
+	// Seed 随机数种子，用于确定节点层数，相同种子和插入顺序会得到相同的图
+	Seed int64
+}
+
+// DefaultConfig 返回默认的索引参数
+func DefaultConfig() Config {
+	return Config{
+		M:              16,
+		EfConstruction: 200,
+		EfSearch:       64,
+		Seed:           42,
+	}
+}
+
+// Result 查询结果
+type Result struct {
+	ID    string  // 说话人ID
+	Score float32 // 余弦相似度[-1,1]，越接近1表示越相似
+}
+
+// node 图中的一个节点
+type node struct {
+	id        string
+	vec       []float32 // 已归一化的向量
+	neighbors [][]int32 // 每一层的邻居
+	deleted   bool
+}
+
+// Index HNSW索引，可安全地并发使用
+// 删除采用墓碑标记：被删除的节点仍参与图的导航，但不会出现在查询结果中，
+// 墓碑过多时可调用Compact重建索引
+type Index struct {
+	mu       sync.RWMutex
+	dim      int
+	cfg      Config
+	levelMul float64
+	rng      *rand.Rand
+
+	nodes    []*node
+	ids      map[string]int32 // 说话人ID到有效节点的映射
+	entry    int32            // 入口节点，-1表示空索引
+	maxLevel int
+}
+
+// New 创建一个空的HNSW索引
+//
+// 参数:
+//   - dim: 嵌入向量维度
+//   - cfg: 索引参数
+//
+// 返回:
+//   - 索引和可能的错误
+func New(dim int, cfg Config) (*Index, error) {
+	if dim <= 0 {
+		return nil, fmt.Errorf("无效的向量维度: %d", dim)
+	}
+	if cfg.M < 2 {
+		return nil, fmt.Errorf("参数M必须不小于2，实际为: %d", cfg.M)
+	}
+	if cfg.EfConstruction < cfg.M {
+		cfg.EfConstruction = cfg.M
+	}
+	if cfg.EfSearch <= 0 {
+		cfg.EfSearch = DefaultConfig().EfSearch
+	}
+
+	return &Index{
+		dim:      dim,
+		cfg:      cfg,
+		levelMul: 1 / math.Log(float64(cfg.M)),
+		rng:      rand.New(rand.NewSource(cfg.Seed)),
+		ids:      make(map[string]int32),
+		entry:    -1,
+	}, nil
+}
+
+// Dimension 返回索引的向量维度
+func (idx *Index) Dimension() int {
+	return idx.dim
+}
+
+// Len 返回索引中的有效说话人数量
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.ids)
+}
+
+// SetEfSearch 调整查询时的候选集大小，用于在召回率和延迟之间权衡
+func (idx *Index) SetEfSearch(ef int) {
+	if ef <= 0 {
+		return
+	}
+	idx.mu.Lock()
+	idx.cfg.EfSearch = ef
+	idx.mu.Unlock()
+}
+
+// Insert 插入或替换一个说话人的嵌入向量，ID不超过65535字节
+func (idx *Index) Insert(id string, emb *speaker.Embedding) error {
+	if len(id) > math.MaxUint16 {
+		return fmt.Errorf("说话人ID过长: %d 字节", len(id))
+	}
+	vec, err := idx.normalize(emb)
+	if err != nil {
+		return err
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if old, ok := idx.ids[id]; ok {
+		idx.nodes[old].deleted = true
+	}
+	idx.insert(id, vec)
+	return nil
+}
+
+// Delete 删除一个说话人，返回该ID是否存在
+func (idx *Index) Delete(id string) bool {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	n, ok := idx.ids[id]
+	if !ok {
+		return false
+	}
+	idx.nodes[n].deleted = true
+	delete(idx.ids, id)
+	return true
+}
+
+// Search 查询与给定嵌入向量最相似的k个说话人
+//
+// 参数:
+//   - query: 查询嵌入向量
+//   - k: 返回结果数量
+//
+// 返回:
+//   - 按相似度从高到低排序的结果，数量可能少于k
+//   - 可能的错误
+func (idx *Index) Search(query *speaker.Embedding, k int) ([]Result, error) {
+	if k <= 0 {
+		return nil, fmt.Errorf("无效的结果数量: %d", k)
+	}
+	vec, err := idx.normalize(query)
+	if err != nil {
+		return nil, err
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if idx.entry < 0 {
+		return nil, nil
+	}
+
+	ep := []candidate{{idx.entry, idx.distance(vec, idx.entry)}}
+	for l := idx.maxLevel; l > 0; l-- {
+		ep = idx.searchLayer(vec, ep, 1, l)[:1]
+	}
+	ef := idx.cfg.EfSearch
+	if ef < k {
+		ef = k
+	}
+	found := idx.searchLayer(vec, ep, ef, 0)
+
+	results := make([]Result, 0, k)
+	for _, c := range found {
+		if idx.nodes[c.node].deleted {
+			continue
+		}
+		results = append(results, Result{ID: idx.nodes[c.node].id, Score: 1 - c.dist})
+		if len(results) == k {
+			break
+		}
+	}
+	return results, nil
+}
+
+// ExactSearch 使用线性扫描查询与给定嵌入向量最相似的k个说话人，
+// 结果是精确的，可作为评估近似查询召回率的基准
+func (idx *Index) ExactSearch(query *speaker.Embedding, k int) ([]Result, error) {
+	if k <= 0 {
+		return nil, fmt.Errorf("无效的结果数量: %d", k)
+	}
+	vec, err := idx.normalize(query)
+	if err != nil {
+		return nil, err
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	results := make([]Result, 0, len(idx.ids))
+	for id, n := range idx.ids {
+		results = append(results, Result{ID: id, Score: 1 - idx.distance(vec, n)})
+	}
+	sortResults(results)
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results, nil
+}
+
+// Compact 丢弃已删除的节点并重建索引
+func (idx *Index) Compact() {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	old := idx.nodes
+	idx.nodes = nil
+	idx.ids = make(map[string]int32, len(idx.ids))
+	idx.entry = -1
+	idx.maxLevel = 0
+	for _, n := range old {
+		if !n.deleted {
+			idx.insert(n.id, n.vec)
+		}
+	}
+}
+
+// normalize 校验维度并返回归一化后的向量副本
+func (idx *Index) normalize(emb *speaker.Embedding) ([]float32, error) {
+	if emb == nil {
+		return nil, errors.New("嵌入向量为空")
+	}
+	data := emb.GetData()
+	if len(data) != idx.dim {
+		return nil, fmt.Errorf("嵌入向量维度不匹配: %d vs %d", len(data), idx.dim)
+	}
+
+	var norm float64
+	for _, v := range data {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		return nil, errors.New("嵌入向量范数为0")
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	vec := make([]float32, len(data))
+	for i, v := range data {
+		vec[i] = v * scale
+	}
+	return vec, nil
+}
+
+// distance 计算向量与节点之间的余弦距离（1-余弦相似度）
+func (idx *Index) distance(vec []float32, n int32) float32 {
+	return 1 - speaker.Dot(vec, idx.nodes[n].vec)
+}
+
+// randomLevel 按指数分布为新节点随机选择层数
+func (idx *Index) randomLevel() int {
+	return int(math.Floor(-math.Log(1-idx.rng.Float64()) * idx.levelMul))
+}
+
+// maxConnections 返回指定层的最大连接数
+func (idx *Index) maxConnections(level int) int {
+	if level == 0 {
+		return 2 * idx.cfg.M
+	}
+	return idx.cfg.M
+}
+
+// insert 将归一化后的向量插入图中，调用方需持有写锁
+func (idx *Index) insert(id string, vec []float32) {
+	level := idx.randomLevel()
+	n := int32(len(idx.nodes))
+	idx.nodes = append(idx.nodes, &node{
+		id:        id,
+		vec:       vec,
+		neighbors: make([][]int32, level+1),
+	})
+	idx.ids[id] = n
+
+	if idx.entry < 0 {
+		idx.entry = n
+		idx.maxLevel = level
+		return
+	}
+
+	ep := []candidate{{idx.entry, idx.distance(vec, idx.entry)}}
+	for l := idx.maxLevel; l > level; l-- {
+		ep = idx.searchLayer(vec, ep, 1, l)[:1]
+	}
+
+	for l := min(level, idx.maxLevel); l >= 0; l-- {
+		found := idx.searchLayer(vec, ep, idx.cfg.EfConstruction, l)
+		neighbors := idx.selectNeighbors(found, idx.cfg.M)
+		idx.nodes[n].neighbors[l] = neighbors
+
+		// 建立反向连接，超出上限时重新挑选邻居
+		for _, nb := range neighbors {
+			links := append(idx.nodes[nb].neighbors[l], n)
+			if len(links) > idx.maxConnections(l) {
+				nbVec := idx.nodes[nb].vec
+				cands := make([]candidate, len(links))
+				for i, link := range links {
+					cands[i] = candidate{link, idx.distance(nbVec, link)}
+				}
+				sortCandidates(cands)
+				links = idx.selectNeighbors(cands, idx.maxConnections(l))
+			}
+			idx.nodes[nb].neighbors[l] = links
+		}
+		ep = found
+	}
+
+	if level > idx.maxLevel {
+		idx.maxLevel = level
+		idx.entry = n
+	}
+}
+
+// searchLayer 在指定层上进行贪心搜索，返回按距离从近到远排序的至多ef个候选
+func (idx *Index) searchLayer(vec []float32, entries []candidate, ef, level int) []candidate {
+	visited := make(map[int32]struct{}, ef*4)
+	cands := &minHeap{}
+	found := &maxHeap{}
+	for _, e := range entries {
+		visited[e.node] = struct{}{}
+		heap.Push(cands, e)
+		heap.Push(found, e)
+	}
+
+	for cands.Len() > 0 {
+		c := heap.Pop(cands).(candidate)
+		if found.Len() >= ef && c.dist > (*found)[0].dist {
+			break
+		}
+		for _, nb := range idx.nodes[c.node].neighbors[level] {
+			if _, ok := visited[nb]; ok {
+				continue
+			}
+			visited[nb] = struct{}{}
+			d := idx.distance(vec, nb)
+			if found.Len() < ef || d < (*found)[0].dist {
+				heap.Push(cands, candidate{nb, d})
+				heap.Push(found, candidate{nb, d})
+				if found.Len() > ef {
+					heap.Pop(found)
+				}
+			}
+		}
+	}
+
+	result := make([]candidate, found.Len())
+	for i := len(result) - 1; i >= 0; i-- {
+		result[i] = heap.Pop(found).(candidate)
+	}
+	return result
+}
+
+// selectNeighbors 使用启发式规则从按距离排序的候选中挑选至多m个邻居：
+// 优先选择离查询点比离已选邻居更近的候选，使邻居分布在不同方向上，
+// 不足m个时再用被淘汰的候选补足
+func (idx *Index) selectNeighbors(cands []candidate, m int) []int32 {
+	if len(cands) <= m {
+		result := make([]int32, len(cands))
+		for i, c := range cands {
+			result[i] = c.node
+		}
+		return result
+	}
+
+	result := make([]int32, 0, m)
+	var pruned []int32
+	for _, c := range cands {
+		if len(result) == m {
+			break
+		}
+		good := true
+		for _, r := range result {
+			if idx.distance(idx.nodes[c.node].vec, r) < c.dist {
+				good = false
+				break
+			}
+		}
+		if good {
+			result = append(result, c.node)
+		} else {
+			pruned = append(pruned, c.node)
+		}
+	}
+	for _, p := range pruned {
+		if len(result) == m {
+			break
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+// sortResults 按相似度从高到低排序，相似度相同时按ID排序
+func sortResults(results []Result) {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+}
+
+// candidate 搜索过程中的候选节点
+type candidate struct {
+	node int32
+	dist float32
+}
+
+// sortCandidates 按距离从近到远排序
+func sortCandidates(cands []candidate) {
+	sort.Slice(cands, func(i, j int) bool { return cands[i].dist < cands[j].dist })
+}
+
+// minHeap 距离最近的候选在堆顶
+type minHeap []candidate
+
+func (h minHeap) Len() int            { return len(h) }
+func (h minHeap) Less(i, j int) bool  { return h[i].dist < h[j].dist }
+func (h minHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *minHeap) Push(x interface{}) { *h = append(*h, x.(candidate)) }
+func (h *minHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// maxHeap 距离最远的候选在堆顶
+type maxHeap []candidate
+
+func (h maxHeap) Len() int            { return len(h) }
+func (h maxHeap) Less(i, j int) bool  { return h[i].dist > h[j].dist }
+func (h maxHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *maxHeap) Push(x interface{}) { *h = append(*h, x.(candidate)) }
+func (h *maxHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
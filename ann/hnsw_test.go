@@ -0,0 +1,148 @@
+package ann
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// randomEmbeddings 生成随机嵌入向量
+func randomEmbeddings(rng *rand.Rand, n, dim int) []*speaker.Embedding {
+	embs := make([]*speaker.Embedding, n)
+	for i := range embs {
+		data := make([]float32, dim)
+		for j := range data {
+			data[j] = float32(rng.NormFloat64())
+		}
+		embs[i] = speaker.NewEmbedding(data)
+	}
+	return embs
+}
+
+// buildIndex 使用随机向量构建索引
+func buildIndex(t *testing.T, n, dim int) (*Index, []*speaker.Embedding) {
+	rng := rand.New(rand.NewSource(1))
+	idx, err := New(dim, DefaultConfig())
+	if err != nil {
+		t.Fatalf("创建索引失败: %v", err)
+	}
+	embs := randomEmbeddings(rng, n, dim)
+	for i, emb := range embs {
+		if err := idx.Insert(fmt.Sprintf("spk%04d", i), emb); err != nil {
+			t.Fatalf("插入失败: %v", err)
+		}
+	}
+	return idx, randomEmbeddings(rng, 50, dim)
+}
+
+// TestIndexRecall 测试近似查询的召回率
+func TestIndexRecall(t *testing.T) {
+	idx, queries := buildIndex(t, 2000, 32)
+
+	report, err := EvaluateRecall(idx, queries, 10)
+	if err != nil {
+		t.Fatalf("评估召回率失败: %v", err)
+	}
+	t.Logf("召回率: %.3f, Top1: %.3f", report.Recall, report.Top1)
+	if report.Recall < 0.9 {
+		t.Fatalf("召回率过低: %.3f", report.Recall)
+	}
+}
+
+// TestIndexDelete 测试删除后的说话人不会出现在结果中
+func TestIndexDelete(t *testing.T) {
+	idx, _ := buildIndex(t, 500, 16)
+
+	target, _ := idx.ExactSearch(speaker.NewEmbedding(idx.nodes[idx.ids["spk0007"]].vec), 1)
+	if len(target) != 1 || target[0].ID != "spk0007" {
+		t.Fatalf("精确查询结果不正确: %+v", target)
+	}
+	query := speaker.NewEmbedding(idx.nodes[idx.ids["spk0007"]].vec)
+
+	if !idx.Delete("spk0007") {
+		t.Fatal("删除已存在的说话人应返回true")
+	}
+	if idx.Delete("spk0007") {
+		t.Fatal("重复删除应返回false")
+	}
+	results, err := idx.Search(query, 5)
+	if err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	for _, r := range results {
+		if r.ID == "spk0007" {
+			t.Fatal("已删除的说话人出现在查询结果中")
+		}
+	}
+
+	idx.Compact()
+	if idx.Len() != 499 || len(idx.nodes) != 499 {
+		t.Fatalf("压缩后节点数量不正确: %d/%d", idx.Len(), len(idx.nodes))
+	}
+}
+
+// TestIndexSaveLoad 测试序列化后查询结果不变
+func TestIndexSaveLoad(t *testing.T) {
+	idx, queries := buildIndex(t, 300, 16)
+	idx.Delete("spk0001")
+
+	path := filepath.Join(t.TempDir(), "test.hnsw")
+	if err := idx.Save(path); err != nil {
+		t.Fatalf("保存索引失败: %v", err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("加载索引失败: %v", err)
+	}
+	if loaded.Len() != idx.Len() {
+		t.Fatalf("加载后说话人数量不一致: %d vs %d", loaded.Len(), idx.Len())
+	}
+
+	for _, q := range queries {
+		want, _ := idx.Search(q, 5)
+		got, _ := loaded.Search(q, 5)
+		if fmt.Sprint(want) != fmt.Sprint(got) {
+			t.Fatalf("加载后查询结果不一致: %v vs %v", want, got)
+		}
+	}
+
+	// 加载后应能继续插入
+	if err := loaded.Insert("new", queries[0]); err != nil {
+		t.Fatalf("加载后插入失败: %v", err)
+	}
+	results, _ := loaded.Search(queries[0], 1)
+	if len(results) != 1 || results[0].ID != "new" {
+		t.Fatalf("新插入的说话人应排在第一: %+v", results)
+	}
+	if err := loaded.Insert(strings.Repeat("x", math.MaxUint16+1), queries[0]); err == nil {
+		t.Fatal("超过65535字节的ID应返回错误")
+	}
+
+	// 长度字段损坏或文件截断时返回错误，而不是按损坏的长度分配内存
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	corrupt := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint32(corrupt[40:], math.MaxUint32) // 节点数
+	// 最高层数比入口节点的层数多一层，校验和仍然正确
+	levels := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint32(levels[36:], binary.LittleEndian.Uint32(levels[36:])+1)
+	binary.LittleEndian.PutUint32(levels[len(levels)-4:], crc32.ChecksumIEEE(levels[:len(levels)-4]))
+	for name, content := range map[string][]byte{"节点数超出文件长度": corrupt, "文件截断": data[:len(data)/2], "入口节点层数不足": levels} {
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Fatalf("%s时加载应返回错误", name)
+		}
+	}
+}
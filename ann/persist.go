@@ -0,0 +1,297 @@
+package ann
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+)
+
+// 索引文件格式：
+//
+//	魔数"SPKH" | 版本(u32) | 维度 | M | EfConstruction | EfSearch | Seed(i64) |
+//	入口节点(i32) | 最大层数 | 节点数 | 节点... | CRC32(u32)
+//	节点: ID长度(u16) | ID | 是否删除(u8) | 向量 | 层数 | 每层: 邻居数 | 邻居(i32)...
+//
+// 未特别说明的整数均为小端u32
+const (
+	indexMagic   = "SPKH"
+	indexVersion = 1
+)
+
+// Save 将索引保存到文件，先写入临时文件再原子替换
+func (idx *Index) Save(path string) error {
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("创建索引文件失败: %w", err)
+	}
+
+	idx.mu.RLock()
+	err = idx.encode(f)
+	idx.mu.RUnlock()
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("写入索引文件失败: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("替换索引文件失败: %w", err)
+	}
+	return nil
+}
+
+// Load 从文件加载索引
+func Load(path string) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开索引文件失败: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("读取索引文件失败: %w", err)
+	}
+
+	idx, err := decode(f, info.Size())
+	if err != nil {
+		return nil, fmt.Errorf("读取索引文件失败: %w", err)
+	}
+	return idx, nil
+}
+
+// encoder 写入小端整数并累计校验和
+type encoder struct {
+	w   *bufio.Writer
+	crc hash.Hash32
+	err error
+}
+
+func (e *encoder) write(p []byte) {
+	if e.err != nil {
+		return
+	}
+	e.crc.Write(p)
+	_, e.err = e.w.Write(p)
+}
+
+func (e *encoder) u32(v uint32) {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	e.write(buf[:])
+}
+
+func (e *encoder) u64(v uint64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	e.write(buf[:])
+}
+
+// encode 序列化索引，调用方需持有读锁
+func (idx *Index) encode(w io.Writer) error {
+	e := &encoder{w: bufio.NewWriter(w), crc: crc32.NewIEEE()}
+	e.write([]byte(indexMagic))
+	e.u32(indexVersion)
+	e.u32(uint32(idx.dim))
+	e.u32(uint32(idx.cfg.M))
+	e.u32(uint32(idx.cfg.EfConstruction))
+	e.u32(uint32(idx.cfg.EfSearch))
+	e.u64(uint64(idx.cfg.Seed))
+	e.u32(uint32(idx.entry))
+	e.u32(uint32(idx.maxLevel))
+	e.u32(uint32(len(idx.nodes)))
+
+	for _, n := range idx.nodes {
+		var idLen [2]byte
+		binary.LittleEndian.PutUint16(idLen[:], uint16(len(n.id)))
+		e.write(idLen[:])
+		e.write([]byte(n.id))
+		if n.deleted {
+			e.write([]byte{1})
+		} else {
+			e.write([]byte{0})
+		}
+		for _, v := range n.vec {
+			e.u32(math.Float32bits(v))
+		}
+		e.u32(uint32(len(n.neighbors)))
+		for _, links := range n.neighbors {
+			e.u32(uint32(len(links)))
+			for _, link := range links {
+				e.u32(uint32(link))
+			}
+		}
+	}
+
+	if e.err != nil {
+		return e.err
+	}
+	var sum [4]byte
+	binary.LittleEndian.PutUint32(sum[:], e.crc.Sum32())
+	if _, err := e.w.Write(sum[:]); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
+
+// decoder 读取小端整数并累计校验和
+type decoder struct {
+	r         io.Reader
+	crc       hash.Hash32
+	err       error
+	remaining int64 // 尚未读取的字节数
+}
+
+func (d *decoder) read(p []byte) {
+	if d.err != nil {
+		return
+	}
+	if _, d.err = io.ReadFull(d.r, p); d.err == nil {
+		d.crc.Write(p)
+		d.remaining -= int64(len(p))
+	}
+}
+
+// fits 判断剩余数据是否足够容纳n个size字节的元素，用于在按长度字段分配内存前拒绝损坏的文件
+func (d *decoder) fits(n, size int) bool {
+	return n >= 0 && int64(n) <= d.remaining/int64(size)
+}
+
+func (d *decoder) u32() uint32 {
+	var buf [4]byte
+	d.read(buf[:])
+	return binary.LittleEndian.Uint32(buf[:])
+}
+
+func (d *decoder) u64() uint64 {
+	var buf [8]byte
+	d.read(buf[:])
+	return binary.LittleEndian.Uint64(buf[:])
+}
+
+// decode 反序列化索引并校验结构，size为数据的总长度
+func decode(r io.Reader, size int64) (*Index, error) {
+	d := &decoder{r: bufio.NewReader(r), crc: crc32.NewIEEE(), remaining: size}
+
+	var head [4]byte
+	d.read(head[:])
+	if d.err == nil && string(head[:]) != indexMagic {
+		return nil, errors.New("不是有效的索引文件")
+	}
+	if v := d.u32(); d.err == nil && v != indexVersion {
+		return nil, fmt.Errorf("不支持的索引版本: %d", v)
+	}
+
+	dim := int(d.u32())
+	cfg := Config{
+		M:              int(d.u32()),
+		EfConstruction: int(d.u32()),
+		EfSearch:       int(d.u32()),
+		Seed:           int64(d.u64()),
+	}
+	entry := int32(d.u32())
+	maxLevel := int(d.u32())
+	count := int(d.u32())
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	idx, err := New(dim, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if count < 0 || entry < -1 || int(entry) >= count || (count > 0) != (entry >= 0) {
+		return nil, errors.New("索引结构无效")
+	}
+	// 每个节点至少包含ID长度、删除标记、向量、层数和第0层的邻居数
+	if !d.fits(count, 11+4*dim) {
+		return nil, errors.New("索引节点数超出文件长度")
+	}
+	idx.entry = entry
+	idx.maxLevel = maxLevel
+	idx.nodes = make([]*node, 0, count)
+
+	for i := 0; i < count && d.err == nil; i++ {
+		var idLen [2]byte
+		d.read(idLen[:])
+		id := make([]byte, binary.LittleEndian.Uint16(idLen[:]))
+		d.read(id)
+		var deleted [1]byte
+		d.read(deleted[:])
+
+		vec := make([]float32, dim)
+		for j := range vec {
+			vec[j] = math.Float32frombits(d.u32())
+		}
+
+		levels := int(d.u32())
+		if d.err == nil && (levels <= 0 || levels > maxLevel+1 || !d.fits(levels, 4)) {
+			return nil, errors.New("索引节点层数无效")
+		}
+		neighbors := make([][]int32, levels)
+		for l := range neighbors {
+			links := int(d.u32())
+			if d.err == nil && (links < 0 || links > idx.maxConnections(l) || !d.fits(links, 4)) {
+				return nil, errors.New("索引节点邻居数无效")
+			}
+			neighbors[l] = make([]int32, links)
+			for k := range neighbors[l] {
+				link := int32(d.u32())
+				if d.err == nil && (link < 0 || int(link) >= count) {
+					return nil, errors.New("索引节点邻居无效")
+				}
+				neighbors[l][k] = link
+			}
+		}
+
+		n := &node{id: string(id), vec: vec, neighbors: neighbors, deleted: deleted[0] != 0}
+		idx.nodes = append(idx.nodes, n)
+		if !n.deleted {
+			idx.ids[n.id] = int32(i)
+		}
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	expected := d.crc.Sum32()
+	var sum [4]byte
+	if _, err := io.ReadFull(d.r, sum[:]); err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint32(sum[:]) != expected {
+		return nil, errors.New("索引文件校验失败")
+	}
+
+	// 搜索从入口节点的最高层开始，入口节点必须恰好有maxLevel+1层
+	if entry >= 0 && len(idx.nodes[entry].neighbors) != maxLevel+1 {
+		return nil, errors.New("索引入口节点层数无效")
+	}
+	// 邻居所在层必须存在
+	for _, n := range idx.nodes {
+		for l, links := range n.neighbors {
+			for _, link := range links {
+				if len(idx.nodes[link].neighbors) <= l {
+					return nil, errors.New("索引节点邻居层数无效")
+				}
+			}
+		}
+	}
+
+	// 避免加载后的层数序列与保存前重复
+	idx.rng = rand.New(rand.NewSource(cfg.Seed + int64(count)))
+	return idx, nil
+}
@@ -0,0 +1,63 @@
+package ann
+
+import (
+	"fmt"
+
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// RecallReport 召回率评估结果
+type RecallReport struct {
+	Queries int     // 查询数量
+	K       int     // 每次查询的结果数量
+	Recall  float64 // 近似结果中命中精确结果的比例[0,1]
+	Top1    float64 // 近似结果第一名与精确结果第一名一致的比例[0,1]
+}
+
+// EvaluateRecall 对比近似查询与精确查询的结果，用于调整M、EfSearch等参数
+//
+// 参数:
+//   - idx: 待评估的索引
+//   - queries: 查询嵌入向量
+//   - k: 每次查询的结果数量
+//
+// 返回:
+//   - 评估结果和可能的错误
+func EvaluateRecall(idx *Index, queries []*speaker.Embedding, k int) (RecallReport, error) {
+	report := RecallReport{Queries: len(queries), K: k}
+	if len(queries) == 0 {
+		return report, nil
+	}
+
+	var hits, total, top1 int
+	for i, q := range queries {
+		exact, err := idx.ExactSearch(q, k)
+		if err != nil {
+			return report, fmt.Errorf("第%d个查询精确搜索失败: %w", i, err)
+		}
+		approx, err := idx.Search(q, k)
+		if err != nil {
+			return report, fmt.Errorf("第%d个查询近似搜索失败: %w", i, err)
+		}
+
+		truth := make(map[string]struct{}, len(exact))
+		for _, r := range exact {
+			truth[r.ID] = struct{}{}
+		}
+		for _, r := range approx {
+			if _, ok := truth[r.ID]; ok {
+				hits++
+			}
+		}
+		total += len(exact)
+		if len(exact) > 0 && len(approx) > 0 && exact[0].ID == approx[0].ID {
+			top1++
+		}
+	}
+
+	if total > 0 {
+		report.Recall = float64(hits) / float64(total)
+	}
+	report.Top1 = float64(top1) / float64(len(queries))
+	return report, nil
+}
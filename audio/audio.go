@@ -0,0 +1,237 @@
+// Package audio 提供音频文件读取和格式转换，输出模型所需的16kHz单声道int16 PCM数据
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+)
+
+// SampleRate 模型期望的采样率
+const SampleRate = 16000
+
+// 支持的输入采样率范围和最大声道数，超出范围的多为损坏或恶意构造的数据
+// （过低的采样率会使重采样后的样本数成倍膨胀）
+const (
+	MinSampleRate = 8000
+	MaxSampleRate = 192000
+	MaxChannels   = 32
+)
+
+// ErrUnsupportedFormat 采样率或声道数超出支持的范围
+var ErrUnsupportedFormat = errors.New("不支持的音频格式")
+
+// WAV音频格式编码
+const (
+	formatPCM        = 1
+	formatFloat      = 3
+	formatExtensible = 0xFFFE
+)
+
+// Format 音频格式信息
+type Format struct {
+	SampleRate    int // 采样率（每秒样本数）
+	NumChannels   int // 声道数
+	BitsPerSample int // 每个采样的位数
+	Float         bool
+}
+
+// Validate 检查采样率和声道数是否在支持的范围内
+func (f Format) Validate() error {
+	if f.SampleRate < MinSampleRate || f.SampleRate > MaxSampleRate {
+		return fmt.Errorf("%w: 采样率%d不在%d到%d之间", ErrUnsupportedFormat, f.SampleRate, MinSampleRate, MaxSampleRate)
+	}
+	if f.NumChannels <= 0 || f.NumChannels > MaxChannels {
+		return fmt.Errorf("%w: 声道数%d不在1到%d之间", ErrUnsupportedFormat, f.NumChannels, MaxChannels)
+	}
+	return nil
+}
+
+// ReadFile 读取音频文件（WAV或16kHz单声道小端int16原始PCM）并返回16kHz单声道int16数组
+func ReadFile(path string) ([]int16, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+	return Decode(data)
+}
+
+// Decode 解码音频数据（WAV或16kHz单声道小端int16原始PCM）并返回16kHz单声道int16数组
+func Decode(data []byte) ([]int16, error) {
+	if IsWAV(data) {
+		pcm, format, err := DecodeWAV(data)
+		if err != nil {
+			return nil, err
+		}
+		return Resample(pcm, format.SampleRate, SampleRate), nil
+	}
+	return DecodeRaw(data)
+}
+
+// IsWAV 判断数据是否为WAV文件
+func IsWAV(data []byte) bool {
+	return len(data) >= 12 &&
+		string(data[0:4]) == "RIFF" &&
+		string(data[8:12]) == "WAVE"
+}
+
+// DecodeRaw 解码小端int16原始PCM数据
+func DecodeRaw(data []byte) ([]int16, error) {
+	// 确保数据长度是偶数（每个int16需要2个字节）
+	if len(data)%2 != 0 {
+		return nil, errors.New("无效的PCM数据: 数据长度不是2的倍数")
+	}
+
+	pcm := make([]int16, len(data)/2)
+	for i := range pcm {
+		pcm[i] = int16(binary.LittleEndian.Uint16(data[i*2:]))
+	}
+	return pcm, nil
+}
+
+// DecodeWAV 解析WAV文件，返回混合为单声道的int16数据和原始格式（不做采样率转换）
+// 支持8/16/24/32位整数PCM和32/64位浮点格式，采样率和声道数超出支持范围时返回ErrUnsupportedFormat
+func DecodeWAV(data []byte) ([]int16, Format, error) {
+	var format Format
+	if !IsWAV(data) {
+		return nil, format, errors.New("不是有效的WAV文件")
+	}
+
+	var pcmData []byte
+	haveFmt := false
+	for offset := 12; offset+8 <= len(data); {
+		id := string(data[offset : offset+4])
+		size := int(binary.LittleEndian.Uint32(data[offset+4 : offset+8]))
+		body := data[offset+8:]
+		if size > len(body) {
+			// 流式写入的文件data块长度可能不准确，以实际长度为准
+			size = len(body)
+		}
+		body = body[:size]
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, format, errors.New("WAV文件fmt块过短")
+			}
+			audioFormat := binary.LittleEndian.Uint16(body[0:2])
+			format.NumChannels = int(binary.LittleEndian.Uint16(body[2:4]))
+			format.SampleRate = int(binary.LittleEndian.Uint32(body[4:8]))
+			format.BitsPerSample = int(binary.LittleEndian.Uint16(body[14:16]))
+			if audioFormat == formatExtensible && size >= 26 {
+				// 子格式GUID的前两个字节即为实际格式
+				audioFormat = binary.LittleEndian.Uint16(body[24:26])
+			}
+			switch audioFormat {
+			case formatPCM:
+			case formatFloat:
+				format.Float = true
+			default:
+				return nil, format, fmt.Errorf("不支持的WAV音频格式: %d", audioFormat)
+			}
+			haveFmt = true
+		case "data":
+			pcmData = body
+		}
+
+		// 块按偶数字节对齐
+		offset += 8 + size + size%2
+	}
+
+	if !haveFmt {
+		return nil, format, errors.New("找不到WAV文件的fmt块")
+	}
+	if pcmData == nil {
+		return nil, format, errors.New("找不到WAV文件的data块")
+	}
+	if err := format.Validate(); err != nil {
+		return nil, format, err
+	}
+
+	pcm, err := toMono(pcmData, format)
+	if err != nil {
+		return nil, format, err
+	}
+	return pcm, format, nil
+}
+
+// toMono 将交织的多声道样本转换为单声道int16
+func toMono(data []byte, format Format) ([]int16, error) {
+	bytesPerSample := format.BitsPerSample / 8
+	switch {
+	case format.Float && bytesPerSample != 4 && bytesPerSample != 8:
+		return nil, fmt.Errorf("不支持的浮点位深: %d", format.BitsPerSample)
+	case !format.Float && (bytesPerSample < 1 || bytesPerSample > 4):
+		return nil, fmt.Errorf("不支持的位深: %d", format.BitsPerSample)
+	}
+
+	frameSize := bytesPerSample * format.NumChannels
+	numFrames := len(data) / frameSize
+	mono := make([]int16, numFrames)
+
+	for i := 0; i < numFrames; i++ {
+		var sum float64
+		for ch := 0; ch < format.NumChannels; ch++ {
+			sum += decodeSample(data[i*frameSize+ch*bytesPerSample:], bytesPerSample, format.Float)
+		}
+		mono[i] = FloatToInt16(float32(sum / float64(format.NumChannels)))
+	}
+	return mono, nil
+}
+
+// decodeSample 将一个样本解码为[-1,1]范围的浮点数
+func decodeSample(b []byte, bytesPerSample int, isFloat bool) float64 {
+	if isFloat {
+		if bytesPerSample == 8 {
+			return math.Float64frombits(binary.LittleEndian.Uint64(b))
+		}
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	}
+
+	switch bytesPerSample {
+	case 1:
+		// 8位PCM为无符号数
+		return (float64(b[0]) - 128) / 128
+	case 2:
+		return float64(int16(binary.LittleEndian.Uint16(b))) / 32768
+	case 3:
+		v := int32(b[0]) | int32(b[1])<<8 | int32(int8(b[2]))<<16
+		return float64(v) / (1 << 23)
+	default:
+		return float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31)
+	}
+}
+
+// FloatToInt16 将[-1,1]范围的浮点样本转换为int16，超出范围的值会被截断
+func FloatToInt16(v float32) int16 {
+	s := math.Round(float64(v) * 32768)
+	if s > math.MaxInt16 {
+		return math.MaxInt16
+	}
+	if s < math.MinInt16 {
+		return math.MinInt16
+	}
+	return int16(s)
+}
+
+// Resample 将PCM数据从一个采样率转换为另一个采样率
+// 降采样时先用加窗sinc低通滤波抗混叠，升采样使用线性插值；与分块输入NewResampler的结果一致
+//
+// 参数:
+//   - inputPcm: 输入PCM数据
+//   - inputSampleRate: 输入采样率
+//   - outputSampleRate: 输出采样率
+//
+// 返回:
+//   - 采样率转换后的PCM数据
+func Resample(inputPcm []int16, inputSampleRate, outputSampleRate int) []int16 {
+	// 如果采样率相同，直接返回原始数据
+	if inputSampleRate == outputSampleRate || len(inputPcm) == 0 {
+		return inputPcm
+	}
+	r := NewResampler(inputSampleRate, outputSampleRate)
+	out := r.Process(inputPcm)
+	return append(out, r.Flush()...)
+}
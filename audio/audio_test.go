@@ -0,0 +1,228 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// buildWAV 构造一个16位PCM的WAV文件
+func buildWAV(sampleRate, channels int, samples []int16) []byte {
+	var buf bytes.Buffer
+	dataSize := len(samples) * 2
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(36+dataSize))
+	buf.WriteString("WAVE")
+	buf.WriteString("fmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(16))
+	binary.Write(&buf, binary.LittleEndian, uint16(formatPCM))
+	binary.Write(&buf, binary.LittleEndian, uint16(channels))
+	binary.Write(&buf, binary.LittleEndian, uint32(sampleRate))
+	binary.Write(&buf, binary.LittleEndian, uint32(sampleRate*channels*2))
+	binary.Write(&buf, binary.LittleEndian, uint16(channels*2))
+	binary.Write(&buf, binary.LittleEndian, uint16(16))
+	// 插入一个额外的块，验证按块解析
+	buf.WriteString("LIST")
+	binary.Write(&buf, binary.LittleEndian, uint32(3))
+	buf.Write([]byte{1, 2, 3, 0})
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(dataSize))
+	binary.Write(&buf, binary.LittleEndian, samples)
+	return buf.Bytes()
+}
+
+// TestDecodeWAV 测试WAV解析、声道混合和采样率转换
+func TestDecodeWAV(t *testing.T) {
+	mono := []int16{0, 100, -100, 32767, -32768}
+	pcm, format, err := DecodeWAV(buildWAV(16000, 1, mono))
+	if err != nil {
+		t.Fatalf("解析单声道WAV失败: %v", err)
+	}
+	if format.SampleRate != 16000 || format.NumChannels != 1 || format.BitsPerSample != 16 {
+		t.Fatalf("格式信息不正确: %+v", format)
+	}
+	for i := range mono {
+		if pcm[i] != mono[i] {
+			t.Fatalf("第%d个样本不一致: %d vs %d", i, pcm[i], mono[i])
+		}
+	}
+
+	stereo := []int16{100, 300, -200, -400}
+	pcm, _, err = DecodeWAV(buildWAV(16000, 2, stereo))
+	if err != nil {
+		t.Fatalf("解析立体声WAV失败: %v", err)
+	}
+	if len(pcm) != 2 || pcm[0] != 200 || pcm[1] != -300 {
+		t.Fatalf("立体声混合结果不正确: %v", pcm)
+	}
+
+	pcm, err = Decode(buildWAV(8000, 1, make([]int16, 800)))
+	if err != nil {
+		t.Fatalf("解码8kHz WAV失败: %v", err)
+	}
+	if len(pcm) != 1600 {
+		t.Fatalf("采样率转换后长度应为1600，实际为: %d", len(pcm))
+	}
+
+	// 采样率超出支持范围时拒绝解码，避免重采样后样本数成倍膨胀
+	for _, rate := range []int{1, MinSampleRate - 1, MaxSampleRate + 1} {
+		if _, err := Decode(buildWAV(rate, 1, make([]int16, 800))); !errors.Is(err, ErrUnsupportedFormat) {
+			t.Fatalf("采样率%d应返回ErrUnsupportedFormat，实际为: %v", rate, err)
+		}
+	}
+}
+
+// TestDecodeRaw 测试原始PCM解码
+func TestDecodeRaw(t *testing.T) {
+	pcm, err := Decode([]byte{0x01, 0x00, 0xff, 0xff})
+	if err != nil {
+		t.Fatalf("解码原始PCM失败: %v", err)
+	}
+	if len(pcm) != 2 || pcm[0] != 1 || pcm[1] != -1 {
+		t.Fatalf("解码结果不正确: %v", pcm)
+	}
+	if _, err := Decode([]byte{0x01}); err == nil {
+		t.Fatal("奇数长度的数据应返回错误")
+	}
+}
+
+// speechSignal 生成静音-正弦-静音的测试信号，返回信号和语音段
+func speechSignal(rng *rand.Rand, silence, speech int, noise float64) ([]int16, Segment) {
+	pcm := make([]int16, 2*silence+speech)
+	for i := range pcm {
+		v := rng.NormFloat64() * noise
+		if i >= silence && i < silence+speech {
+			v += 8000 * math.Sin(2*math.Pi*220*float64(i)/SampleRate)
+		}
+		pcm[i] = int16(v)
+	}
+	return pcm, Segment{Start: silence, End: silence + speech}
+}
+
+// TestDetectSpeech 测试语音活动检测和信噪比估计
+func TestDetectSpeech(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	pcm, want := speechSignal(rng, SampleRate/2, SampleRate, 30)
+	segs := DetectSpeech(pcm, SampleRate, DefaultVADConfig())
+	if len(segs) != 1 {
+		t.Fatalf("应检测到1个语音段，实际为%v", segs)
+	}
+	// 边界误差不超过一帧加扩展长度
+	if d := segs[0].Start - want.Start; d > 0 || d < -SampleRate/20 {
+		t.Fatalf("语音段起点不正确: %v，期望约为%v", segs[0], want)
+	}
+	if d := segs[0].End - want.End; d < 0 || d > SampleRate/20 {
+		t.Fatalf("语音段终点不正确: %v，期望约为%v", segs[0], want)
+	}
+	if s := SpeechSeconds(segs, SampleRate); s < 1 || s > 1.1 {
+		t.Fatalf("净语音时长不正确: %v", s)
+	}
+	if n := len(ExtractSegments(pcm, segs)); n != segs[0].Len() {
+		t.Fatalf("拼接后的样本数不正确: %d", n)
+	}
+
+	// 噪声越大信噪比越低
+	clean := EstimateSNR(pcm, SampleRate, segs)
+	noisy, _ := speechSignal(rng, SampleRate/2, SampleRate, 1000)
+	if snr := EstimateSNR(noisy, SampleRate, DetectSpeech(noisy, SampleRate, DefaultVADConfig())); snr >= clean || snr < 5 || snr > 20 {
+		t.Fatalf("信噪比估计不合理: 干净%.1fdB 含噪%.1fdB", clean, snr)
+	}
+	if segs := DetectSpeech(make([]int16, SampleRate), SampleRate, DefaultVADConfig()); len(segs) != 0 {
+		t.Fatalf("纯静音不应检测到语音: %v", segs)
+	}
+}
+
+// TestStreamConverter 测试分块输入的流式转换结果与整段转换一致
+func TestStreamConverter(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, rate := range []int{8000, 16000, 44100, 48000} {
+		pcm := make([]int16, rate+7)
+		for i := range pcm {
+			pcm[i] = int16(rng.Intn(20000) - 10000)
+		}
+		want := Resample(pcm, rate, SampleRate)
+		r := NewResampler(rate, SampleRate)
+		var got []int16
+		for i := 0; i < len(pcm); {
+			n := min(1+rng.Intn(500), len(pcm)-i)
+			got = append(got, r.Process(pcm[i:i+n])...)
+			i += n
+		}
+		got = append(got, r.Flush()...)
+		if len(got) != len(want) {
+			t.Fatalf("%dHz: 输出长度应为%d，实际为%d", rate, len(want), len(got))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("%dHz: 第%d个样本应为%d，实际为%d", rate, i, want[i], got[i])
+			}
+		}
+	}
+
+	// 48kHz双声道float32，块边界落在样本中间
+	format := Format{SampleRate: 48000, NumChannels: 2, BitsPerSample: 32, Float: true}
+	data := make([]byte, 4800*2*4)
+	for i := 0; i < len(data); i += 4 {
+		binary.LittleEndian.PutUint32(data[i:], math.Float32bits(float32(math.Sin(float64(i)/100))))
+	}
+	mono, err := toMono(data, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Resample(mono, 48000, SampleRate)
+	c, err := NewConverter(format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int16
+	for i := 0; i < len(data); i += 1001 {
+		out, err := c.Write(data[i:min(i+1001, len(data))])
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, out...)
+	}
+	got = append(got, c.Flush()...)
+	if len(got) != 1600 || len(got) != len(want) {
+		t.Fatalf("输出长度应为1600，实际为%d", len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("第%d个样本应为%d，实际为%d", i, want[i], got[i])
+		}
+	}
+
+	if _, err := NewConverter(Format{SampleRate: 48000, NumChannels: 1, BitsPerSample: 12}); err == nil {
+		t.Fatal("不支持的位深应返回错误")
+	}
+	if _, err := NewConverter(Format{SampleRate: 48000, NumChannels: MaxChannels + 1, BitsPerSample: 16}); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("声道数超出范围应返回ErrUnsupportedFormat，实际为: %v", err)
+	}
+}
+
+// TestResampleAntiAlias 测试降采样时滤除输出奈奎斯特频率以上的分量，保留通带内的分量
+func TestResampleAntiAlias(t *testing.T) {
+	peak := func(freq float64) float64 {
+		pcm := make([]int16, 48000)
+		for i := range pcm {
+			pcm[i] = int16(10000 * math.Sin(2*math.Pi*freq*float64(i)/48000))
+		}
+		out := Resample(pcm, 48000, SampleRate)
+		var m float64
+		// 跳过两端的滤波器过渡区
+		for _, v := range out[100 : len(out)-100] {
+			m = max(m, math.Abs(float64(v)))
+		}
+		return m
+	}
+	if m := peak(1000); m < 9500 || m > 10500 {
+		t.Fatalf("1kHz应保持幅度，实际峰值为%v", m)
+	}
+	// 12kHz高于16kHz的奈奎斯特频率，不滤波会混叠为4kHz
+	if m := peak(12000); m > 100 {
+		t.Fatalf("12kHz应被滤除，实际峰值为%v", m)
+	}
+}
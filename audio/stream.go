@@ -0,0 +1,215 @@
+package audio
+
+import (
+	"errors"
+	"math"
+)
+
+// 抗混叠滤波器参数：截止频率为输出奈奎斯特频率的rolloff倍，
+// 冲激响应每侧保留zeroCrossings个过零点，用Blackman窗截断，并按kernelPhases的分辨率预先制表
+const (
+	rolloff       = 0.9
+	zeroCrossings = 8
+	kernelPhases  = 256
+)
+
+// kernel 降采样使用的加窗sinc低通滤波器，自变量以输入样本间隔为单位
+type kernel struct {
+	halfWidth float64   // 冲激响应的半宽
+	reach     int       // 每个输出样本在插值位置两侧各需要的输入样本数
+	table     []float64 // table[j]为t=j/kernelPhases处的响应
+}
+
+// newKernel 创建从inRate降采样到outRate的抗混叠滤波器
+func newKernel(inRate, outRate int) *kernel {
+	cutoff := rolloff * float64(outRate) / float64(inRate) // 相对于输入奈奎斯特频率
+	halfWidth := zeroCrossings / cutoff
+	k := &kernel{halfWidth: halfWidth, reach: int(math.Ceil(halfWidth))}
+	k.table = make([]float64, int(halfWidth*kernelPhases)+2)
+	for j := range k.table {
+		t := float64(j) / kernelPhases
+		if t >= halfWidth {
+			continue
+		}
+		x := math.Pi * cutoff * t
+		sinc := 1.0
+		if x != 0 {
+			sinc = math.Sin(x) / x
+		}
+		w := math.Pi * t / halfWidth
+		k.table[j] = cutoff * sinc * (0.42 + 0.5*math.Cos(w) + 0.08*math.Cos(2*w))
+	}
+	return k
+}
+
+// at 返回t处的响应（对称），在表中线性插值
+func (k *kernel) at(t float64) float64 {
+	pos := math.Abs(t) * kernelPhases
+	j := int(pos)
+	if j+1 >= len(k.table) {
+		return 0
+	}
+	frac := pos - float64(j)
+	return k.table[j]*(1-frac) + k.table[j+1]*frac
+}
+
+// Resampler 流式采样率转换，分块输入的结果与对整段数据调用Resample一致
+// 降采样时先经过抗混叠低通滤波，升采样使用线性插值
+// 非并发安全
+type Resampler struct {
+	inRate, outRate int
+	kernel          *kernel // 降采样时的抗混叠滤波器，升采样时为nil
+	buf             []int16 // 尚未用完的输入样本，buf[0]为第base个输入样本
+	base            int
+	in, out         int // 已输入和已输出的样本数
+}
+
+// NewResampler 创建从inRate到outRate的流式采样率转换
+func NewResampler(inRate, outRate int) *Resampler {
+	r := &Resampler{inRate: inRate, outRate: outRate}
+	if inRate > outRate {
+		r.kernel = newKernel(inRate, outRate)
+	}
+	return r
+}
+
+// position 返回第i个输出样本对应的输入位置
+func (r *Resampler) position(i int) float64 {
+	return float64(i) * float64(r.inRate) / float64(r.outRate)
+}
+
+// Process 输入一块PCM，返回已能确定的输出样本；插值或滤波需要的后续样本尚未到达的输出留到下次
+func (r *Resampler) Process(pcm []int16) []int16 {
+	r.in += len(pcm)
+	if r.inRate == r.outRate {
+		r.out += len(pcm)
+		return pcm
+	}
+	r.buf = append(r.buf, pcm...)
+	out := r.interpolate(false)
+
+	// 丢弃之后的输出不再需要的样本
+	first := int(r.position(r.out))
+	if r.kernel != nil {
+		first -= r.kernel.reach - 1
+	}
+	if drop := min(first-r.base, len(r.buf)); drop > 0 {
+		r.buf = append(r.buf[:0], r.buf[drop:]...)
+		r.base += drop
+	}
+	return out
+}
+
+// Flush 结束输入，返回剩余的输出样本，超出范围的位置使用最后一个样本
+func (r *Resampler) Flush() []int16 {
+	if r.inRate == r.outRate || len(r.buf) == 0 {
+		return nil
+	}
+	out := r.interpolate(true)
+	r.buf = nil
+	return out
+}
+
+// interpolate 计算输出样本，输出长度不超过已输入样本对应的长度；
+// final为false时只计算需要的后续样本已到达的输出
+func (r *Resampler) interpolate(final bool) []int16 {
+	if r.kernel != nil {
+		return r.filter(final)
+	}
+	total := int(float64(r.in) * float64(r.outRate) / float64(r.inRate))
+	var out []int16
+	for ; r.out < total; r.out++ {
+		pos := r.position(r.out)
+		idx := int(pos)
+		if idx >= r.in-1 {
+			if !final {
+				break
+			}
+			out = append(out, r.buf[len(r.buf)-1])
+			continue
+		}
+		fraction := pos - float64(idx)
+		sample1 := float64(r.buf[idx-r.base])
+		sample2 := float64(r.buf[idx+1-r.base])
+		out = append(out, int16(sample1*(1-fraction)+sample2*fraction))
+	}
+	return out
+}
+
+// filter 降采样：在每个输出位置用抗混叠滤波器对两侧的输入样本加权求和，
+// 开头之前和结尾之后的位置分别使用第一个和最后一个样本
+func (r *Resampler) filter(final bool) []int16 {
+	total := int(float64(r.in) * float64(r.outRate) / float64(r.inRate))
+	reach := r.kernel.reach
+	var out []int16
+	for ; r.out < total; r.out++ {
+		pos := r.position(r.out)
+		idx := int(pos)
+		if idx+reach >= r.in && !final {
+			break
+		}
+		var sum, weight float64
+		for k := idx - reach + 1; k <= idx+reach; k++ {
+			h := r.kernel.at(pos - float64(k))
+			if h == 0 {
+				continue
+			}
+			j := min(max(k, 0), r.in-1) - r.base
+			sum += h * float64(r.buf[j])
+			weight += h
+		}
+		v := math.Round(sum / weight)
+		out = append(out, int16(max(min(v, math.MaxInt16), math.MinInt16)))
+	}
+	return out
+}
+
+// Converter 将任意格式的交织样本流转换为16kHz单声道int16，输入块的边界可以落在样本或帧的中间
+// 非并发安全
+type Converter struct {
+	format    Format
+	frameSize int
+	rest      []byte // 不足一帧的剩余字节
+	resampler *Resampler
+}
+
+// NewConverter 创建格式转换，format描述输入的采样率、声道数和样本格式（小端）
+func NewConverter(format Format) (*Converter, error) {
+	if err := format.Validate(); err != nil {
+		return nil, err
+	}
+	if format.BitsPerSample%8 != 0 {
+		return nil, errors.New("位深必须为8的整数倍")
+	}
+	if _, err := toMono(nil, format); err != nil {
+		return nil, err
+	}
+	return &Converter{
+		format:    format,
+		frameSize: format.BitsPerSample / 8 * format.NumChannels,
+		resampler: NewResampler(format.SampleRate, SampleRate),
+	}, nil
+}
+
+// Write 输入一块字节流，返回转换后的16kHz单声道样本
+func (c *Converter) Write(data []byte) ([]int16, error) {
+	if len(c.rest) > 0 {
+		data = append(c.rest, data...)
+		c.rest = nil
+	}
+	if n := len(data) % c.frameSize; n > 0 {
+		c.rest = append([]byte(nil), data[len(data)-n:]...)
+		data = data[:len(data)-n]
+	}
+	mono, err := toMono(data, c.format)
+	if err != nil {
+		return nil, err
+	}
+	return c.resampler.Process(mono), nil
+}
+
+// Flush 结束输入，返回剩余的样本；不足一帧的剩余字节被丢弃
+func (c *Converter) Flush() []int16 {
+	c.rest = nil
+	return c.resampler.Flush()
+}
@@ -0,0 +1,220 @@
+package audio
+
+import (
+	"math"
+	"sort"
+)
+
+// Segment 表示一段语音区间，Start和End为样本下标（左闭右开）
+type Segment struct {
+	Start int
+	End   int
+}
+
+// Len 返回区间包含的样本数
+func (s Segment) Len() int {
+	return s.End - s.Start
+}
+
+// Seconds 返回区间在给定采样率下的时长（秒）
+func (s Segment) Seconds(sampleRate int) float64 {
+	return float64(s.Len()) / float64(sampleRate)
+}
+
+// VADConfig 基于能量的语音活动检测配置
+type VADConfig struct {
+	FrameMs      float64 // 帧长（毫秒）
+	HopMs        float64 // 帧移（毫秒）
+	MarginDB     float64 // 语音帧能量需高于噪声底的分贝数
+	FloorDB      float64 // 语音帧能量的绝对下限（dBFS），低于该值一律视为静音
+	MinSpeechMs  float64 // 短于该时长的语音段被丢弃
+	MinSilenceMs float64 // 短于该时长的静音间隔被合并
+	PadMs        float64 // 每个语音段前后扩展的时长
+}
+
+// DefaultVADConfig 返回默认的语音活动检测配置
+func DefaultVADConfig() VADConfig {
+	return VADConfig{
+		FrameMs:      25,
+		HopMs:        10,
+		MarginDB:     6,
+		FloorDB:      -55,
+		MinSpeechMs:  100,
+		MinSilenceMs: 200,
+		PadMs:        30,
+	}
+}
+
+// 噪声底取帧能量的该分位数
+const noisePercentile = 0.1
+
+// frameEnergies 计算每帧的平均能量（dBFS）
+func frameEnergies(pcm []int16, frameLen, hop int) []float64 {
+	if len(pcm) < frameLen {
+		if len(pcm) == 0 {
+			return nil
+		}
+		frameLen = len(pcm)
+	}
+	n := (len(pcm)-frameLen)/hop + 1
+	energies := make([]float64, n)
+	for i := range energies {
+		energies[i] = PowerDB(pcm[i*hop : i*hop+frameLen])
+	}
+	return energies
+}
+
+// PowerDB 计算样本的平均功率（dBFS），全零时返回-100
+func PowerDB(pcm []int16) float64 {
+	var sum float64
+	for _, v := range pcm {
+		x := float64(v) / 32768
+		sum += x * x
+	}
+	if sum == 0 {
+		return -100
+	}
+	return 10 * math.Log10(sum/float64(len(pcm)))
+}
+
+// percentile 返回数据的p分位数，不修改原数据
+func percentile(data []float64, p float64) float64 {
+	sorted := append([]float64(nil), data...)
+	sort.Float64s(sorted)
+	return sorted[int(p*float64(len(sorted)-1))]
+}
+
+// DetectSpeech 基于帧能量检测语音段
+// 门限取噪声底（帧能量的10%分位数）加MarginDB与FloorDB中的较大值，
+// 随后合并过短的静音间隔、丢弃过短的语音段，并向两侧扩展PadMs
+//
+// 参数:
+//   - pcm: PCM数据
+//   - sampleRate: 采样率
+//   - cfg: 检测配置
+//
+// 返回:
+//   - 按时间排序、互不重叠的语音段
+func DetectSpeech(pcm []int16, sampleRate int, cfg VADConfig) []Segment {
+	frameLen := max(int(cfg.FrameMs*float64(sampleRate)/1000), 1)
+	hop := max(int(cfg.HopMs*float64(sampleRate)/1000), 1)
+	energies := frameEnergies(pcm, frameLen, hop)
+	if len(energies) == 0 {
+		return nil
+	}
+	threshold := math.Max(percentile(energies, noisePercentile)+cfg.MarginDB, cfg.FloorDB)
+
+	// 连续的语音帧组成语音段
+	var segs []Segment
+	for i, e := range energies {
+		if e < threshold {
+			continue
+		}
+		start, end := i*hop, min(i*hop+frameLen, len(pcm))
+		if n := len(segs); n > 0 && start <= segs[n-1].End {
+			segs[n-1].End = end
+			continue
+		}
+		segs = append(segs, Segment{Start: start, End: end})
+	}
+
+	// 合并过短的静音间隔
+	minSilence := int(cfg.MinSilenceMs * float64(sampleRate) / 1000)
+	var merged []Segment
+	for _, s := range segs {
+		if n := len(merged); n > 0 && s.Start-merged[n-1].End < minSilence {
+			merged[n-1].End = s.End
+			continue
+		}
+		merged = append(merged, s)
+	}
+
+	// 丢弃过短的语音段并扩展边界
+	minSpeech := int(cfg.MinSpeechMs * float64(sampleRate) / 1000)
+	pad := int(cfg.PadMs * float64(sampleRate) / 1000)
+	result := merged[:0]
+	for _, s := range merged {
+		if s.Len() < minSpeech {
+			continue
+		}
+		s.Start, s.End = max(s.Start-pad, 0), min(s.End+pad, len(pcm))
+		if n := len(result); n > 0 && s.Start <= result[n-1].End {
+			result[n-1].End = s.End
+			continue
+		}
+		result = append(result, s)
+	}
+	return result
+}
+
+// SpeechSeconds 返回语音段的总时长（秒）
+func SpeechSeconds(segs []Segment, sampleRate int) float64 {
+	var n int
+	for _, s := range segs {
+		n += s.Len()
+	}
+	return float64(n) / float64(sampleRate)
+}
+
+// ExtractSegments 拼接所有语音段的样本
+func ExtractSegments(pcm []int16, segs []Segment) []int16 {
+	var n int
+	for _, s := range segs {
+		n += s.Len()
+	}
+	out := make([]int16, 0, n)
+	for _, s := range segs {
+		out = append(out, pcm[s.Start:s.End]...)
+	}
+	return out
+}
+
+// EstimateSNR 根据语音段估计信噪比（分贝）
+// 信号功率取语音段内的平均功率，噪声功率取语音段外的平均功率；
+// 语音段外没有样本时，噪声功率取帧能量的10%分位数。噪声功率不低于1个量化单位
+//
+// 参数:
+//   - pcm: PCM数据
+//   - sampleRate: 采样率
+//   - segs: DetectSpeech检测到的语音段
+//
+// 返回:
+//   - 信噪比（分贝），没有语音段时返回0
+func EstimateSNR(pcm []int16, sampleRate int, segs []Segment) float64 {
+	if len(segs) == 0 {
+		return 0
+	}
+	var speechSum, noiseSum float64
+	var speechN, noiseN int
+	pos := 0
+	for _, s := range segs {
+		for _, v := range pcm[pos:s.Start] {
+			noiseSum += float64(v) * float64(v)
+		}
+		noiseN += s.Start - pos
+		for _, v := range pcm[s.Start:s.End] {
+			speechSum += float64(v) * float64(v)
+		}
+		speechN += s.Len()
+		pos = s.End
+	}
+	for _, v := range pcm[pos:] {
+		noiseSum += float64(v) * float64(v)
+	}
+	noiseN += len(pcm) - pos
+
+	speech := speechSum / float64(speechN)
+	var noise float64
+	if noiseN > 0 {
+		noise = noiseSum / float64(noiseN)
+	} else {
+		frameLen := max(int(DefaultVADConfig().FrameMs*float64(sampleRate)/1000), 1)
+		noiseDB := percentile(frameEnergies(pcm, frameLen, frameLen), noisePercentile)
+		noise = math.Pow(10, noiseDB/10) * 32768 * 32768
+	}
+	noise = math.Max(noise, 1)
+	if speech <= noise {
+		return 0
+	}
+	return 10 * math.Log10(speech/noise)
+}
@@ -0,0 +1,288 @@
+// Package batch 对一批录音做两两比较：每个文件只提取一次嵌入向量（并行、可缓存），
+// 计算N×N相似度矩阵，并可按阈值聚类，用于在数据集中查找重复的说话人
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/seastart/3dspeaker-onnx-go/audio"
+	"github.com/seastart/3dspeaker-onnx-go/diarize"
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// DefaultExtensions 默认参与比较的音频文件扩展名
+var DefaultExtensions = []string{".wav", ".pcm"}
+
+// ListFiles 列出目录中扩展名匹配的音频文件（不区分大小写），按路径排序
+//
+// 参数:
+//   - dir: 目录路径
+//   - recursive: 是否包含子目录
+//   - exts: 扩展名列表（带"."），为空时使用DefaultExtensions
+func ListFiles(dir string, recursive bool, exts []string) ([]string, error) {
+	if len(exts) == 0 {
+		exts = DefaultExtensions
+	}
+	match := func(name string) bool {
+		ext := filepath.Ext(name)
+		for _, e := range exts {
+			if strings.EqualFold(ext, e) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if match(d.Name()) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("列出目录 %s 失败: %w", dir, err)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// FileError 单个文件的处理错误
+type FileError struct {
+	Path string
+	Err  error
+}
+
+// Error 实现error接口
+func (e *FileError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+// Unwrap 返回原始错误
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// Result 批量比较的结果
+type Result struct {
+	Files      []string             // 成功提取嵌入向量的文件，与Embeddings、Scores的行列一一对应
+	Embeddings []*speaker.Embedding // 各文件的嵌入向量
+	Scores     [][]float32          // Compare计算的余弦相似度矩阵，Embed不填充（Cluster在需要时计算）
+	Failed     []*FileError         // 读取或提取失败的文件，不参与比较
+	CacheHits  int                  // 从缓存中得到的嵌入向量数
+}
+
+// Embed 并行提取每个文件的嵌入向量，并发度等于会话池的大小
+// 内容哈希已在缓存中的文件不再提取；单个文件失败时记入Result.Failed而不中止，
+// 只有ctx取消或会话池关闭时返回错误
+//
+// 参数:
+//   - ctx: 上下文
+//   - pool: Speaker会话池
+//   - paths: 音频文件路径[必须是16khz单声道音频]
+//   - cache: 嵌入向量缓存，为nil时不使用缓存
+func Embed(ctx context.Context, pool *speaker.Pool, paths []string, cache *Cache) (*Result, error) {
+	type item struct {
+		emb *speaker.Embedding
+		hit bool
+		err error
+	}
+	items := make([]item, len(paths))
+
+	var (
+		wg       sync.WaitGroup
+		fatalErr error
+		once     sync.Once
+	)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	indices := make(chan int)
+	for w := 0; w < pool.Size(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				emb, hit, err := embedFile(ctx, pool, paths[i], cache)
+				if err != nil && (errors.Is(err, speaker.ErrPoolClosed) || ctx.Err() != nil) {
+					once.Do(func() {
+						fatalErr = err
+						cancel()
+					})
+					continue
+				}
+				items[i] = item{emb: emb, hit: hit, err: err}
+			}
+		}()
+	}
+feed:
+	for i := range paths {
+		select {
+		case indices <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indices)
+	wg.Wait()
+	if fatalErr == nil {
+		fatalErr = ctx.Err()
+	}
+	if fatalErr != nil {
+		return nil, fatalErr
+	}
+
+	result := &Result{}
+	for i, it := range items {
+		if it.err != nil {
+			result.Failed = append(result.Failed, &FileError{Path: paths[i], Err: it.err})
+			continue
+		}
+		result.Files = append(result.Files, paths[i])
+		result.Embeddings = append(result.Embeddings, it.emb)
+		if it.hit {
+			result.CacheHits++
+		}
+	}
+	return result, nil
+}
+
+// embedFile 读取一个文件并提取嵌入向量，返回是否命中缓存
+func embedFile(ctx context.Context, pool *speaker.Pool, path string, cache *Cache) (*speaker.Embedding, bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("读取音频失败: %w", err)
+	}
+	var hash string
+	if cache != nil {
+		hash = Hash(data)
+		if emb, ok := cache.Get(hash); ok {
+			return emb, true, nil
+		}
+	}
+	pcm, err := audio.Decode(data)
+	if err != nil {
+		return nil, false, fmt.Errorf("解析音频失败: %w", err)
+	}
+
+	var emb *speaker.Embedding
+	err = pool.Do(ctx, func(s *speaker.Speaker) error {
+		emb, err = s.ExtractEmbedding(pcm)
+		return err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if cache != nil {
+		cache.Put(hash, emb)
+	}
+	return emb, false, nil
+}
+
+// Compare 提取全部文件的嵌入向量并计算N×N余弦相似度矩阵
+// 参数同Embed，结果的Scores[i][j]为Files[i]与Files[j]的相似度
+func Compare(ctx context.Context, pool *speaker.Pool, paths []string, cache *Cache) (*Result, error) {
+	result, err := Embed(ctx, pool, paths, cache)
+	if err != nil {
+		return nil, err
+	}
+	result.Scores = speaker.ScoreMatrix(result.Embeddings, result.Embeddings)
+	return result, nil
+}
+
+// Cluster 聚类结果中的一类
+type Cluster struct {
+	Files     []string `json:"files"`
+	MeanScore float64  `json:"mean_score"` // 类内两两相似度的平均值，单个文件的类为1
+}
+
+// Summary 聚类摘要
+type Summary struct {
+	Threshold  float64   `json:"threshold"`
+	Clusters   []Cluster `json:"clusters"`   // 按文件数从多到少排序，文件数相同时按首个文件在Files中的顺序
+	Singletons int       `json:"singletons"` // 只有一个文件的类的数量
+}
+
+// Cluster 按余弦相似度阈值对文件做平均链接的层次聚类
+// 同一类中的文件可视为同一说话人，可用于查找数据集中的重复说话人；
+// 结果来自Embed、没有相似度矩阵时先计算并填充Scores
+//
+// 参数:
+//   - threshold: 停止合并的平均余弦相似度阈值
+//   - numClusters: 已知类别数，<=0表示由阈值决定
+func (r *Result) Cluster(threshold float64, numClusters int) *Summary {
+	summary := &Summary{Threshold: threshold}
+	if len(r.Embeddings) == 0 {
+		return summary
+	}
+	if len(r.Scores) != len(r.Embeddings) {
+		r.Scores = speaker.ScoreMatrix(r.Embeddings, r.Embeddings)
+	}
+	embs := make([][]float32, len(r.Embeddings))
+	for i, e := range r.Embeddings {
+		embs[i] = normalized(e.GetData())
+	}
+	labels := diarize.Agglomerative(embs, threshold, numClusters)
+
+	groups := make(map[int][]int)
+	for i, l := range labels {
+		groups[l] = append(groups[l], i)
+	}
+	for l := 0; l < len(groups); l++ {
+		members := groups[l]
+		c := Cluster{MeanScore: 1}
+		var sum float64
+		var pairs int
+		for a, i := range members {
+			c.Files = append(c.Files, r.Files[i])
+			for _, j := range members[a+1:] {
+				sum += float64(r.Scores[i][j])
+				pairs++
+			}
+		}
+		if pairs > 0 {
+			c.MeanScore = sum / float64(pairs)
+		} else {
+			summary.Singletons++
+		}
+		summary.Clusters = append(summary.Clusters, c)
+	}
+	sort.SliceStable(summary.Clusters, func(i, j int) bool {
+		return len(summary.Clusters[i].Files) > len(summary.Clusters[j].Files)
+	})
+	return summary
+}
+
+// normalized 返回L2归一化的副本，范数为0时原样复制
+func normalized(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	out := append([]float32(nil), v...)
+	if sum == 0 {
+		return out
+	}
+	inv := float32(1 / math.Sqrt(sum))
+	for i := range out {
+		out[i] *= inv
+	}
+	return out
+}
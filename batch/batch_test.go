@@ -0,0 +1,150 @@
+package batch
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// writePCM 写入一个原始PCM文件并返回其内容
+func writePCM(t *testing.T, path string, value int16) []byte {
+	t.Helper()
+	data := make([]byte, 3200)
+	for i := 0; i < len(data); i += 2 {
+		binary.LittleEndian.PutUint16(data[i:], uint16(value))
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// TestListFiles 测试按扩展名列出文件
+func TestListFiles(t *testing.T) {
+	dir := t.TempDir()
+	os.Mkdir(filepath.Join(dir, "sub"), 0755)
+	for _, name := range []string{"b.wav", "a.WAV", "c.txt", "sub/d.wav"} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0644)
+	}
+
+	got, err := ListFiles(dir, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "a.WAV"), filepath.Join(dir, "b.wav")}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("非递归列出结果错误: %v", got)
+	}
+	got, _ = ListFiles(dir, true, []string{".wav"})
+	if len(got) != 3 || got[2] != filepath.Join(dir, "sub", "d.wav") {
+		t.Fatalf("递归列出结果错误: %v", got)
+	}
+}
+
+// TestCache 测试缓存的持久化和键不一致时作废
+func TestCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	cache, err := OpenCache(path, "model-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 写入后修改原向量不影响缓存的内容
+	data := []float32{0.6, 0.8}
+	put := speaker.NewEmbeddingWithNorm(data, 12.5)
+	cache.Put("h1", put)
+	put.GetData()[0] = 0
+	if err := cache.Save(); err != nil {
+		t.Fatalf("保存缓存失败: %v", err)
+	}
+
+	cache, _ = OpenCache(path, "model-a")
+	emb, ok := cache.Get("h1")
+	if !ok || !reflect.DeepEqual(emb.GetData(), data) || emb.Norm() != 12.5 {
+		t.Fatalf("重新打开后应能读到缓存的嵌入向量和原始范数: %v %v", emb.GetData(), emb.Norm())
+	}
+
+	// 旧版本格式的缓存作废
+	if err := os.WriteFile(path, []byte(`{"key":"model-a","entries":{"h1":[0.6,0.8]}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if cache, err = OpenCache(path, "model-a"); err != nil || cache.Len() != 0 {
+		t.Fatalf("旧版本格式的缓存应为空: %v", err)
+	}
+	cache, _ = OpenCache(path, "model-b")
+	if cache.Len() != 0 {
+		t.Fatalf("键不一致时缓存应为空，实际有%d条", cache.Len())
+	}
+}
+
+// TestEmbedCache 测试命中缓存的文件不经过模型，失败的文件记入Failed
+func TestEmbedCache(t *testing.T) {
+	dir := t.TempDir()
+	a := writePCM(t, filepath.Join(dir, "a.pcm"), 100)
+	writePCM(t, filepath.Join(dir, "b.pcm"), 200)
+	cache, _ := OpenCache("", "model")
+	cache.Put(Hash(a), speaker.NewEmbedding([]float32{1, 0}))
+
+	// 未加载模型的实例提取时必然失败，只有命中缓存的文件能成功
+	pool, err := speaker.NewPool(2, func() (*speaker.Speaker, error) { return &speaker.Speaker{}, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	paths, _ := ListFiles(dir, false, nil)
+	result, err := Compare(context.Background(), pool, paths, cache)
+	if err != nil {
+		t.Fatalf("批量比较失败: %v", err)
+	}
+	if len(result.Files) != 1 || result.Files[0] != paths[0] || result.CacheHits != 1 {
+		t.Fatalf("只有a.pcm应命中缓存: %+v", result)
+	}
+	if len(result.Failed) != 1 || result.Failed[0].Path != paths[1] {
+		t.Fatalf("b.pcm应记为失败: %+v", result.Failed)
+	}
+	if len(result.Scores) != 1 || result.Scores[0][0] < 0.999 {
+		t.Fatalf("相似度矩阵错误: %v", result.Scores)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Embed(ctx, pool, paths, cache); err == nil {
+		t.Fatal("ctx取消时应返回错误")
+	}
+}
+
+// TestCluster 测试聚类摘要
+func TestCluster(t *testing.T) {
+	r := &Result{
+		Files: []string{"a1", "b1", "a2", "c1"},
+		Embeddings: []*speaker.Embedding{
+			speaker.NewEmbedding([]float32{1, 0.1, 0}),
+			speaker.NewEmbedding([]float32{0, 1, 0}),
+			speaker.NewEmbedding([]float32{2, 0, 0.1}),
+			speaker.NewEmbedding([]float32{0, 0, 1}),
+		},
+	}
+	r.Scores = speaker.ScoreMatrix(r.Embeddings, r.Embeddings)
+
+	summary := r.Cluster(0.7, 0)
+	if len(summary.Clusters) != 3 || summary.Singletons != 2 {
+		t.Fatalf("应聚成3类，其中2个单文件类: %+v", summary)
+	}
+	if !reflect.DeepEqual(summary.Clusters[0].Files, []string{"a1", "a2"}) || summary.Clusters[0].MeanScore < 0.99 {
+		t.Fatalf("最大的类应为a1、a2: %+v", summary.Clusters[0])
+	}
+	if summary.Clusters[1].Files[0] != "b1" || summary.Clusters[1].MeanScore != 1 {
+		t.Fatalf("单文件类应按首次出现的顺序排列: %+v", summary.Clusters[1:])
+	}
+
+	// Embed的结果没有相似度矩阵，聚类时按需计算
+	r.Scores = nil
+	if again := r.Cluster(0.7, 0); !reflect.DeepEqual(again, summary) {
+		t.Fatalf("没有相似度矩阵时的聚类结果不一致: %+v", again)
+	}
+}
@@ -0,0 +1,138 @@
+package batch
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// Hash 返回音频文件内容的SHA-256，作为缓存的键
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Cache 以文件内容哈希为键的嵌入向量缓存，可安全地并发使用
+// 缓存绑定一个键（一般为模型指纹加上影响嵌入向量的设置），键不一致时旧的缓存内容全部作废
+type Cache struct {
+	mu      sync.Mutex
+	path    string
+	key     string
+	entries map[string]cacheEntry
+	dirty   bool
+}
+
+// cacheVersion 缓存文件格式的版本，版本不一致的缓存全部作废
+const cacheVersion = 2
+
+// cacheFile 缓存文件的JSON格式
+type cacheFile struct {
+	Version int                   `json:"version"`
+	Key     string                `json:"key"`
+	Entries map[string]cacheEntry `json:"entries"`
+}
+
+// cacheEntry 一条缓存的嵌入向量及其原始范数
+type cacheEntry struct {
+	Data []float32 `json:"data"`
+	Norm float32   `json:"norm"` // 模型原始输出的L2范数，见Embedding.Norm
+}
+
+// OpenCache 打开缓存文件，文件不存在、格式版本或键不一致时返回空缓存
+// path为空时返回只存在于内存中的缓存
+func OpenCache(path, key string) (*Cache, error) {
+	c := &Cache{path: path, key: key, entries: make(map[string]cacheEntry)}
+	if path == "" {
+		return c, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取缓存文件失败: %w", err)
+	}
+	// 先只解析版本和键，旧版本的条目格式不同
+	var head struct {
+		Version int    `json:"version"`
+		Key     string `json:"key"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return nil, fmt.Errorf("解析缓存文件失败: %w", err)
+	}
+	if head.Version != cacheVersion || head.Key != key {
+		c.dirty = true
+		return c, nil
+	}
+	var f cacheFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("解析缓存文件失败: %w", err)
+	}
+	if f.Entries != nil {
+		c.entries = f.Entries
+	}
+	return c, nil
+}
+
+// Len 返回缓存的嵌入向量数量
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Get 按内容哈希查找嵌入向量
+func (c *Cache) Get(hash string) (*speaker.Embedding, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[hash]
+	if !ok {
+		return nil, false
+	}
+	return speaker.NewEmbeddingWithNorm(e.Data, e.Norm), true
+}
+
+// Put 写入嵌入向量的副本，调用Save后才会持久化
+func (c *Cache) Put(hash string, emb *speaker.Embedding) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[hash] = cacheEntry{Data: append([]float32(nil), emb.GetData()...), Norm: emb.Norm()}
+	c.dirty = true
+}
+
+// Save 将缓存写回文件，先写临时文件再重命名，内容未变化或未指定路径时不做任何操作
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.path == "" || !c.dirty {
+		return nil
+	}
+	data, err := json.Marshal(cacheFile{Version: cacheVersion, Key: c.key, Entries: c.entries})
+	if err != nil {
+		return fmt.Errorf("序列化缓存失败: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("创建缓存文件失败: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("写入缓存文件失败: %w", err)
+	}
+	c.dirty = false
+	return nil
+}
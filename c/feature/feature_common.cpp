@@ -90,7 +90,7 @@ void speakerlab::MelBankProcessor::init_mel_bins(float sample_frequency, int win
     if (opts_.high_freq > 0.0) high_frequency = opts_.high_freq;
     else high_frequency = nyquist + opts_.high_freq;
     
-    std::cout << "In init_mel_bins: num_fft_bins = " << num_fft_bins 
+    std::clog << "In init_mel_bins: num_fft_bins = " << num_fft_bins 
               << " num_bins = " << num_bins
               << " nyquist = " << nyquist
               << " low_frequency = " << low_frequency
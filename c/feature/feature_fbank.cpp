@@ -5,6 +5,8 @@
 #include "feature_fbank.h"
 #include "feature_functions.h"
 
+#include <algorithm>
+
 
 speakerlab::FbankComputer::FbankComputer(const speakerlab::FbankOptions &opts) : opts_(opts),
                                                                                  frame_preprocessor_(opts.frame_opts),
@@ -17,6 +19,7 @@ speakerlab::FbankComputer::FbankComputer(const speakerlab::FbankOptions &opts) :
 
     int padded_window_length = opts_.frame_opts.padded_window_size();
     mel_bank_processor_.init_mel_bins(opts.frame_opts.sample_freq, padded_window_length);
+    mel_bins_ = mel_bank_processor_.get_mel_bins();
 }
 
 // 直接从PCM数据提取特征（新接口）
@@ -29,7 +32,6 @@ speakerlab::Feature speakerlab::FbankComputer::compute_feature_from_pcm(const sh
     // 计算相关参数
     int frame_length = opts_.compute_window_size();
     int frame_shift = opts_.compute_window_shift();
-    int fft_n = round_up_to_nearest_power_of_two(frame_length);
     
     // 将int16类型的PCM数据转换为float类型
     Wave wav_data(pcm_length);
@@ -50,52 +52,57 @@ speakerlab::Feature speakerlab::FbankComputer::compute_feature_from_pcm(const sh
     Feature feature;
     feature.resize(num_frames);
 
+    for (int i = 0; i < num_frames; i++) {
+        compute_frame(wav_data.data() + i * frame_shift, feature[i]);
+    }
+    return feature;
+}
+
+// 计算一帧的FBANK特征：预处理、FFT、功率谱、梅尔滤波和取对数
+void speakerlab::FbankComputer::compute_frame(const float* frame_data, std::vector<float>& feature) {
+    int frame_length = opts_.compute_window_size();
+    int fft_n = round_up_to_nearest_power_of_two(frame_length);
     float epsilon = std::numeric_limits<float>::epsilon();
     int fbank_num_bins = opts_.get_fbank_num_bins();
-    std::vector<std::pair<int, std::vector<float>>> mel_bins = mel_bank_processor_.get_mel_bins();
-    
-    for (int i = 0; i < num_frames; i++) {
-        std::vector<float> cur_wav_data(wav_data.data() + i * frame_shift,
-                                        wav_data.data() + i * frame_shift + frame_length);
-        // 包含抖动、预处理等
-        frame_preprocessor_.frame_pre_process(cur_wav_data);
-
-        // 构建FFT
-        std::vector<std::complex<float>> cur_window_data(fft_n);
-        for (int j = 0; j < fft_n; j++) {
-            if (j < frame_length) {
-                cur_window_data[j] = std::complex<float>(cur_wav_data[j], 0.0);
-            } else {
-                cur_window_data[j] = std::complex<float>(0.0, 0.0);
-            }
+
+    std::vector<float> cur_wav_data(frame_data, frame_data + frame_length);
+    // 包含抖动、预处理等
+    frame_preprocessor_.frame_pre_process(cur_wav_data);
+
+    // 构建FFT
+    std::vector<std::complex<float>> cur_window_data(fft_n);
+    for (int j = 0; j < fft_n; j++) {
+        if (j < frame_length) {
+            cur_window_data[j] = std::complex<float>(cur_wav_data[j], 0.0);
+        } else {
+            cur_window_data[j] = std::complex<float>(0.0, 0.0);
         }
-        custom_fft(bit_rev_index_, sin_tbl_, cur_window_data);
-        std::vector<float> power(fft_n / 2);
+    }
+    custom_fft(bit_rev_index_, sin_tbl_, cur_window_data);
+    std::vector<float> power(fft_n / 2);
+    for (int j = 0; j < fft_n / 2; j++) {
+        power[j] = cur_window_data[j].real() * cur_window_data[j].real() +
+                   cur_window_data[j].imag() * cur_window_data[j].imag();
+    }
+    if (!opts_.use_power) {
         for (int j = 0; j < fft_n / 2; j++) {
-            power[j] = cur_window_data[j].real() * cur_window_data[j].real() +
-                       cur_window_data[j].imag() * cur_window_data[j].imag();
+            power[j] = powf(power[j], 0.5);
         }
-        if (!opts_.use_power) {
-            for (int j = 0; j < fft_n / 2; j++) {
-                power[j] = powf(power[j], 0.5);
-            }
+    }
+    // 梯度滤波
+    feature.resize(fbank_num_bins);
+    for (int j = 0; j < fbank_num_bins; j++) {
+        float mel_energy = 0.0;
+        int start_index = mel_bins_[j].first;
+        for (int k = 0; k < mel_bins_[j].second.size(); k++) {
+            mel_energy += mel_bins_[j].second[k] * power[k + start_index];
         }
-        // 梯度滤波
-        feature[i].resize(opts_.get_fbank_num_bins());
-        for (int j = 0; j < fbank_num_bins; j++) {
-            float mel_energy = 0.0;
-            int start_index = mel_bins[j].first;
-            for (int k = 0; k < mel_bins[j].second.size(); k++) {
-                mel_energy += mel_bins[j].second[k] * power[k + start_index];
-            }
-            if (opts_.use_log_fbank) {
-                if (mel_energy < epsilon) mel_energy = epsilon;
-                mel_energy = logf(mel_energy);
-            }
-            feature[i][j] = mel_energy;
+        if (opts_.use_log_fbank) {
+            if (mel_energy < epsilon) mel_energy = epsilon;
+            mel_energy = logf(mel_energy);
         }
+        feature[j] = mel_energy;
     }
-    return feature;
 }
 
 // 检查PCM数据是否有效
@@ -131,3 +138,55 @@ bool speakerlab::FbankComputer::check_pcm_data(int pcm_length) {
     
     return true;
 }
+
+speakerlab::StreamingFbankComputer::StreamingFbankComputer(const speakerlab::FbankComputer &computer) :
+        computer_(computer) {
+}
+
+// 输入一块PCM数据，只计算新产生的完整帧
+// 帧的起点与整段计算时相同（第i帧从第i*frame_shift个样本开始），因此输出与compute_feature_from_pcm一致
+speakerlab::Feature speakerlab::StreamingFbankComputer::accept_pcm(const short* pcm_data, int pcm_length) {
+    if (pcm_length < 0 || (pcm_length > 0 && !pcm_data)) {
+        throw std::invalid_argument("PCM data is invalid");
+    }
+    int frame_length = computer_.frame_length();
+    int frame_shift = computer_.frame_shift();
+    if (frame_length < 2 || frame_shift <= 0) {
+        throw std::invalid_argument("Invalid frame length or frame shift");
+    }
+
+    // 跳过上一帧之后、下一帧起点之前的样本
+    int skipped = static_cast<int>(std::min<long long>(skip_, pcm_length));
+    skip_ -= skipped;
+    leftover_.reserve(leftover_.size() + pcm_length - skipped);
+    for (int i = skipped; i < pcm_length; i++) {
+        leftover_.push_back(static_cast<float>(pcm_data[i]) / 32768.0f);
+    }
+
+    Feature feature;
+    int num_samples = leftover_.size();
+    if (num_samples < frame_length) {
+        return feature;
+    }
+    int num_frames = 1 + ((num_samples - frame_length) / frame_shift);
+    feature.resize(num_frames);
+    for (int i = 0; i < num_frames; i++) {
+        computer_.compute_frame(leftover_.data() + i * frame_shift, feature[i]);
+    }
+    // 保留下一帧起点之后的样本；帧移大于帧长时下一帧起点可能还未到达，记录需要跳过的样本数
+    long long next_start = static_cast<long long>(num_frames) * frame_shift;
+    if (next_start >= static_cast<long long>(leftover_.size())) {
+        skip_ = next_start - leftover_.size();
+        leftover_.clear();
+    } else {
+        leftover_.erase(leftover_.begin(), leftover_.begin() + next_start);
+    }
+    num_frames_ += num_frames;
+    return feature;
+}
+
+void speakerlab::StreamingFbankComputer::reset() {
+    leftover_.clear();
+    skip_ = 0;
+    num_frames_ = 0;
+}
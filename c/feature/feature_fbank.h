@@ -71,6 +71,17 @@ namespace speakerlab {
         // 检查PCM数据是否有效（简化版，仅检查长度）
         bool check_pcm_data(int pcm_length);
 
+        // 计算一帧的FBANK特征
+        // frame_data: frame_length()个已归一化到[-1,1]的样本
+        // feature: 输出num_bins()维特征
+        void compute_frame(const float* frame_data, std::vector<float>& feature);
+
+        int frame_length() { return opts_.compute_window_size(); }
+
+        int frame_shift() { return opts_.compute_window_shift(); }
+
+        int num_bins() { return opts_.get_fbank_num_bins(); }
+
     private:
         FbankOptions opts_;
         FramePreprocessor frame_preprocessor_;
@@ -78,6 +89,32 @@ namespace speakerlab {
         float log_energy_floor_;
         std::vector<int> bit_rev_index_;
         std::vector<float> sin_tbl_;
+        std::vector<std::pair<int, std::vector<float>>> mel_bins_;
+    };
+
+    // 流式FBANK特征提取器，按块输入PCM数据，只计算新产生的完整帧
+    // 不足一帧的样本保留到下次输入，以任意分块输入同一段音频时，输出与compute_feature_from_pcm一致
+    class StreamingFbankComputer {
+    public:
+        // 复制computer的配置和状态，之后与computer互不影响
+        explicit StreamingFbankComputer(const FbankComputer &computer);
+
+        // 输入一块PCM数据，返回本次新产生的帧
+        // pcm_data: 单声道16kHz的int16 PCM数据
+        // pcm_length: PCM数据长度（样本数），可以为0
+        Feature accept_pcm(const short* pcm_data, int pcm_length);
+
+        // 丢弃保留的样本，帧计数清零
+        void reset();
+
+        // 到目前为止输出的帧数
+        long long num_frames() const { return num_frames_; }
+
+    private:
+        FbankComputer computer_;
+        Wave leftover_; // 下一帧起点开始的尚未用完的样本
+        long long skip_ = 0; // 帧移大于帧长时，下一帧起点之前尚未到达的样本数，到达后丢弃
+        long long num_frames_ = 0;
     };
 }
 
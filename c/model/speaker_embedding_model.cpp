@@ -10,9 +10,18 @@ speakerlab::OnnxSpeakerEmbeddingModel::OnnxSpeakerEmbeddingModel(const std::stri
     session_ptr_ = std::make_shared<Ort::Session>(env_, onnx_file.c_str(), session_options_);
 }
 
+int64_t speakerlab::OnnxSpeakerEmbeddingModel::embedding_dim() const {
+    Ort::TypeInfo type_info = session_ptr_->GetOutputTypeInfo(0);
+    std::vector<int64_t> shape = type_info.GetTensorTypeAndShapeInfo().GetShape();
+    if (shape.empty()) {
+        return -1;
+    }
+    return shape.back();
+}
+
 void speakerlab::OnnxSpeakerEmbeddingModel::describe_embedding_model() {
     size_t num_input_nodes = session_ptr_->GetInputCount();
-    std::cout << "Number of input nodes: " << num_input_nodes << std::endl;
+    std::clog << "Number of input nodes: " << num_input_nodes << std::endl;
     
     // 针对新版ONNX Runtime API的兼容处理
     for (size_t i = 0; i < num_input_nodes; i++) {
@@ -21,7 +30,7 @@ void speakerlab::OnnxSpeakerEmbeddingModel::describe_embedding_model() {
         auto tensor_info = type_info.GetTensorTypeAndShapeInfo();
         
         // 输出类型信息，不尝试获取输入名称
-        std::cout << "Input " << i << " : type=" << tensor_info.GetElementType() 
+        std::clog << "Input " << i << " : type=" << tensor_info.GetElementType() 
                   << ", shape=" << tensor_info.GetShape().size() << "D" << std::endl;
     }
 }
@@ -32,6 +32,9 @@ namespace speakerlab {
 
         void extract_embedding(const speakerlab::Feature &feature, speakerlab::Embedding &embedding) override;
 
+        // 根据模型输出形状返回嵌入向量维度，维度不固定时返回-1
+        int64_t embedding_dim() const;
+
     private:
         // Ort::Session do not have default constructor, use point instead
         std::shared_ptr<Ort::Session> session_ptr_;
@@ -1,7 +1,9 @@
 #include "speaker_wrapper.h"
 #include <cmath>
+#include <algorithm>
 #include <memory>
 #include <iostream>
+#include <stdexcept>
 #include <vector>
 #include "model/speaker_embedding_model.h"
 #include "feature/feature_fbank.h"
@@ -10,6 +12,7 @@
 struct SpeakerModelWrapper {
     std::unique_ptr<speakerlab::OnnxSpeakerEmbeddingModel> model;
     std::unique_ptr<speakerlab::FbankComputer> feature_extractor;
+    int embedding_dim = 0;
     
     // 参数化构造函数
     SpeakerModelWrapper(const std::string& onnx_path, float sample_freq, float frame_shift_ms, float frame_length_ms, 
@@ -37,8 +40,20 @@ struct SpeakerModelWrapper {
             // 创建FbankComputer
             feature_extractor = std::make_unique<speakerlab::FbankComputer>(opts);
             
+            // 获取嵌入向量维度，模型输出维度未固定时用1秒静音推理一次
+            embedding_dim = static_cast<int>(model->embedding_dim());
+            if (embedding_dim <= 0) {
+                std::vector<short> silence(static_cast<size_t>(sample_freq), 0);
+                speakerlab::Embedding emb;
+                model->extract_embedding(extractFeatureFromPcm(silence.data(), silence.size()), emb);
+                embedding_dim = static_cast<int>(emb.size());
+            }
+            if (embedding_dim <= 0) {
+                throw std::runtime_error("无法确定嵌入向量维度");
+            }
+            
             // 输出参数信息
-            std::cout << "使用传入的参数创建FbankComputer [采样率=" << sample_freq 
+            std::clog << "使用传入的参数创建FbankComputer [采样率=" << sample_freq 
                       << ", 帧移=" << frame_shift_ms << "ms"
                       << ", 帧长=" << frame_length_ms << "ms"
                       << ", 滤波器数=" << num_bins
@@ -64,6 +79,63 @@ struct SpeakerModelWrapper {
     }
 };
 
+// 在特征上运行模型，按需归一化后写入调用方的缓冲区
+// 返回值与ExtractEmbedding相同
+static int embedFeature(SpeakerModelWrapper* wrapper,
+                        const speakerlab::Feature& feature,
+                        float* embedding,
+                        int embedding_size,
+                        int normalize,
+                        float* norm) {
+    // 提取嵌入向量
+    speakerlab::Embedding emb;
+    wrapper->model->extract_embedding(feature, emb);
+    
+    if (emb.empty()) {
+        std::cerr << "嵌入向量提取失败" << std::endl;
+        return 0;
+    }
+    if (static_cast<int>(emb.size()) != embedding_size) {
+        std::cerr << "嵌入向量维度" << emb.size() << "与输出缓冲区长度" << embedding_size << "不一致" << std::endl;
+        return 0;
+    }
+    
+    // 计算向量的L2范数
+    float l2 = 0.0f;
+    for (int i = 0; i < embedding_size; i++) {
+        l2 += emb[i] * emb[i];
+    }
+    l2 = std::sqrt(l2);
+    if (norm) {
+        *norm = l2;
+    }
+    
+    // 范数为0时无法归一化，也无法用于比较
+    if (l2 < 1e-10) {
+        std::cerr << "嵌入向量范数接近于0" << std::endl;
+        return -1;
+    }
+    
+    // 按需归一化后写入调用方的缓冲区
+    float scale = normalize ? 1.0f / l2 : 1.0f;
+    for (int i = 0; i < embedding_size; i++) {
+        embedding[i] = emb[i] * scale;
+    }
+    return 1;
+}
+
+// 将特征按帧连续复制到调用方的缓冲区，返回帧数，缓冲区不足时返回-1
+static int copyFeature(const speakerlab::Feature& feature, float* out, int max_frames) {
+    if (static_cast<int>(feature.size()) > max_frames) {
+        std::cerr << "特征缓冲区不足: 需要" << feature.size() << "帧，只能容纳" << max_frames << "帧" << std::endl;
+        return -1;
+    }
+    for (const auto& frame : feature) {
+        out = std::copy(frame.begin(), frame.end(), out);
+    }
+    return static_cast<int>(feature.size());
+}
+
 extern "C" {
 
 // 实现加载模型函数
@@ -88,7 +160,7 @@ SpeakerModelHandle LoadSpeakerModel(const char* onnx_model_path,
                                               num_bins, use_log, dither, use_power);
         
         // 输出成功信息
-        std::cout << "成功加载模型和特征提取器" << std::endl;
+        std::clog << "成功加载模型和特征提取器" << std::endl;
         
         return static_cast<SpeakerModelHandle>(wrapper);
     } catch (const std::exception& e) {
@@ -105,18 +177,32 @@ void FreeSpeakerModel(SpeakerModelHandle handle) {
     }
 }
 
-// 从所人数据中提取说话人嵌入向量
+// 获取模型输出的嵌入向量维度
+int GetEmbeddingDimension(SpeakerModelHandle handle) {
+    if (!handle) {
+        return 0;
+    }
+    return static_cast<SpeakerModelWrapper*>(handle)->embedding_dim;
+}
+
+// 从所人数据中提取说话人嵌入向量，直接写入调用方提供的缓冲区
 int ExtractEmbedding(SpeakerModelHandle handle, 
                      const short* pcm_data, 
                      int pcm_length, 
-                     float** embedding, 
-                     int* embedding_size) {
-    if (!handle || !pcm_data || !embedding || !embedding_size || pcm_length <= 0) {
+                     float* embedding, 
+                     int embedding_size,
+                     int normalize,
+                     float* norm) {
+    if (!handle || !pcm_data || !embedding || pcm_length <= 0) {
         std::cerr << "ExtractEmbedding参数无效" << std::endl;
         return 0;
     }
     
     auto* wrapper = static_cast<SpeakerModelWrapper*>(handle);
+    if (embedding_size != wrapper->embedding_dim) {
+        std::cerr << "输出缓冲区长度" << embedding_size << "与嵌入向量维度" << wrapper->embedding_dim << "不一致" << std::endl;
+        return 0;
+    }
     
     try {
         // 打印调试信息
@@ -131,43 +217,136 @@ int ExtractEmbedding(SpeakerModelHandle handle,
             return 0;
         }
         
-        // 提取嵌入向量
-        // std::cout << "开始提取嵌入向量..." << std::endl;
-        speakerlab::Embedding emb;
-        wrapper->model->extract_embedding(feature, emb);
-        
-        if (emb.empty()) {
-            std::cerr << "嵌入向量提取失败" << std::endl;
+        return embedFeature(wrapper, feature, embedding, embedding_size, normalize, norm);
+    } catch (const std::exception& e) {
+        std::cerr << "提取嵌入向量时出错: " << e.what() << std::endl;
+        return 0;
+    }
+}
+
+// 获取FBANK特征的形状参数
+int GetFbankInfo(SpeakerModelHandle handle, int* num_bins, int* frame_length, int* frame_shift) {
+    if (!handle) {
+        return 0;
+    }
+    auto* wrapper = static_cast<SpeakerModelWrapper*>(handle);
+    if (num_bins) {
+        *num_bins = wrapper->feature_extractor->num_bins();
+    }
+    if (frame_length) {
+        *frame_length = wrapper->feature_extractor->frame_length();
+    }
+    if (frame_shift) {
+        *frame_shift = wrapper->feature_extractor->frame_shift();
+    }
+    return 1;
+}
+
+// 计算整段PCM数据的FBANK特征
+int ComputeFbank(SpeakerModelHandle handle,
+                 const short* pcm_data,
+                 int pcm_length,
+                 float* features,
+                 int max_frames) {
+    if (!handle || !pcm_data || !features || pcm_length <= 0) {
+        std::cerr << "ComputeFbank参数无效" << std::endl;
+        return -1;
+    }
+    auto* wrapper = static_cast<SpeakerModelWrapper*>(handle);
+    try {
+        speakerlab::Feature feature = wrapper->extractFeatureFromPcm(pcm_data, pcm_length);
+        return copyFeature(feature, features, max_frames);
+    } catch (const std::exception& e) {
+        std::cerr << "计算FBANK特征时出错: " << e.what() << std::endl;
+        return -1;
+    }
+}
+
+// 创建流式FBANK特征提取器
+FbankStreamHandle CreateFbankStream(SpeakerModelHandle handle) {
+    if (!handle) {
+        return nullptr;
+    }
+    auto* wrapper = static_cast<SpeakerModelWrapper*>(handle);
+    try {
+        return static_cast<FbankStreamHandle>(new speakerlab::StreamingFbankComputer(*wrapper->feature_extractor));
+    } catch (const std::exception& e) {
+        std::cerr << "创建流式特征提取器出错: " << e.what() << std::endl;
+        return nullptr;
+    }
+}
+
+// 释放流式FBANK特征提取器
+void FreeFbankStream(FbankStreamHandle stream) {
+    if (stream) {
+        delete static_cast<speakerlab::StreamingFbankComputer*>(stream);
+    }
+}
+
+// 丢弃流式提取器中保留的样本
+void ResetFbankStream(FbankStreamHandle stream) {
+    if (stream) {
+        static_cast<speakerlab::StreamingFbankComputer*>(stream)->reset();
+    }
+}
+
+// 向流式提取器输入一块PCM数据，输出新产生的帧
+int AcceptFbankStream(FbankStreamHandle stream,
+                      const short* pcm_data,
+                      int pcm_length,
+                      float* features,
+                      int max_frames) {
+    if (!stream || pcm_length < 0 || (pcm_length > 0 && !pcm_data)) {
+        std::cerr << "AcceptFbankStream参数无效" << std::endl;
+        return -1;
+    }
+    try {
+        auto* computer = static_cast<speakerlab::StreamingFbankComputer*>(stream);
+        speakerlab::Feature feature = computer->accept_pcm(pcm_data, pcm_length);
+        if (feature.empty()) {
             return 0;
         }
-        
-        // 计算向量的L2范数
-        float norm = 0.0f;
-        for (int i = 0; i < emb.size(); i++) {
-            norm += emb[i] * emb[i];
-        }
-        norm = std::sqrt(norm);
-        
-        // 当范数为0时的处理
-        if (norm < 1e-10) {
-            std::cerr << "警告: 嵌入向量范数接近于0，无法归一化" << std::endl;
-            norm = 1.0f; // 避免除以0
+        if (!features) {
+            std::cerr << "AcceptFbankStream输出缓冲区为空" << std::endl;
+            return -1;
         }
-        
-        // 分配内存并复制嵌入向量（进行归一化）
-        int size = emb.size();
-        float* output = new float[size];
-        for (int i = 0; i < size; i++) {
-            output[i] = emb[i] / norm; // 归一化
+        return copyFeature(feature, features, max_frames);
+    } catch (const std::exception& e) {
+        std::cerr << "流式计算FBANK特征时出错: " << e.what() << std::endl;
+        return -1;
+    }
+}
+
+// 从FBANK特征中提取说话人嵌入向量
+int ExtractEmbeddingFromFeatures(SpeakerModelHandle handle,
+                                 const float* features,
+                                 int num_frames,
+                                 int num_bins,
+                                 float* embedding,
+                                 int embedding_size,
+                                 int normalize,
+                                 float* norm) {
+    if (!handle || !features || !embedding || num_frames <= 0) {
+        std::cerr << "ExtractEmbeddingFromFeatures参数无效" << std::endl;
+        return 0;
+    }
+    auto* wrapper = static_cast<SpeakerModelWrapper*>(handle);
+    if (num_bins != wrapper->feature_extractor->num_bins()) {
+        std::cerr << "特征维度" << num_bins << "与模型配置" << wrapper->feature_extractor->num_bins() << "不一致" << std::endl;
+        return 0;
+    }
+    if (embedding_size != wrapper->embedding_dim) {
+        std::cerr << "输出缓冲区长度" << embedding_size << "与嵌入向量维度" << wrapper->embedding_dim << "不一致" << std::endl;
+        return 0;
+    }
+    try {
+        speakerlab::Feature feature(num_frames);
+        for (int i = 0; i < num_frames; i++) {
+            feature[i].assign(features + static_cast<long>(i) * num_bins, features + static_cast<long>(i + 1) * num_bins);
         }
-        
-        *embedding = output;
-        *embedding_size = size;
-        
-        // std::cout << "成功提取嵌入向量，维度=" << size << ", 归一化前范数=" << norm << std::endl;
-        return 1;
+        return embedFeature(wrapper, feature, embedding, embedding_size, normalize, norm);
     } catch (const std::exception& e) {
-        std::cerr << "提取嵌入向量时出错: " << e.what() << std::endl;
+        std::cerr << "从特征提取嵌入向量时出错: " << e.what() << std::endl;
         return 0;
     }
 }
@@ -232,11 +411,4 @@ float ComputeL2Distance(const float* embedding1, int size1,
     return distance;
 }
 
-// 释放嵌入向量内存
-void FreeEmbedding(float* embedding) {
-    if (embedding) {
-        delete[] embedding;
-    }
-}
-
 } // extern "C"
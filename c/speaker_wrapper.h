@@ -10,6 +10,11 @@ extern "C" {
  */
 typedef void* SpeakerModelHandle;
 
+/**
+ * 流式FBANK特征提取器句柄
+ */
+typedef void* FbankStreamHandle;
+
 /**
  * 加载ONNX模型并初始化特征提取器
  * 
@@ -39,6 +44,14 @@ SpeakerModelHandle LoadSpeakerModel(const char* onnx_model_path,
  */
 void FreeSpeakerModel(SpeakerModelHandle handle);
 
+/**
+ * 获取模型输出的嵌入向量维度
+ * 
+ * @param handle 模型句柄
+ * @return 嵌入向量维度，失败返回0
+ */
+int GetEmbeddingDimension(SpeakerModelHandle handle);
+
 /**
  * 从所人数据中提取说话人嵌入向量[16kHz单声道int16 PCM数据]
  * 
@@ -47,15 +60,107 @@ void FreeSpeakerModel(SpeakerModelHandle handle);
  * @param handle 模型句柄
  * @param pcm_data PCM数据指针（int16类型数据）
  * @param pcm_length PCM数据长度（样本数）
- * @param embedding 输出的嵌入向量，调用方负责释放内存
- * @param embedding_size 输出的嵌入向量长度
- * @return 成功返回1，失败返回0
+ * @param embedding 调用方预先分配的输出缓冲区，嵌入向量直接写入其中
+ * @param embedding_size 输出缓冲区长度，必须等于GetEmbeddingDimension的返回值
+ * @param normalize 是否对嵌入向量做L2归一化（1表示是，0表示输出模型原始输出）
+ * @param norm 输出模型原始输出的L2范数，可为NULL
+ * @return 成功返回1，失败返回0，原始输出范数为0（无法归一化）时返回-1
  */
 int ExtractEmbedding(SpeakerModelHandle handle, 
                      const short* pcm_data, 
                      int pcm_length, 
-                     float** embedding, 
-                     int* embedding_size);
+                     float* embedding, 
+                     int embedding_size,
+                     int normalize,
+                     float* norm);
+
+/**
+ * 获取FBANK特征的形状参数
+ * 
+ * @param handle 模型句柄
+ * @param num_bins 输出每帧特征维度（梅尔滤波器组数量）
+ * @param frame_length 输出帧长（样本数）
+ * @param frame_shift 输出帧移（样本数）
+ * @return 成功返回1，失败返回0
+ */
+int GetFbankInfo(SpeakerModelHandle handle, int* num_bins, int* frame_length, int* frame_shift);
+
+/**
+ * 计算整段PCM数据的FBANK特征，不足一帧的末尾样本被丢弃
+ * 
+ * @param handle 模型句柄
+ * @param pcm_data PCM数据指针（int16类型数据）
+ * @param pcm_length PCM数据长度（样本数）
+ * @param features 调用方预先分配的输出缓冲区，按帧连续写入，每帧num_bins个值
+ * @param max_frames 输出缓冲区可容纳的帧数，不小于1 + (pcm_length - frame_length) / frame_shift
+ * @return 写入的帧数，失败返回-1
+ */
+int ComputeFbank(SpeakerModelHandle handle,
+                 const short* pcm_data,
+                 int pcm_length,
+                 float* features,
+                 int max_frames);
+
+/**
+ * 创建流式FBANK特征提取器，使用模型的特征配置，创建后与模型互不影响
+ * 
+ * @param handle 模型句柄
+ * @return 流式提取器句柄，失败时返回NULL
+ */
+FbankStreamHandle CreateFbankStream(SpeakerModelHandle handle);
+
+/**
+ * 释放流式FBANK特征提取器
+ * 
+ * @param stream 流式提取器句柄
+ */
+void FreeFbankStream(FbankStreamHandle stream);
+
+/**
+ * 丢弃流式提取器中保留的样本，重新开始
+ * 
+ * @param stream 流式提取器句柄
+ */
+void ResetFbankStream(FbankStreamHandle stream);
+
+/**
+ * 向流式提取器输入一块PCM数据，输出本次新产生的完整帧
+ * 不足一帧的样本保留到下次输入，以任意分块输入同一段音频时，输出的帧与ComputeFbank一致
+ * 
+ * @param stream 流式提取器句柄
+ * @param pcm_data PCM数据指针（int16类型数据）
+ * @param pcm_length PCM数据长度（样本数），可以为0
+ * @param features 调用方预先分配的输出缓冲区，按帧连续写入，每帧num_bins个值
+ * @param max_frames 输出缓冲区可容纳的帧数，不小于pcm_length / frame_shift + 1
+ * @return 写入的帧数，失败返回-1
+ */
+int AcceptFbankStream(FbankStreamHandle stream,
+                      const short* pcm_data,
+                      int pcm_length,
+                      float* features,
+                      int max_frames);
+
+/**
+ * 从FBANK特征中提取说话人嵌入向量
+ * 
+ * @param handle 模型句柄
+ * @param features 按帧连续存放的特征
+ * @param num_frames 帧数
+ * @param num_bins 每帧特征维度，必须与GetFbankInfo一致
+ * @param embedding 调用方预先分配的输出缓冲区
+ * @param embedding_size 输出缓冲区长度，必须等于GetEmbeddingDimension的返回值
+ * @param normalize 是否对嵌入向量做L2归一化
+ * @param norm 输出模型原始输出的L2范数，可为NULL
+ * @return 成功返回1，失败返回0，原始输出范数为0时返回-1
+ */
+int ExtractEmbeddingFromFeatures(SpeakerModelHandle handle,
+                                 const float* features,
+                                 int num_frames,
+                                 int num_bins,
+                                 float* embedding,
+                                 int embedding_size,
+                                 int normalize,
+                                 float* norm);
 
 /**
  * 计算两个嵌入向量的余弦相似度
@@ -73,13 +178,6 @@ float ComputeCosineSimilarity(const float* embedding1, int size1,
 float ComputeL2Distance(const float* embedding1, int size1,
                        const float* embedding2, int size2);
 
-/**
- * 释放嵌入向量内存
- * 
- * @param embedding 嵌入向量指针
- */
-void FreeEmbedding(float* embedding);
-
 #ifdef __cplusplus
 }
 #endif
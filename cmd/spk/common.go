@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// 输出格式
+const (
+	textFormat = "text"
+	jsonFormat = "json"
+	csvFormat  = "csv"
+)
+
+// commonFlags 各子命令共用的参数
+type commonFlags struct {
+	model     string
+	config    string
+	format    string
+	transform string
+}
+
+// addCommonFlags 注册共用参数
+func addCommonFlags(fs *flag.FlagSet) *commonFlags {
+	c := &commonFlags{}
+	fs.StringVar(&c.model, "model", "", "ONNX模型文件路径")
+	fs.StringVar(&c.config, "config", "", "FBANK特征配置文件路径，为空时使用默认配置")
+	fs.StringVar(&c.format, "format", textFormat, "输出格式: text/json/csv")
+	fs.StringVar(&c.transform, "transform", "", "嵌入向量变换序列路径（由spk transform生成）")
+	return c
+}
+
+// parseFlags 解析参数并检查共用参数
+func parseFlags(fs *flag.FlagSet, args []string, c *commonFlags) error {
+	fs.Parse(args)
+	switch c.format {
+	case textFormat, jsonFormat, csvFormat:
+	default:
+		return fmt.Errorf("未知的输出格式: %s", c.format)
+	}
+	if c.model == "" {
+		return fmt.Errorf("用法: spk %s -model=<模型路径> [-config=<配置文件路径>] [-format=text|json|csv] ...", fs.Name())
+	}
+	return nil
+}
+
+// loadSpeaker 加载模型并应用嵌入向量变换
+func (c *commonFlags) loadSpeaker() (*speaker.Speaker, error) {
+	spk, err := loadSpeaker(c.model, c.config)
+	if err != nil {
+		return nil, err
+	}
+	if err := useTransform(spk, c.transform); err != nil {
+		spk.Close()
+		return nil, err
+	}
+	return spk, nil
+}
+
+// loadModel 只加载模型，用于直接使用ModelHandle的子命令（不应用嵌入向量变换）
+func (c *commonFlags) loadModel() (*speaker.ModelHandle, error) {
+	fmt.Fprintln(os.Stderr, "正在加载模型...")
+	model, err := speaker.LoadModel(c.model, c.config)
+	if err != nil {
+		return nil, fmt.Errorf("加载模型失败: %w", err)
+	}
+	return model, nil
+}
+
+// report 子命令的输出结果，JSON格式直接序列化结果本身
+type report interface {
+	// writeText 输出便于阅读的文本
+	writeText(w io.Writer)
+	// csvRows 返回CSV的各行，第一行为表头
+	csvRows() [][]string
+}
+
+// print 按输出格式将结果写到标准输出
+func (c *commonFlags) print(r report) error {
+	return writeReport(os.Stdout, c.format, r)
+}
+
+// writeReport 按输出格式写出结果
+func writeReport(w io.Writer, format string, r report) error {
+	switch format {
+	case jsonFormat:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case csvFormat:
+		cw := csv.NewWriter(w)
+		cw.WriteAll(r.csvRows())
+		return cw.Error()
+	default:
+		r.writeText(w)
+		return nil
+	}
+}
+
+// formatFloat 格式化CSV中的浮点数
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', 6, 64)
+}
+
+// formatBool 格式化CSV中的布尔值
+func formatBool(v bool) string {
+	return strconv.FormatBool(v)
+}
+
+// audioArgs 返回-audio参数和位置参数中的音频路径
+func audioArgs(fs *flag.FlagSet, audio string) ([]string, error) {
+	var paths []string
+	if audio != "" {
+		paths = append(paths, audio)
+	}
+	paths = append(paths, fs.Args()...)
+	if len(paths) == 0 {
+		return nil, errors.New("未指定音频文件")
+	}
+	return paths, nil
+}
+
+// embeddingFile embed子命令输出的嵌入向量文件，可作为verify -enroll的输入
+type embeddingFile struct {
+	Fingerprint string           `json:"fingerprint"` // 提取时使用的模型指纹
+	Dimension   int              `json:"dimension"`
+	Embeddings  []embeddingEntry `json:"embeddings"`
+}
+
+// embeddingEntry 嵌入向量文件中的一条记录
+type embeddingEntry struct {
+	ID   string    `json:"id"`   // 音频路径
+	Norm float32   `json:"norm"` // 模型原始输出的L2范数
+	Data []float32 `json:"data"`
+}
+
+// writeText 每行输出"ID [ 向量 ]"，与Kaldi的文本格式一致
+func (f *embeddingFile) writeText(w io.Writer) {
+	for _, e := range f.Embeddings {
+		fmt.Fprintf(w, "%s [", e.ID)
+		for _, v := range e.Data {
+			fmt.Fprintf(w, " %g", v)
+		}
+		fmt.Fprintln(w, " ]")
+	}
+}
+
+func (f *embeddingFile) csvRows() [][]string {
+	header := []string{"id", "norm"}
+	for i := 0; i < f.Dimension; i++ {
+		header = append(header, fmt.Sprintf("d%d", i))
+	}
+	rows := [][]string{header}
+	for _, e := range f.Embeddings {
+		row := []string{e.ID, formatFloat(float64(e.Norm))}
+		for _, v := range e.Data {
+			row = append(row, formatFloat(float64(v)))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// save 将嵌入向量文件保存为JSON
+func (f *embeddingFile) save(path string) error {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("序列化嵌入向量失败: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("写入嵌入向量文件失败: %w", err)
+	}
+	return nil
+}
+
+// loadEmbeddingFile 读取嵌入向量文件，fingerprint非空时校验模型指纹
+func loadEmbeddingFile(path, fingerprint string) (*embeddingFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取嵌入向量文件失败: %w", err)
+	}
+	var f embeddingFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("解析嵌入向量文件失败: %w", err)
+	}
+	if fingerprint != "" && f.Fingerprint != "" && f.Fingerprint != fingerprint {
+		return nil, fmt.Errorf("嵌入向量文件的模型指纹 %s 与当前模型 %s 不一致", f.Fingerprint, fingerprint)
+	}
+	if len(f.Embeddings) == 0 {
+		return nil, fmt.Errorf("嵌入向量文件 %s 为空", path)
+	}
+	return &f, nil
+}
+
+// embeddings 返回文件中的全部嵌入向量，记录了原始范数时Norm返回该值
+func (f *embeddingFile) embeddings() []*speaker.Embedding {
+	out := make([]*speaker.Embedding, len(f.Embeddings))
+	for i, e := range f.Embeddings {
+		if e.Norm > 0 {
+			out[i] = speaker.NewEmbeddingWithNorm(e.Data, e.Norm)
+		} else {
+			out[i] = speaker.NewEmbedding(e.Data)
+		}
+	}
+	return out
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/seastart/3dspeaker-onnx-go/audio"
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// decision 一次判决的结果
+type decision struct {
+	Enroll    string  `json:"enroll"`
+	Test      string  `json:"test"`
+	Score     float32 `json:"score"`
+	Threshold float32 `json:"threshold"`
+	Accepted  bool    `json:"accepted"` // 是否判为同一说话人
+}
+
+func (d *decision) writeText(w io.Writer) {
+	fmt.Fprintf(w, "注册: %s\n", d.Enroll)
+	fmt.Fprintf(w, "测试: %s\n", d.Test)
+	fmt.Fprintf(w, "相似度分数: %.4f (阈值: %.2f)\n", d.Score, d.Threshold)
+	if d.Accepted {
+		fmt.Fprintln(w, "判断结果: 来自同一说话人")
+	} else {
+		fmt.Fprintln(w, "判断结果: 来自不同说话人")
+	}
+}
+
+func (d *decision) csvRows() [][]string {
+	return [][]string{
+		{"enroll", "test", "score", "threshold", "accepted"},
+		{d.Enroll, d.Test, formatFloat(float64(d.Score)), formatFloat(float64(d.Threshold)), formatBool(d.Accepted)},
+	}
+}
+
+// thresholdFlags 判决阈值相关的参数
+type thresholdFlags struct {
+	threshold  float64
+	config     string
+	minQuality float64
+}
+
+// addThresholdFlags 注册判决阈值相关的参数
+func addThresholdFlags(fs *flag.FlagSet) *thresholdFlags {
+	t := &thresholdFlags{}
+	fs.Float64Var(&t.threshold, "threshold", math.NaN(), "判断为同一说话人的阈值，可以为0或负数；未指定时使用阈值配置文件或默认值0.70")
+	fs.StringVar(&t.config, "threshold-config", "", "阈值配置文件路径（由spk threshold生成）")
+	fs.Float64Var(&t.minQuality, "min-quality", 0, "音频所要求的最低质量分数[0,1]，<=0表示不检查")
+	return t
+}
+
+// apply 将阈值配置和最低质量应用到Speaker，返回实际使用的阈值
+func (t *thresholdFlags) apply(spk *speaker.Speaker) (float32, error) {
+	if t.config != "" {
+		if err := spk.LoadThreshold(t.config); err != nil {
+			return 0, fmt.Errorf("加载阈值配置失败: %w", err)
+		}
+	}
+	spk.SetMinQuality(t.minQuality)
+	if !math.IsNaN(t.threshold) {
+		return float32(t.threshold), nil
+	}
+	return spk.Threshold(), nil
+}
+
+// runCompare 比较两段音频是否来自同一说话人
+func runCompare(args []string) error {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	c := addCommonFlags(fs)
+	t := addThresholdFlags(fs)
+	audio1Path := fs.String("audio1", "", "第一个音频文件路径")
+	audio2Path := fs.String("audio2", "", "第二个音频文件路径")
+	if err := parseFlags(fs, args, c); err != nil {
+		return err
+	}
+	if *audio1Path == "" || *audio2Path == "" {
+		return errors.New("用法: spk compare -model=<模型路径> -audio1=<音频文件1> -audio2=<音频文件2> [-threshold=0.70] [-threshold-config=<阈值配置>]")
+	}
+
+	spk, err := c.loadSpeaker()
+	if err != nil {
+		return err
+	}
+	defer spk.Close()
+	threshold, err := t.apply(spk)
+	if err != nil {
+		return err
+	}
+
+	pcm1, err := audio.ReadFile(*audio1Path)
+	if err != nil {
+		return fmt.Errorf("读取音频文件1失败: %w", err)
+	}
+	pcm2, err := audio.ReadFile(*audio2Path)
+	if err != nil {
+		return fmt.Errorf("读取音频文件2失败: %w", err)
+	}
+
+	same, score, err := spk.IsSameSpeakerWithThreshold(pcm1, pcm2, threshold)
+	if errors.Is(err, speaker.ErrLowQuality) {
+		return fmt.Errorf("音频质量不满足要求: %w", err)
+	}
+	if err != nil {
+		return fmt.Errorf("比较音频失败: %w", err)
+	}
+	return c.print(&decision{Enroll: *audio1Path, Test: *audio2Path, Score: score, Threshold: threshold, Accepted: same})
+}
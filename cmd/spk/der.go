@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/seastart/3dspeaker-onnx-go/diarize"
+)
+
+// runDER 根据参考和系统输出的RTTM计算说话人日志错误率（DER）和Jaccard错误率（JER）
+func runDER(args []string) error {
+	fs := flag.NewFlagSet("der", flag.ExitOnError)
+	refPath := fs.String("ref", "", "参考标注RTTM路径")
+	hypPath := fs.String("hyp", "", "系统输出RTTM路径")
+	collar := fs.Float64("collar", 0.25, "参考片段边界两侧不计分的时长（秒）")
+	skipOverlap := fs.Bool("skip-overlap", false, "是否不对多人同时说话的区域计分")
+	perFile := fs.Bool("per-file", false, "是否输出每个录音的结果")
+	fs.Parse(args)
+
+	if *refPath == "" || *hypPath == "" {
+		return errors.New("用法: spk der -ref=<参考RTTM> -hyp=<系统输出RTTM> [-collar=0.25] [-skip-overlap]")
+	}
+	ref, err := readRTTMFile(*refPath)
+	if err != nil {
+		return err
+	}
+	hyp, err := readRTTMFile(*hypPath)
+	if err != nil {
+		return err
+	}
+
+	total, files := diarize.ScoreFiles(ref, hyp, diarize.DERConfig{Collar: *collar, SkipOverlap: *skipOverlap})
+	if *perFile {
+		ids := make([]string, 0, len(files))
+		for id := range files {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			r := files[id]
+			fmt.Printf("%s\tDER=%.2f%%\tJER=%.2f%%\n", id, 100*r.DER, 100*r.JER)
+		}
+	}
+	fmt.Printf("录音数: %d (collar=%.2fs, 排除重叠=%v)\n", len(files), *collar, *skipOverlap)
+	fmt.Printf("计分时长: %.2fs\n", total.Total)
+	fmt.Printf("漏检: %.2f%%  虚警: %.2f%%  混淆: %.2f%%\n", 100*total.MissedRate(), 100*total.FalseAlarmRate(), 100*total.ConfusionRate())
+	fmt.Printf("DER: %.2f%%\n", 100*total.DER)
+	fmt.Printf("JER: %.2f%%\n", 100*total.JER)
+	return nil
+}
+
+// readRTTMFile 读取RTTM文件
+func readRTTMFile(path string) (map[string][]diarize.Turn, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开RTTM失败: %w", err)
+	}
+	defer f.Close()
+	return diarize.ReadRTTM(f)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/seastart/3dspeaker-onnx-go/audio"
+	"github.com/seastart/3dspeaker-onnx-go/diarize"
+)
+
+// diarization 说话人日志结果
+type diarization struct {
+	Audio    string         `json:"audio"`
+	Speakers int            `json:"speakers"`
+	Turns    []diarize.Turn `json:"turns"`
+}
+
+func (r *diarization) writeText(w io.Writer) {
+	fmt.Fprintf(w, "音频: %s (%d个说话人)\n", r.Audio, r.Speakers)
+	for _, t := range r.Turns {
+		fmt.Fprintf(w, "%8.2f\t%8.2f\t%s\n", t.Start, t.End, t.Speaker)
+	}
+}
+
+func (r *diarization) csvRows() [][]string {
+	rows := [][]string{{"start", "end", "speaker"}}
+	for _, t := range r.Turns {
+		rows = append(rows, []string{formatFloat(t.Start), formatFloat(t.End), t.Speaker})
+	}
+	return rows
+}
+
+// runDiarize 对音频做说话人日志
+func runDiarize(args []string) error {
+	fs := flag.NewFlagSet("diarize", flag.ExitOnError)
+	c := addCommonFlags(fs)
+	cfg := diarize.DefaultConfig()
+	audioPath := fs.String("audio", "", "音频文件路径")
+	method := fs.String("method", cfg.Method.String(), "聚类方法: ahc/spectral")
+	fs.Float64Var(&cfg.Threshold, "threshold", cfg.Threshold, "凝聚层次聚类停止合并的余弦相似度阈值")
+	fs.IntVar(&cfg.NumSpeakers, "num-speakers", 0, "已知说话人数，<=0表示自动估计")
+	fs.IntVar(&cfg.MaxSpeakers, "max-speakers", cfg.MaxSpeakers, "谱聚类估计说话人数的上限")
+	turnsPath := fs.String("turns", "", "说话人片段输出路径，格式由-turns-format或文件扩展名决定")
+	turnsFormat := fs.String("turns-format", "", "说话人片段文件格式: rttm/json/vtt/srt/audacity")
+	if err := parseFlags(fs, args, c); err != nil {
+		return err
+	}
+	if *audioPath == "" {
+		return errors.New("用法: spk diarize -model=<模型路径> -audio=<音频文件> [-method=ahc|spectral] [-num-speakers=N] [-turns=<片段输出>]")
+	}
+	var err error
+	if cfg.Method, err = diarize.ParseMethod(*method); err != nil {
+		return err
+	}
+
+	model, err := c.loadModel()
+	if err != nil {
+		return err
+	}
+	defer model.Close()
+	d, err := diarize.New(model, cfg)
+	if err != nil {
+		return err
+	}
+
+	pcm, err := audio.ReadFile(*audioPath)
+	if err != nil {
+		return fmt.Errorf("读取音频 %s 失败: %w", *audioPath, err)
+	}
+	turns, err := d.Diarize(pcm)
+	if err != nil {
+		return fmt.Errorf("说话人日志失败: %w", err)
+	}
+
+	result := &diarization{Audio: *audioPath, Turns: turns}
+	speakers := make(map[string]bool)
+	for _, t := range turns {
+		speakers[t.Speaker] = true
+	}
+	result.Speakers = len(speakers)
+
+	if *turnsPath != "" {
+		if err := writeTurns(*turnsPath, *turnsFormat, *audioPath, turns); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "说话人片段已写入: %s\n", *turnsPath)
+	}
+	return c.print(result)
+}
+
+// writeTurns 按格式写出说话人片段，format为空时按文件扩展名判断，RTTM的录音标识取音频文件名
+func writeTurns(path, format, audioPath string, turns []diarize.Turn) error {
+	if format == "" {
+		format = filepath.Ext(path)
+	}
+	f, err := diarize.ParseFormat(format)
+	if err != nil {
+		return err
+	}
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("创建片段文件失败: %w", err)
+	}
+	fileID := strings.TrimSuffix(filepath.Base(audioPath), filepath.Ext(audioPath))
+	err = diarize.Write(out, f, fileID, turns)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("写入片段文件失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// runEmbed 提取音频的嵌入向量，输出到标准输出，可同时写入嵌入向量文件
+func runEmbed(args []string) error {
+	fs := flag.NewFlagSet("embed", flag.ExitOnError)
+	c := addCommonFlags(fs)
+	audioPath := fs.String("audio", "", "音频文件路径，也可以在参数之后列出多个音频文件")
+	root := fs.String("root", "", "相对路径的根目录")
+	raw := fs.Bool("raw", false, "是否输出未归一化的模型原始输出")
+	output := fs.String("output", "", "嵌入向量文件（JSON）输出路径，可作为verify -enroll的输入")
+	if err := parseFlags(fs, args, c); err != nil {
+		return err
+	}
+	paths, err := audioArgs(fs, *audioPath)
+	if err != nil {
+		return err
+	}
+
+	spk, err := c.loadSpeaker()
+	if err != nil {
+		return err
+	}
+	defer spk.Close()
+	spk.SetRawEmbedding(*raw)
+
+	result := &embeddingFile{Fingerprint: spk.Fingerprint()}
+	for _, path := range paths {
+		emb, err := embedFile(spk, *root, path)
+		if err != nil {
+			return err
+		}
+		result.Dimension = emb.GetEmbeddingDimension()
+		result.Embeddings = append(result.Embeddings, embeddingEntry{ID: path, Norm: emb.Norm(), Data: emb.GetData()})
+	}
+	if *output != "" {
+		if err := result.save(*output); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "嵌入向量已写入: %s\n", *output)
+	}
+	return c.print(result)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/seastart/3dspeaker-onnx-go/eval"
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// evaluation 试验列表的评估结果
+type evaluation struct {
+	Trials          int      `json:"trials"`
+	Targets         int      `json:"targets"`
+	NonTargets      int      `json:"non_targets"`
+	EER             float64  `json:"eer"`
+	EERThreshold    float64  `json:"eer_threshold"`
+	PTarget         float64  `json:"p_target"`
+	CMiss           float64  `json:"c_miss"`
+	CFA             float64  `json:"c_fa"`
+	MinDCF          float64  `json:"min_dcf"`
+	MinDCFThreshold float64  `json:"min_dcf_threshold"`
+	ActDCF          *float64 `json:"act_dcf,omitempty"` // 仅在提供校准器时计算
+	Cllr            *float64 `json:"cllr,omitempty"`
+}
+
+func (r *evaluation) writeText(w io.Writer) {
+	fmt.Fprintf(w, "试验数: %d (同一人 %d, 不同人 %d)\n", r.Trials, r.Targets, r.NonTargets)
+	fmt.Fprintf(w, "EER: %.2f%% (阈值: %.4f)\n", r.EER*100, r.EERThreshold)
+	fmt.Fprintf(w, "minDCF(P_target=%g, C_miss=%g, C_fa=%g): %.4f (阈值: %.4f)\n",
+		r.PTarget, r.CMiss, r.CFA, r.MinDCF, r.MinDCFThreshold)
+	if r.ActDCF != nil {
+		fmt.Fprintf(w, "actDCF: %.4f\n", *r.ActDCF)
+		fmt.Fprintf(w, "Cllr: %.4f\n", *r.Cllr)
+	} else {
+		fmt.Fprintln(w, "actDCF/Cllr: 未提供校准器，跳过")
+	}
+}
+
+func (r *evaluation) csvRows() [][]string {
+	rows := [][]string{
+		{"metric", "value"},
+		{"trials", fmt.Sprint(r.Trials)},
+		{"targets", fmt.Sprint(r.Targets)},
+		{"non_targets", fmt.Sprint(r.NonTargets)},
+		{"eer", formatFloat(r.EER)},
+		{"eer_threshold", formatFloat(r.EERThreshold)},
+		{"min_dcf", formatFloat(r.MinDCF)},
+		{"min_dcf_threshold", formatFloat(r.MinDCFThreshold)},
+	}
+	if r.ActDCF != nil {
+		rows = append(rows, []string{"act_dcf", formatFloat(*r.ActDCF)}, []string{"cllr", formatFloat(*r.Cllr)})
+	}
+	return rows
+}
+
+// runEval 对试验列表打分并输出评估指标
+func runEval(args []string) error {
+	fs := flag.NewFlagSet("eval", flag.ExitOnError)
+	c := addCommonFlags(fs)
+	trialsPath := fs.String("trials", "", "试验列表路径，每行为\"标签 路径1 路径2\"")
+	root := fs.String("root", "", "试验列表中相对路径的根目录")
+	pldaPath := fs.String("plda", "", "PLDA模型路径，提供时使用PLDA打分代替余弦打分")
+	pTarget := fs.Float64("p-target", eval.DefaultDCFParams.PTarget, "目标说话人先验概率")
+	cMiss := fs.Float64("c-miss", eval.DefaultDCFParams.CMiss, "漏检代价")
+	cFA := fs.Float64("c-fa", eval.DefaultDCFParams.CFA, "虚警代价")
+	calibratorPath := fs.String("calibrator", "", "分数校准器文件路径，提供时计算actDCF和Cllr")
+	detPath := fs.String("det", "", "DET/ROC曲线CSV输出路径")
+	scoresPath := fs.String("scores", "", "逐条试验分数输出路径")
+	if err := parseFlags(fs, args, c); err != nil {
+		return err
+	}
+
+	// 检查必要参数
+	if *trialsPath == "" {
+		return errors.New("用法: spk eval -model=<模型路径> -config=<配置文件路径> -trials=<试验列表> [-root=<音频根目录>] [-det=<曲线输出>]")
+	}
+
+	trials, err := eval.ReadTrialsFile(*trialsPath)
+	if err != nil {
+		return err
+	}
+	if len(trials) == 0 {
+		return errors.New("试验列表为空")
+	}
+
+	var calibrator *speaker.Calibrator
+	if *calibratorPath != "" {
+		calibrator, err = speaker.LoadCalibrator(*calibratorPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	spk, err := c.loadSpeaker()
+	if err != nil {
+		return err
+	}
+	defer spk.Close()
+	if err := usePLDA(spk, *pldaPath); err != nil {
+		return err
+	}
+
+	scores, err := scoreTrials(spk, trials, *root)
+	if err != nil {
+		return err
+	}
+	if *scoresPath != "" {
+		if err := writeScores(*scoresPath, scores); err != nil {
+			return err
+		}
+	}
+
+	targets, nonTargets := splitScores(scores)
+	params := eval.DCFParams{PTarget: *pTarget, CMiss: *cMiss, CFA: *cFA}
+	report, err := eval.Evaluate(targets, nonTargets, params)
+	if err != nil {
+		return fmt.Errorf("评估失败: %w", err)
+	}
+	result := &evaluation{
+		Trials:          len(trials),
+		Targets:         report.NumTargets,
+		NonTargets:      report.NumNonTargets,
+		EER:             report.EER,
+		EERThreshold:    report.EERThreshold,
+		PTarget:         params.PTarget,
+		CMiss:           params.CMiss,
+		CFA:             params.CFA,
+		MinDCF:          report.MinDCF,
+		MinDCFThreshold: report.MinDCFThreshold,
+	}
+
+	if calibrator != nil {
+		targetLLRs := make([]float64, len(targets))
+		for i, s := range targets {
+			targetLLRs[i] = calibrator.LLR(s)
+		}
+		nonTargetLLRs := make([]float64, len(nonTargets))
+		for i, s := range nonTargets {
+			nonTargetLLRs[i] = calibrator.LLR(s)
+		}
+		actDCF := eval.ActualDCF(targetLLRs, nonTargetLLRs, params)
+		cllr := eval.Cllr(targetLLRs, nonTargetLLRs)
+		result.ActDCF, result.Cllr = &actDCF, &cllr
+	}
+
+	if *detPath != "" {
+		f, err := os.Create(*detPath)
+		if err != nil {
+			return fmt.Errorf("创建曲线文件失败: %w", err)
+		}
+		err = eval.WriteCurveCSV(f, eval.DETCurve(targets, nonTargets))
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("写入曲线文件失败: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "DET/ROC曲线已写入: %s\n", *detPath)
+	}
+	return c.print(result)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/seastart/3dspeaker-onnx-go/audio"
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// featureDump FBANK特征
+type featureDump struct {
+	Audio      string      `json:"audio"`
+	NumFrames  int         `json:"num_frames"`
+	NumBins    int         `json:"num_bins"`
+	FrameShift int         `json:"frame_shift"` // 帧移（样本数）
+	Frames     [][]float32 `json:"frames"`
+}
+
+// writeText 每行输出一帧特征
+func (d *featureDump) writeText(w io.Writer) {
+	for _, frame := range d.Frames {
+		for i, v := range frame {
+			if i > 0 {
+				fmt.Fprint(w, " ")
+			}
+			fmt.Fprintf(w, "%g", v)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func (d *featureDump) csvRows() [][]string {
+	header := []string{"frame"}
+	for i := 0; i < d.NumBins; i++ {
+		header = append(header, fmt.Sprintf("b%d", i))
+	}
+	rows := [][]string{header}
+	for i, frame := range d.Frames {
+		row := []string{fmt.Sprint(i)}
+		for _, v := range frame {
+			row = append(row, formatFloat(float64(v)))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// runFeatures 输出音频的FBANK特征
+func runFeatures(args []string) error {
+	fs := flag.NewFlagSet("features", flag.ExitOnError)
+	c := addCommonFlags(fs)
+	audioPath := fs.String("audio", "", "音频文件路径")
+	if err := parseFlags(fs, args, c); err != nil {
+		return err
+	}
+	if *audioPath == "" {
+		return errors.New("用法: spk features -model=<模型路径> [-config=<配置文件路径>] -audio=<音频文件>")
+	}
+
+	model, err := c.loadModel()
+	if err != nil {
+		return err
+	}
+	defer model.Close()
+
+	pcm, err := audio.ReadFile(*audioPath)
+	if err != nil {
+		return fmt.Errorf("读取音频 %s 失败: %w", *audioPath, err)
+	}
+	feats, err := model.ComputeFbank(pcm)
+	if err != nil {
+		return err
+	}
+	return c.print(newFeatureDump(*audioPath, model.FbankInfo(), feats))
+}
+
+// newFeatureDump 将特征矩阵按帧拆分
+func newFeatureDump(path string, info speaker.FbankInfo, feats *speaker.Features) *featureDump {
+	d := &featureDump{Audio: path, NumFrames: feats.NumFrames(), NumBins: feats.NumBins, FrameShift: info.FrameShift}
+	d.Frames = make([][]float32, d.NumFrames)
+	for i := range d.Frames {
+		d.Frames[i] = feats.Frame(i)
+	}
+	return d
+}
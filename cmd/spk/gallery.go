@@ -0,0 +1,271 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/seastart/3dspeaker-onnx-go/audio"
+	"github.com/seastart/3dspeaker-onnx-go/gallery"
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// enrollment 注册结果
+type enrollment struct {
+	ID       string   `json:"id"`
+	Files    []string `json:"files"`
+	Quality  float64  `json:"quality"`  // 各段音频质量分数的最小值
+	Speakers int      `json:"speakers"` // 注册后库中的说话人数
+}
+
+func (e *enrollment) writeText(w io.Writer) {
+	fmt.Fprintf(w, "已注册说话人 %s（%d段音频，最低质量 %.3f），库中共 %d 个说话人\n", e.ID, len(e.Files), e.Quality, e.Speakers)
+}
+
+func (e *enrollment) csvRows() [][]string {
+	return [][]string{
+		{"id", "files", "quality", "speakers"},
+		{e.ID, fmt.Sprint(len(e.Files)), formatFloat(e.Quality), fmt.Sprint(e.Speakers)},
+	}
+}
+
+// openGallery 打开说话人库并绑定到当前模型
+func openGallery(path string, spk *speaker.Speaker, dim int) (*gallery.Store, error) {
+	if path == "" {
+		return nil, errors.New("未指定说话人库路径")
+	}
+	store, err := gallery.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if dim > 0 {
+		err = store.Bind(spk.Fingerprint(), dim)
+	} else if h := store.Header(); h.Fingerprint != "" && h.Fingerprint != spk.Fingerprint() {
+		err = fmt.Errorf("%w: 库为 %s，当前模型为 %s", gallery.ErrFingerprintMismatch, h.Fingerprint, spk.Fingerprint())
+	}
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// runEnroll 从一段或多段音频注册说话人，多段音频的嵌入向量取归一化平均
+func runEnroll(args []string) error {
+	fs := flag.NewFlagSet("enroll", flag.ExitOnError)
+	c := addCommonFlags(fs)
+	galleryPath := fs.String("gallery", "speakers.gallery", "说话人库路径，不存在时自动创建")
+	id := fs.String("id", "", "说话人ID，已存在时覆盖")
+	audioPath := fs.String("audio", "", "注册音频路径，也可以在参数之后列出多个音频文件")
+	minQuality := fs.Float64("min-quality", 0, "注册音频所要求的最低质量分数[0,1]，<=0表示不检查")
+	if err := parseFlags(fs, args, c); err != nil {
+		return err
+	}
+	if *id == "" {
+		return errors.New("用法: spk enroll -model=<模型路径> -gallery=<说话人库> -id=<说话人ID> <音频文件>...")
+	}
+	paths, err := audioArgs(fs, *audioPath)
+	if err != nil {
+		return err
+	}
+
+	spk, err := c.loadSpeaker()
+	if err != nil {
+		return err
+	}
+	defer spk.Close()
+	spk.SetMinQuality(*minQuality)
+
+	result := &enrollment{ID: *id, Files: paths, Quality: 1}
+	embs := make([]*speaker.Embedding, len(paths))
+	for i, path := range paths {
+		pcm, err := audio.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("读取音频 %s 失败: %w", path, err)
+		}
+		if embs[i], err = spk.Enroll(pcm); err != nil {
+			return fmt.Errorf("注册音频 %s 失败: %w", path, err)
+		}
+		result.Quality = min(result.Quality, embs[i].Quality().Score)
+	}
+	emb, err := speaker.AverageEmbeddings(embs)
+	if err != nil {
+		return err
+	}
+
+	store, err := openGallery(*galleryPath, spk, emb.GetEmbeddingDimension())
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	if err := store.Put(*id, emb); err != nil {
+		return err
+	}
+	result.Speakers = store.Len()
+	return c.print(result)
+}
+
+// runVerify 验证音频是否来自说话人库或嵌入向量文件中的说话人
+func runVerify(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	c := addCommonFlags(fs)
+	t := addThresholdFlags(fs)
+	galleryPath := fs.String("gallery", "speakers.gallery", "说话人库路径")
+	id := fs.String("id", "", "说话人库中的说话人ID")
+	enrollPath := fs.String("enroll", "", "嵌入向量文件路径（由spk embed -output生成），多条记录取平均，可替代-gallery和-id")
+	audioPath := fs.String("audio", "", "测试音频路径")
+	if err := parseFlags(fs, args, c); err != nil {
+		return err
+	}
+	if *audioPath == "" || (*id == "") == (*enrollPath == "") {
+		return errors.New("用法: spk verify -model=<模型路径> (-gallery=<说话人库> -id=<说话人ID> | -enroll=<嵌入向量文件>) -audio=<测试音频>")
+	}
+
+	spk, err := c.loadSpeaker()
+	if err != nil {
+		return err
+	}
+	defer spk.Close()
+	threshold, err := t.apply(spk)
+	if err != nil {
+		return err
+	}
+
+	var enroll *speaker.Embedding
+	name := *id
+	if *enrollPath != "" {
+		f, err := loadEmbeddingFile(*enrollPath, spk.Fingerprint())
+		if err != nil {
+			return err
+		}
+		if enroll, err = speaker.AverageEmbeddings(f.embeddings()); err != nil {
+			return err
+		}
+		name = *enrollPath
+	} else {
+		store, err := openGallery(*galleryPath, spk, 0)
+		if err != nil {
+			return err
+		}
+		emb, ok := store.Get(*id)
+		store.Close()
+		if !ok {
+			return fmt.Errorf("%w: %s", gallery.ErrNotFound, *id)
+		}
+		enroll = emb
+	}
+
+	pcm, err := audio.ReadFile(*audioPath)
+	if err != nil {
+		return fmt.Errorf("读取音频 %s 失败: %w", *audioPath, err)
+	}
+	accepted, score, err := spk.VerifyWithThreshold(enroll, pcm, threshold)
+	if err != nil {
+		return err
+	}
+	return c.print(&decision{Enroll: name, Test: *audioPath, Score: score, Threshold: threshold, Accepted: accepted})
+}
+
+// candidate 辨认结果中的一个候选说话人
+type candidate struct {
+	ID       string  `json:"id"`
+	Score    float32 `json:"score"`
+	Accepted bool    `json:"accepted"` // 分数是否达到阈值
+}
+
+// identification 辨认结果
+type identification struct {
+	Audio      string      `json:"audio"`
+	Threshold  float32     `json:"threshold"`
+	Candidates []candidate `json:"candidates"` // 按分数从高到低排序
+}
+
+func (r *identification) writeText(w io.Writer) {
+	fmt.Fprintf(w, "音频: %s (阈值: %.2f)\n", r.Audio, r.Threshold)
+	if len(r.Candidates) == 0 || !r.Candidates[0].Accepted {
+		fmt.Fprintln(w, "未找到匹配的说话人")
+	}
+	for i, c := range r.Candidates {
+		mark := ""
+		if c.Accepted {
+			mark = " *"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%.4f%s\n", i+1, c.ID, c.Score, mark)
+	}
+}
+
+func (r *identification) csvRows() [][]string {
+	rows := [][]string{{"rank", "id", "score", "accepted"}}
+	for i, c := range r.Candidates {
+		rows = append(rows, []string{fmt.Sprint(i + 1), c.ID, formatFloat(float64(c.Score)), formatBool(c.Accepted)})
+	}
+	return rows
+}
+
+// runIdentify 在说话人库中查找与音频最相似的说话人
+func runIdentify(args []string) error {
+	fs := flag.NewFlagSet("identify", flag.ExitOnError)
+	c := addCommonFlags(fs)
+	t := addThresholdFlags(fs)
+	galleryPath := fs.String("gallery", "speakers.gallery", "说话人库路径")
+	audioPath := fs.String("audio", "", "测试音频路径")
+	top := fs.Int("top", 5, "输出分数最高的候选说话人数")
+	if err := parseFlags(fs, args, c); err != nil {
+		return err
+	}
+	if *audioPath == "" {
+		return errors.New("用法: spk identify -model=<模型路径> -gallery=<说话人库> -audio=<测试音频> [-top=5]")
+	}
+
+	spk, err := c.loadSpeaker()
+	if err != nil {
+		return err
+	}
+	defer spk.Close()
+	threshold, err := t.apply(spk)
+	if err != nil {
+		return err
+	}
+	store, err := openGallery(*galleryPath, spk, 0)
+	if err != nil {
+		return err
+	}
+	entries := store.Snapshot()
+	store.Close()
+	if len(entries) == 0 {
+		return fmt.Errorf("说话人库 %s 为空", *galleryPath)
+	}
+
+	pcm, err := audio.ReadFile(*audioPath)
+	if err != nil {
+		return fmt.Errorf("读取音频 %s 失败: %w", *audioPath, err)
+	}
+	// 设置了最低质量时与verify相同，测试音频质量不满足要求时不做辨认
+	extract := spk.ExtractEmbedding
+	if spk.MinQuality() > 0 {
+		extract = spk.ExtractEmbeddingWithQuality
+	}
+	probe, err := extract(pcm)
+	if err != nil {
+		return fmt.Errorf("提取嵌入向量失败: %w", err)
+	}
+	if err := spk.CheckQuality(probe); err != nil {
+		return fmt.Errorf("音频质量不满足要求: %w", err)
+	}
+
+	result := &identification{Audio: *audioPath, Threshold: threshold}
+	for _, e := range entries {
+		score, err := spk.CompareEmbeddings(e.Embedding, probe)
+		if err != nil {
+			return fmt.Errorf("与说话人 %s 打分失败: %w", e.ID, err)
+		}
+		result.Candidates = append(result.Candidates, candidate{ID: e.ID, Score: score, Accepted: score >= threshold})
+	}
+	sort.SliceStable(result.Candidates, func(i, j int) bool { return result.Candidates[i].Score > result.Candidates[j].Score })
+	if *top > 0 && len(result.Candidates) > *top {
+		result.Candidates = result.Candidates[:*top]
+	}
+	return c.print(result)
+}
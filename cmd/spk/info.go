@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+)
+
+// modelInfo 模型信息
+type modelInfo struct {
+	Model       string `json:"model"`
+	Config      string `json:"config,omitempty"`
+	Fingerprint string `json:"fingerprint"` // 模型和特征配置的指纹，说话人库据此检查兼容性
+	Dimension   int    `json:"dimension"`   // 嵌入向量维度
+	NumBins     int    `json:"num_bins"`    // 每帧特征维度
+	FrameLength int    `json:"frame_length"`
+	FrameShift  int    `json:"frame_shift"`
+}
+
+func (m *modelInfo) writeText(w io.Writer) {
+	fmt.Fprintf(w, "模型: %s\n", m.Model)
+	if m.Config != "" {
+		fmt.Fprintf(w, "特征配置: %s\n", m.Config)
+	}
+	fmt.Fprintf(w, "指纹: %s\n", m.Fingerprint)
+	fmt.Fprintf(w, "嵌入向量维度: %d\n", m.Dimension)
+	fmt.Fprintf(w, "FBANK: %d维, 帧长 %d 样本, 帧移 %d 样本\n", m.NumBins, m.FrameLength, m.FrameShift)
+}
+
+func (m *modelInfo) csvRows() [][]string {
+	return [][]string{
+		{"model", "config", "fingerprint", "dimension", "num_bins", "frame_length", "frame_shift"},
+		{m.Model, m.Config, m.Fingerprint, fmt.Sprint(m.Dimension), fmt.Sprint(m.NumBins), fmt.Sprint(m.FrameLength), fmt.Sprint(m.FrameShift)},
+	}
+}
+
+// runInfo 输出模型的指纹、嵌入向量维度和特征参数
+func runInfo(args []string) error {
+	fs := flag.NewFlagSet("info", flag.ExitOnError)
+	c := addCommonFlags(fs)
+	if err := parseFlags(fs, args, c); err != nil {
+		return err
+	}
+	model, err := c.loadModel()
+	if err != nil {
+		return err
+	}
+	defer model.Close()
+
+	fbank := model.FbankInfo()
+	return c.print(&modelInfo{
+		Model:       c.model,
+		Config:      c.config,
+		Fingerprint: model.Fingerprint(),
+		Dimension:   model.Dimension(),
+		NumBins:     fbank.NumBins,
+		FrameLength: fbank.FrameLength,
+		FrameShift:  fbank.FrameShift,
+	})
+}
@@ -0,0 +1,84 @@
+// 说话人识别命令行工具
+//
+//	spk embed      提取音频的嵌入向量，可写入嵌入向量文件
+//	spk compare    比较两段音频是否来自同一说话人
+//	spk enroll     从一段或多段音频注册说话人到说话人库
+//	spk verify     验证音频是否来自库中（或嵌入向量文件中）的说话人
+//	spk identify   在说话人库中查找与音频最相似的说话人
+//	spk diarize    说话人日志：标注音频中谁在什么时候说话
+//	spk eval       读取VoxCeleb格式的试验列表，对每条试验打分并输出EER、minDCF等指标
+//	spk features   输出音频的FBANK特征
+//	spk info       输出模型的指纹、嵌入向量维度和特征参数
+//	spk matrix     并行提取目录中每个音频的嵌入向量，输出N×N相似度矩阵和可选的聚类摘要
+//	spk serve      启动说话人注册、验证、辨认的HTTP REST服务（可同时启动gRPC服务）
+//
+// 以上子命令共用-model、-config和-format（text/json/csv）参数，结果输出到标准输出，
+// 加载模型等诊断信息输出到标准错误
+//
+// 评估和训练相关的子命令：
+//
+//	spk threshold  根据带标签的试验或分数推荐判决阈值，并写入Speaker可加载的阈值配置文件
+//	spk plda       使用带说话人标签的音频训练PLDA后端，可选地用域内音频自适应
+//	spk transform  使用域内音频拟合嵌入向量变换（均值减除、LDA、白化、长度规整）
+//	spk der        根据参考和系统输出的RTTM计算说话人日志错误率（DER、JER）
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// usage 子命令列表
+const usage = "用法: spk <embed|compare|enroll|verify|identify|diarize|eval|features|info|matrix|serve|threshold|plda|transform|der> [参数]"
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(1)
+	}
+	command, args := os.Args[1], os.Args[2:]
+
+	var err error
+	switch command {
+	case "embed":
+		err = runEmbed(args)
+	case "compare":
+		err = runCompare(args)
+	case "enroll":
+		err = runEnroll(args)
+	case "verify":
+		err = runVerify(args)
+	case "identify":
+		err = runIdentify(args)
+	case "diarize":
+		err = runDiarize(args)
+	case "eval":
+		err = runEval(args)
+	case "features":
+		err = runFeatures(args)
+	case "info":
+		err = runInfo(args)
+	case "matrix":
+		err = runMatrix(args)
+	case "serve":
+		err = runServe(args)
+	case "threshold":
+		err = runThreshold(args)
+	case "plda":
+		err = runPLDA(args)
+	case "transform":
+		err = runTransform(args)
+	case "der":
+		err = runDER(args)
+	case "help", "-h", "-help", "--help":
+		fmt.Println(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "未知的子命令: %s\n", command)
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
+}
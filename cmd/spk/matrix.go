@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/seastart/3dspeaker-onnx-go/batch"
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// similarityMatrix 目录内两两比较的结果
+type similarityMatrix struct {
+	Files     []string          `json:"files"`
+	Scores    [][]float32       `json:"scores"`
+	Failed    map[string]string `json:"failed,omitempty"` // 文件路径到错误信息
+	CacheHits int               `json:"cache_hits"`
+	Summary   *batch.Summary    `json:"summary,omitempty"`
+
+	labels []int // 各文件所属的类在Summary.Clusters中的下标
+}
+
+func (m *similarityMatrix) writeText(w io.Writer) {
+	for i, file := range m.Files {
+		fmt.Fprintf(w, "%s", file)
+		for _, s := range m.Scores[i] {
+			fmt.Fprintf(w, "\t%.4f", s)
+		}
+		fmt.Fprintln(w)
+	}
+	if m.Summary != nil {
+		fmt.Fprintf(w, "\n聚类结果 (阈值: %g): %d类，其中%d个单文件类\n", m.Summary.Threshold, len(m.Summary.Clusters), m.Summary.Singletons)
+		for i, c := range m.Summary.Clusters {
+			if len(c.Files) > 1 {
+				fmt.Fprintf(w, "类%d (%d个文件, 平均相似度 %.4f): %s\n", i, len(c.Files), c.MeanScore, strings.Join(c.Files, " "))
+			}
+		}
+	}
+}
+
+// csvRows 输出相似度矩阵，聚类时在文件名之后增加cluster列
+func (m *similarityMatrix) csvRows() [][]string {
+	header := []string{"file"}
+	if m.Summary != nil {
+		header = append(header, "cluster")
+	}
+	rows := [][]string{append(header, m.Files...)}
+	for i, file := range m.Files {
+		row := []string{file}
+		if m.Summary != nil {
+			row = append(row, fmt.Sprint(m.labels[i]))
+		}
+		for _, s := range m.Scores[i] {
+			row = append(row, formatFloat(float64(s)))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// runMatrix 对目录中的每个音频文件只提取一次嵌入向量，计算N×N相似度矩阵
+func runMatrix(args []string) error {
+	fs := flag.NewFlagSet("matrix", flag.ExitOnError)
+	c := addCommonFlags(fs)
+	dir := fs.String("dir", "", "音频目录")
+	recursive := fs.Bool("recursive", false, "是否包含子目录")
+	exts := fs.String("ext", strings.Join(batch.DefaultExtensions, ","), "参与比较的文件扩展名，逗号分隔")
+	workers := fs.Int("workers", runtime.NumCPU(), "并行提取的模型会话数")
+	cachePath := fs.String("cache", "", "嵌入向量缓存文件路径，文件内容未变化时不再重新提取")
+	cluster := fs.Float64("cluster", 0, "聚类的余弦相似度阈值，>0时输出聚类摘要")
+	numClusters := fs.Int("num-clusters", 0, "已知类别数，>0时按类别数聚类并输出聚类摘要")
+	if err := parseFlags(fs, args, c); err != nil {
+		return err
+	}
+	if *dir == "" {
+		return errors.New("用法: spk matrix -model=<模型路径> -dir=<音频目录> [-cache=<缓存文件>] [-cluster=0.7] [-workers=N]")
+	}
+
+	paths, err := batch.ListFiles(*dir, *recursive, strings.Split(*exts, ","))
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("目录 %s 中没有音频文件", *dir)
+	}
+
+	pool, err := speaker.NewPool(min(*workers, len(paths)), c.loadSpeaker)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	key, err := c.cacheKey(pool.Fingerprint())
+	if err != nil {
+		return err
+	}
+	cache, err := batch.OpenCache(*cachePath, key)
+	if err != nil {
+		return err
+	}
+
+	result, err := batch.Compare(context.Background(), pool, paths, cache)
+	if err != nil {
+		return err
+	}
+	if err := cache.Save(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "已处理 %d 个文件（缓存命中 %d，失败 %d）\n", len(paths), result.CacheHits, len(result.Failed))
+
+	m := &similarityMatrix{Files: result.Files, Scores: result.Scores, CacheHits: result.CacheHits}
+	if len(result.Failed) > 0 {
+		m.Failed = make(map[string]string)
+		for _, f := range result.Failed {
+			m.Failed[f.Path] = f.Err.Error()
+			fmt.Fprintf(os.Stderr, "跳过 %v\n", f)
+		}
+	}
+	if *cluster > 0 || *numClusters > 0 {
+		m.Summary = result.Cluster(*cluster, *numClusters)
+		index := make(map[string]int, len(m.Files))
+		for i, c := range m.Summary.Clusters {
+			for _, file := range c.Files {
+				index[file] = i
+			}
+		}
+		m.labels = make([]int, len(m.Files))
+		for i, file := range m.Files {
+			m.labels[i] = index[file]
+		}
+	}
+	return c.print(m)
+}
+
+// cacheKey 返回嵌入向量缓存的键：模型指纹加上嵌入向量变换文件的内容哈希
+func (c *commonFlags) cacheKey(fingerprint string) (string, error) {
+	if c.transform == "" {
+		return fingerprint, nil
+	}
+	data, err := os.ReadFile(c.transform)
+	if err != nil {
+		return "", fmt.Errorf("读取嵌入向量变换失败: %w", err)
+	}
+	return fingerprint + "+" + batch.Hash(data), nil
+}
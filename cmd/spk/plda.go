@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/seastart/3dspeaker-onnx-go/plda"
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// runPLDA 使用带说话人标签的音频训练PLDA模型，可选地用无标签的域内音频自适应
+func runPLDA(args []string) error {
+	defaults := plda.DefaultConfig()
+	adaptDefaults := plda.DefaultAdaptConfig()
+
+	fs := flag.NewFlagSet("plda", flag.ExitOnError)
+	modelPath := fs.String("model", "", "ONNX模型文件路径")
+	configPath := fs.String("config", "", "FBANK特征配置文件路径")
+	listPath := fs.String("list", "", "训练列表路径，每行为\"说话人 路径\"")
+	root := fs.String("root", "", "列表中相对路径的根目录")
+	transformPath := fs.String("transform", "", "嵌入向量变换序列路径，提供时在变换后的嵌入向量上训练")
+	ldaDim := fs.Int("lda-dim", defaults.LDADim, "LDA降维后的维度，0表示不做LDA")
+	iterations := fs.Int("iterations", defaults.Iterations, "EM迭代次数")
+	adaptPath := fs.String("adapt", "", "无标签域内音频列表路径，每行一个路径，提供时对模型做域自适应")
+	withinScale := fs.Float64("within-scale", adaptDefaults.WithinScale, "自适应时分配给说话人内协方差的比例")
+	betweenScale := fs.Float64("between-scale", adaptDefaults.BetweenScale, "自适应时分配给说话人间协方差的比例")
+	output := fs.String("output", "plda.json", "PLDA模型输出路径")
+	fs.Parse(args)
+
+	if *modelPath == "" || *listPath == "" {
+		return errors.New("用法: spk plda -model=<模型路径> -list=<训练列表> [-lda-dim=128] [-adapt=<域内音频列表>] [-output=plda.json]")
+	}
+
+	entries, err := readList(*listPath, 2)
+	if err != nil {
+		return err
+	}
+	var adaptPaths []string
+	if *adaptPath != "" {
+		lines, err := readList(*adaptPath, 1)
+		if err != nil {
+			return err
+		}
+		for _, line := range lines {
+			adaptPaths = append(adaptPaths, line[0])
+		}
+	}
+
+	spk, err := loadSpeaker(*modelPath, *configPath)
+	if err != nil {
+		return err
+	}
+	defer spk.Close()
+	if err := useTransform(spk, *transformPath); err != nil {
+		return err
+	}
+
+	embeddings := make([]*speaker.Embedding, len(entries))
+	labels := make([]string, len(entries))
+	for i, entry := range entries {
+		labels[i] = entry[0]
+		if embeddings[i], err = embedFile(spk, *root, entry[1]); err != nil {
+			return err
+		}
+		if (i+1)%1000 == 0 {
+			fmt.Fprintf(os.Stderr, "已提取 %d/%d 条训练音频\n", i+1, len(entries))
+		}
+	}
+
+	fmt.Fprintln(os.Stderr, "正在训练PLDA...")
+	model, err := plda.Train(embeddings, labels, plda.Config{LDADim: *ldaDim, Iterations: *iterations})
+	if err != nil {
+		return fmt.Errorf("训练PLDA失败: %w", err)
+	}
+	model.SetFingerprint(spk.Fingerprint())
+
+	if len(adaptPaths) > 0 {
+		inDomain := make([]*speaker.Embedding, len(adaptPaths))
+		for i, path := range adaptPaths {
+			if inDomain[i], err = embedFile(spk, *root, path); err != nil {
+				return err
+			}
+		}
+		cfg := adaptDefaults
+		cfg.WithinScale = *withinScale
+		cfg.BetweenScale = *betweenScale
+		if err := model.Adapt(inDomain, cfg); err != nil {
+			return fmt.Errorf("PLDA自适应失败: %w", err)
+		}
+		fmt.Printf("已使用 %d 条域内音频自适应\n", len(inDomain))
+	}
+
+	if err := model.Save(*output); err != nil {
+		return err
+	}
+	fmt.Printf("PLDA模型已写入: %s (输入维度 %d, 输出维度 %d)\n", *output, model.Dim(), model.OutputDim())
+	return nil
+}
+
+// readList 读取以空白分隔的列表文件，每行须有fields列，空行和以#开头的行会被忽略
+func readList(path string, fields int) ([][]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开列表失败: %w", err)
+	}
+	defer f.Close()
+
+	var lines [][]string
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		parts := strings.Fields(text)
+		if len(parts) != fields {
+			return nil, fmt.Errorf("%s 第%d行应有%d列: %s", path, line, fields, text)
+		}
+		lines = append(lines, parts)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取列表失败: %w", err)
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("列表 %s 为空", path)
+	}
+	return lines, nil
+}
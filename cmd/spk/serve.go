@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc"
+
+	"github.com/seastart/3dspeaker-onnx-go/gallery"
+	"github.com/seastart/3dspeaker-onnx-go/rpc"
+	"github.com/seastart/3dspeaker-onnx-go/server"
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// runServe 启动HTTP REST服务，指定-grpc-addr时同时启动gRPC服务
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	c := addCommonFlags(fs)
+	t := addThresholdFlags(fs)
+	cfg := server.DefaultConfig()
+	addr := fs.String("addr", ":8080", "监听地址")
+	grpcAddr := fs.String("grpc-addr", "", "gRPC服务监听地址，为空时不启动")
+	galleryPath := fs.String("gallery", "speakers.gallery", "说话人库路径，不存在时自动创建")
+	workers := fs.Int("workers", runtime.NumCPU(), "模型会话数，即同时处理的请求数")
+	identifyThreshold := fs.Float64("identify-threshold", math.NaN(), "辨认时接受候选说话人的阈值，未指定时与验证阈值相同")
+	fs.IntVar(&cfg.TopK, "top", cfg.TopK, "辨认默认返回的候选说话人数")
+	fs.Int64Var(&cfg.MaxBodyBytes, "max-body", cfg.MaxBodyBytes, "请求体大小上限（字节）")
+	fs.Float64Var(&cfg.MaxAudioSeconds, "max-audio", cfg.MaxAudioSeconds, "每段音频的时长上限（秒）")
+	allowOrigin := fs.String("allow-origin", "", "允许WebSocket跨域连接的Origin，逗号分隔，*表示全部；为空时只允许同源")
+	if err := parseFlags(fs, args, c); err != nil {
+		return err
+	}
+
+	pool, err := speaker.NewPool(*workers, func() (*speaker.Speaker, error) {
+		spk, err := c.loadSpeaker()
+		if err != nil {
+			return nil, err
+		}
+		threshold, err := t.apply(spk)
+		if err != nil {
+			spk.Close()
+			return nil, err
+		}
+		cfg.VerifyThreshold = &threshold
+		return spk, nil
+	})
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	if !math.IsNaN(*identifyThreshold) {
+		threshold := float32(*identifyThreshold)
+		cfg.IdentifyThreshold = &threshold
+	}
+	if *allowOrigin != "" {
+		origins := strings.Split(*allowOrigin, ",")
+		cfg.CheckOrigin = func(r *http.Request) bool {
+			return slices.Contains(origins, "*") || slices.Contains(origins, r.Header.Get("Origin"))
+		}
+	}
+
+	store, err := gallery.Open(*galleryPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	srv, err := server.New(pool, store, cfg)
+	if err != nil {
+		return err
+	}
+
+	errc := make(chan error, 2)
+	if *grpcAddr != "" {
+		rpcCfg := rpc.DefaultConfig()
+		rpcCfg.VerifyThreshold, rpcCfg.IdentifyThreshold, rpcCfg.TopK, rpcCfg.MaxAudioSeconds = cfg.VerifyThreshold, cfg.IdentifyThreshold, cfg.TopK, cfg.MaxAudioSeconds
+		svc, err := rpc.New(pool, store, rpcCfg)
+		if err != nil {
+			return err
+		}
+		lis, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			return err
+		}
+		g := grpc.NewServer()
+		svc.Register(g)
+		defer g.Stop()
+		go func() { errc <- g.Serve(lis) }()
+		fmt.Fprintf(os.Stderr, "gRPC服务监听 %s\n", *grpcAddr)
+	}
+
+	fmt.Fprintf(os.Stderr, "说话人识别服务监听 %s（%d个模型会话，库中 %d 个说话人，验证阈值 %.2f）\n", *addr, pool.Size(), store.Len(), *cfg.VerifyThreshold)
+	go func() { errc <- http.ListenAndServe(*addr, srv) }()
+	return <-errc
+}
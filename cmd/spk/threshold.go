@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/seastart/3dspeaker-onnx-go/eval"
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// runThreshold 根据带标签的数据推荐判决阈值并写入阈值配置文件
+func runThreshold(args []string) error {
+	defaults := eval.DefaultThresholdOptions()
+
+	fs := flag.NewFlagSet("threshold", flag.ExitOnError)
+	modelPath := fs.String("model", "", "ONNX模型文件路径（使用-trials时必需）")
+	configPath := fs.String("config", "", "FBANK特征配置文件路径")
+	trialsPath := fs.String("trials", "", "试验列表路径，每行为\"标签 路径1 路径2\"")
+	root := fs.String("root", "", "试验列表中相对路径的根目录")
+	transformPath := fs.String("transform", "", "嵌入向量变换序列路径")
+	pldaPath := fs.String("plda", "", "PLDA模型路径，提供时使用PLDA打分代替余弦打分")
+	scoresPath := fs.String("scores", "", "带标签的分数列表路径，每行为\"标签 ... 分数\"，可替代-trials")
+	criterion := fs.String("criterion", defaults.Criterion.String(), "阈值选择准则: far/eer/mindcf")
+	targetFAR := fs.Float64("far", defaults.TargetFAR, "目标虚警率（criterion=far）")
+	pTarget := fs.Float64("p-target", defaults.DCF.PTarget, "目标说话人先验概率（criterion=mindcf）")
+	cMiss := fs.Float64("c-miss", defaults.DCF.CMiss, "漏检代价（criterion=mindcf）")
+	cFA := fs.Float64("c-fa", defaults.DCF.CFA, "虚警代价（criterion=mindcf）")
+	bootstrap := fs.Int("bootstrap", defaults.Bootstrap, "自助法重采样次数，0表示不计算置信区间")
+	confidence := fs.Float64("confidence", defaults.Confidence, "置信水平")
+	output := fs.String("output", "threshold.json", "阈值配置输出路径")
+	fs.Parse(args)
+
+	if (*trialsPath == "") == (*scoresPath == "") {
+		return errors.New("用法: spk threshold (-trials=<试验列表> -model=<模型路径> | -scores=<分数列表>) [-criterion=far -far=0.01] [-output=threshold.json]")
+	}
+
+	c, err := eval.ParseCriterion(*criterion)
+	if err != nil {
+		return err
+	}
+	opts := eval.ThresholdOptions{
+		Criterion:  c,
+		TargetFAR:  *targetFAR,
+		DCF:        eval.DCFParams{PTarget: *pTarget, CMiss: *cMiss, CFA: *cFA},
+		Bootstrap:  *bootstrap,
+		Confidence: *confidence,
+		Seed:       defaults.Seed,
+	}
+
+	var targets, nonTargets []float64
+	var fingerprint, scorer string
+	if *scoresPath != "" {
+		targets, nonTargets, err = eval.ReadScoresFile(*scoresPath)
+		if err != nil {
+			return err
+		}
+	} else {
+		if *modelPath == "" {
+			return errors.New("使用-trials时必须指定-model")
+		}
+		trials, err := eval.ReadTrialsFile(*trialsPath)
+		if err != nil {
+			return err
+		}
+		spk, err := loadSpeaker(*modelPath, *configPath)
+		if err != nil {
+			return err
+		}
+		defer spk.Close()
+		if err := useTransform(spk, *transformPath); err != nil {
+			return err
+		}
+		if err := usePLDA(spk, *pldaPath); err != nil {
+			return err
+		}
+
+		scores, err := scoreTrials(spk, trials, *root)
+		if err != nil {
+			return err
+		}
+		targets, nonTargets = splitScores(scores)
+		fingerprint = spk.Fingerprint()
+		scorer = speaker.ScorerName(spk.Scorer())
+	}
+
+	result, err := eval.RecommendThreshold(targets, nonTargets, opts)
+	if err != nil {
+		return fmt.Errorf("推荐阈值失败: %w", err)
+	}
+
+	config := &speaker.ThresholdConfig{
+		Threshold:   float32(result.Threshold),
+		Lower:       float32(result.Lower),
+		Upper:       float32(result.Upper),
+		Confidence:  result.Confidence,
+		Criterion:   result.Criterion.String(),
+		FAR:         result.FAR,
+		FRR:         result.FRR,
+		Trials:      len(targets) + len(nonTargets),
+		Fingerprint: fingerprint,
+		Scorer:      scorer,
+	}
+	if err := config.Save(*output); err != nil {
+		return err
+	}
+
+	// 输出结果
+	fmt.Printf("\n推荐阈值 (%s): %.4f\n", result.Criterion, result.Threshold)
+	if *bootstrap > 0 {
+		fmt.Printf("%.0f%%置信区间: [%.4f, %.4f] (%d次重采样)\n", result.Confidence*100, result.Lower, result.Upper, *bootstrap)
+	}
+	fmt.Printf("虚警率: %.4f%%, 漏检率: %.4f%%\n", result.FAR*100, result.FRR*100)
+	fmt.Printf("阈值配置已写入: %s\n", *output)
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// runTransform 使用域内音频拟合嵌入向量变换序列（均值减除、LDA、白化、长度规整）
+func runTransform(args []string) error {
+	fs := flag.NewFlagSet("transform", flag.ExitOnError)
+	modelPath := fs.String("model", "", "ONNX模型文件路径")
+	configPath := fs.String("config", "", "FBANK特征配置文件路径")
+	listPath := fs.String("list", "", "域内音频列表路径，每行一个路径；使用LDA时每行为\"说话人 路径\"")
+	root := fs.String("root", "", "列表中相对路径的根目录")
+	mean := fs.Bool("mean", true, "是否减去全局均值")
+	ldaDim := fs.Int("lda-dim", 0, "LDA投影维度，0表示不做LDA")
+	whitening := fs.String("whitening", speaker.NoWhitening.String(), "白化方法: none/pca/zca")
+	lengthNorm := fs.Bool("length-norm", true, "是否做长度规整")
+	output := fs.String("output", "transform.json", "变换序列输出路径")
+	fs.Parse(args)
+
+	if *modelPath == "" || *listPath == "" {
+		return errors.New("用法: spk transform -model=<模型路径> -list=<域内音频列表> [-whitening=zca] [-lda-dim=128] [-output=transform.json]")
+	}
+	method, err := speaker.ParseWhitenMethod(*whitening)
+	if err != nil {
+		return err
+	}
+
+	fields := 1
+	if *ldaDim > 0 {
+		fields = 2
+	}
+	entries, err := readList(*listPath, fields)
+	if err != nil {
+		return err
+	}
+
+	spk, err := loadSpeaker(*modelPath, *configPath)
+	if err != nil {
+		return err
+	}
+	defer spk.Close()
+
+	embeddings := make([]*speaker.Embedding, len(entries))
+	var labels []string
+	for i, entry := range entries {
+		if fields == 2 {
+			labels = append(labels, entry[0])
+		}
+		if embeddings[i], err = embedFile(spk, *root, entry[fields-1]); err != nil {
+			return err
+		}
+	}
+
+	cfg := speaker.TransformConfig{MeanSubtraction: *mean, LDADim: *ldaDim, Whitening: method, LengthNorm: *lengthNorm}
+	pipeline, err := speaker.FitPipeline(embeddings, labels, cfg)
+	if err != nil {
+		return fmt.Errorf("拟合变换序列失败: %w", err)
+	}
+	pipeline.SetFingerprint(spk.Fingerprint())
+	if err := pipeline.Save(*output); err != nil {
+		return err
+	}
+	fmt.Printf("变换序列已写入: %s (%d个变换, %d条域内音频)\n", *output, len(pipeline.Stages()), len(embeddings))
+	return nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/seastart/3dspeaker-onnx-go/audio"
+	"github.com/seastart/3dspeaker-onnx-go/eval"
+	"github.com/seastart/3dspeaker-onnx-go/plda"
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// trialScore 一条试验及其分数
+type trialScore struct {
+	eval.Trial
+	Score float32
+}
+
+// scoreTrials 对试验列表逐条打分，每个音频只提取一次嵌入向量
+// root非空时，试验列表中的相对路径以root为根目录
+func scoreTrials(spk *speaker.Speaker, trials []eval.Trial, root string) ([]trialScore, error) {
+	embeddings := make(map[string]*speaker.Embedding)
+	embed := func(path string) (*speaker.Embedding, error) {
+		if emb, ok := embeddings[path]; ok {
+			return emb, nil
+		}
+		emb, err := embedFile(spk, root, path)
+		if err != nil {
+			return nil, err
+		}
+		embeddings[path] = emb
+		return emb, nil
+	}
+
+	scores := make([]trialScore, len(trials))
+	for i, trial := range trials {
+		enroll, err := embed(trial.Enroll)
+		if err != nil {
+			return nil, fmt.Errorf("第%d条试验失败: %w", i+1, err)
+		}
+		test, err := embed(trial.Test)
+		if err != nil {
+			return nil, fmt.Errorf("第%d条试验失败: %w", i+1, err)
+		}
+		score, err := spk.CompareEmbeddings(enroll, test)
+		if err != nil {
+			return nil, fmt.Errorf("第%d条试验打分失败: %w", i+1, err)
+		}
+		scores[i] = trialScore{Trial: trial, Score: score}
+
+		if (i+1)%1000 == 0 {
+			fmt.Fprintf(os.Stderr, "已完成 %d/%d 条试验\n", i+1, len(trials))
+		}
+	}
+	return scores, nil
+}
+
+// embedFile 读取音频文件并提取嵌入向量，root非空时相对路径以root为根目录
+func embedFile(spk *speaker.Speaker, root, path string) (*speaker.Embedding, error) {
+	if root != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	pcm, err := audio.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取音频 %s 失败: %w", path, err)
+	}
+	emb, err := spk.ExtractEmbedding(pcm)
+	if err != nil {
+		return nil, fmt.Errorf("提取 %s 的嵌入向量失败: %w", path, err)
+	}
+	return emb, nil
+}
+
+// splitScores 按标签拆分分数
+func splitScores(scores []trialScore) (targets, nonTargets []float64) {
+	for _, s := range scores {
+		if s.Target {
+			targets = append(targets, float64(s.Score))
+		} else {
+			nonTargets = append(nonTargets, float64(s.Score))
+		}
+	}
+	return targets, nonTargets
+}
+
+// writeScores 将逐条试验分数写入文件，格式为"标签 路径1 路径2 分数"，可作为threshold -scores的输入
+func writeScores(path string, scores []trialScore) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("创建分数文件失败: %w", err)
+	}
+	for _, s := range scores {
+		label := 0
+		if s.Target {
+			label = 1
+		}
+		fmt.Fprintf(f, "%d %s %s %.6f\n", label, s.Enroll, s.Test, s.Score)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("写入分数文件失败: %w", err)
+	}
+	return nil
+}
+
+// loadSpeaker 加载模型
+func loadSpeaker(modelPath, configPath string) (*speaker.Speaker, error) {
+	fmt.Fprintln(os.Stderr, "正在加载模型...")
+	spk, err := speaker.New(modelPath, configPath)
+	if err != nil {
+		return nil, fmt.Errorf("加载模型失败: %w", err)
+	}
+	return spk, nil
+}
+
+// useTransform 加载嵌入向量变换序列，path为空时不做任何操作
+func useTransform(spk *speaker.Speaker, path string) error {
+	if path == "" {
+		return nil
+	}
+	return spk.LoadTransform(path)
+}
+
+// usePLDA 加载PLDA模型并设置为打分器，path为空时不做任何操作
+func usePLDA(spk *speaker.Speaker, path string) error {
+	if path == "" {
+		return nil
+	}
+	model, err := plda.Load(path)
+	if err != nil {
+		return err
+	}
+	if fp := model.Fingerprint(); fp != "" && fp != spk.Fingerprint() {
+		return fmt.Errorf("PLDA模型的模型指纹 %s 与当前模型 %s 不一致", fp, spk.Fingerprint())
+	}
+	spk.SetScorer(model)
+	return nil
+}
@@ -0,0 +1,244 @@
+package diarize
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/seastart/3dspeaker-onnx-go/audio"
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// Extractor 嵌入向量提取器，*speaker.Speaker和*speaker.ModelHandle都实现了该接口
+type Extractor interface {
+	ExtractEmbedding(pcmData []int16) (*speaker.Embedding, error)
+}
+
+// ChangeMethod 说话人变化检测使用的距离
+type ChangeMethod int
+
+const (
+	// CosineChange 相邻两个窗口嵌入向量的余弦距离
+	CosineChange ChangeMethod = iota
+	// BICChange 变化点两侧窗口嵌入向量序列的ΔBIC（对角高斯，按维度平均）
+	BICChange
+)
+
+// String 返回变化检测方法的名称
+func (m ChangeMethod) String() string {
+	switch m {
+	case CosineChange:
+		return "cosine"
+	case BICChange:
+		return "bic"
+	default:
+		return fmt.Sprintf("ChangeMethod(%d)", int(m))
+	}
+}
+
+// ParseChangeMethod 解析变化检测方法名称（cosine、bic）
+func ParseChangeMethod(name string) (ChangeMethod, error) {
+	switch name {
+	case "cosine":
+		return CosineChange, nil
+	case "bic":
+		return BICChange, nil
+	default:
+		return 0, fmt.Errorf("未知的变化检测方法: %s", name)
+	}
+}
+
+// ChangeConfig 说话人变化检测配置
+type ChangeConfig struct {
+	Method        ChangeMethod
+	WindowSeconds float64 // 提取嵌入向量的窗口长度（秒）
+	HopSeconds    float64 // 候选变化点的间隔（秒），也是窗口的移动步长
+	// ContextSeconds BIC方法中变化点每侧参与建模的时长（秒），不小于WindowSeconds
+	ContextSeconds float64
+	// Penalty BIC方法的惩罚系数λ
+	Penalty float64
+	// Threshold 峰值需超过的分数：余弦方法为余弦距离，BIC方法为按维度平均的ΔBIC
+	Threshold float64
+	// MinGapSeconds 相邻变化点之间的最小间隔（秒），间隔内只保留分数最高的峰值
+	MinGapSeconds float64
+	// ConfidenceScale 置信度为1-exp(-(分数-阈值)/ConfidenceScale)
+	ConfidenceScale float64
+}
+
+// DefaultChangeConfig 返回给定方法的默认变化检测配置
+func DefaultChangeConfig(method ChangeMethod) ChangeConfig {
+	cfg := ChangeConfig{
+		Method:          method,
+		WindowSeconds:   1.5,
+		HopSeconds:      0.25,
+		MinGapSeconds:   1,
+		Threshold:       0.4,
+		ConfidenceScale: 0.2,
+	}
+	if method == BICChange {
+		cfg.ContextSeconds = 3
+		cfg.Penalty = 1
+		cfg.Threshold = 0
+		cfg.ConfidenceScale = 0.5
+	}
+	return cfg
+}
+
+// Change 一个说话人变化点
+type Change struct {
+	Time       float64 `json:"time"`       // 变化时刻（秒）
+	Score      float64 `json:"score"`      // 该时刻的变化分数
+	Confidence float64 `json:"confidence"` // 置信度[0,1)
+}
+
+// ChangeDetector 说话人变化检测器，不做聚类，只给出说话人切换的时刻
+type ChangeDetector struct {
+	extractor Extractor
+	cfg       ChangeConfig
+}
+
+// NewChangeDetector 创建说话人变化检测器
+func NewChangeDetector(extractor Extractor, cfg ChangeConfig) (*ChangeDetector, error) {
+	if extractor == nil {
+		return nil, errors.New("嵌入向量提取器为空")
+	}
+	if _, _, err := windowSamples(cfg.WindowSeconds, cfg.HopSeconds); err != nil {
+		return nil, err
+	}
+	if cfg.Method == BICChange && cfg.ContextSeconds < cfg.WindowSeconds {
+		return nil, fmt.Errorf("BIC上下文时长%v不能小于窗口长度%v", cfg.ContextSeconds, cfg.WindowSeconds)
+	}
+	return &ChangeDetector{extractor: extractor, cfg: cfg}, nil
+}
+
+// Detect 检测一段音频中的说话人变化点[必须是16khz单声道音频]
+//
+// 参数:
+//   - pcmData: PCM音频数据，int16格式
+//
+// 返回:
+//   - 按时间排序的变化点
+//   - 可能的错误
+func (d *ChangeDetector) Detect(pcmData []int16) ([]Change, error) {
+	embs, err := d.embedChunks(pcmData)
+	if err != nil {
+		return nil, err
+	}
+	return d.pickPeaks(d.scoreCurve(embs)), nil
+}
+
+// embedChunks 以HopSeconds为步长提取每个窗口的归一化嵌入向量，静音窗口为nil
+func (d *ChangeDetector) embedChunks(pcmData []int16) ([][]float32, error) {
+	win := int(d.cfg.WindowSeconds * audio.SampleRate)
+	hop := int(d.cfg.HopSeconds * audio.SampleRate)
+	var embs [][]float32
+	for start := 0; start+win <= len(pcmData); start += hop {
+		emb, err := d.extractor.ExtractEmbedding(pcmData[start : start+win])
+		if errors.Is(err, speaker.ErrZeroNorm) {
+			embs = append(embs, nil)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("提取窗口嵌入向量失败: %w", err)
+		}
+		data := append([]float32(nil), emb.GetData()...)
+		if n := float32(math.Sqrt(float64(speaker.Dot(data, data)))); n > 0 {
+			for i := range data {
+				data[i] /= n
+			}
+		}
+		embs = append(embs, data)
+	}
+	return embs, nil
+}
+
+// scoreCurve 计算每个候选变化点的分数，第k个点位于第k个窗口的起点，无法计算时为NaN
+func (d *ChangeDetector) scoreCurve(embs [][]float32) []float64 {
+	// 变化点左侧窗口的结束位置与变化点对齐
+	lag := int(math.Round(d.cfg.WindowSeconds / d.cfg.HopSeconds))
+	scores := make([]float64, len(embs))
+	for k := range scores {
+		scores[k] = math.NaN()
+		if k < lag {
+			continue
+		}
+		switch d.cfg.Method {
+		case BICChange:
+			// 每侧取完全落在上下文内的窗口
+			context := int(math.Round((d.cfg.ContextSeconds - d.cfg.WindowSeconds) / d.cfg.HopSeconds))
+			if k-lag-context < 0 || k+context >= len(embs) {
+				continue
+			}
+			scores[k] = deltaBIC(embs[k-lag-context:k-lag+1], embs[k:k+context+1], d.cfg.Penalty)
+		default:
+			left, right := embs[k-lag], embs[k]
+			if left != nil && right != nil {
+				scores[k] = 1 - float64(speaker.Dot(left, right))
+			}
+		}
+	}
+	return scores
+}
+
+// deltaBIC 计算两组向量用两个对角高斯建模相对于用一个对角高斯建模的ΔBIC，按维度平均
+// 正值表示两侧更可能来自不同说话人；含nil（静音窗口）时返回NaN
+func deltaBIC(left, right [][]float32, penalty float64) float64 {
+	all := append(append([][]float32(nil), left...), right...)
+	for _, v := range all {
+		if v == nil {
+			return math.NaN()
+		}
+	}
+	dim := len(all[0])
+	n, n1, n2 := float64(len(all)), float64(len(left)), float64(len(right))
+	// 方差下限，避免样本数少时对数行列式发散
+	const varFloor = 1e-4
+	logVar := func(x [][]float32, j int) float64 {
+		var mean, sq float64
+		for _, v := range x {
+			mean += float64(v[j])
+		}
+		mean /= float64(len(x))
+		for _, v := range x {
+			d := float64(v[j]) - mean
+			sq += d * d
+		}
+		return math.Log(math.Max(sq/float64(len(x)), varFloor))
+	}
+	var r float64
+	for j := 0; j < dim; j++ {
+		r += n*logVar(all, j) - n1*logVar(left, j) - n2*logVar(right, j)
+	}
+	r /= 2
+	// 两个模型比一个模型多出均值和方差各dim个参数
+	p := penalty * 0.5 * 2 * float64(dim) * math.Log(n)
+	return (r - p) / float64(dim)
+}
+
+// pickPeaks 选取超过阈值、且在最小间隔内分数最高的局部极大值
+func (d *ChangeDetector) pickPeaks(scores []float64) []Change {
+	gap := max(int(math.Round(d.cfg.MinGapSeconds/d.cfg.HopSeconds)), 1)
+	hop := int(d.cfg.HopSeconds * audio.SampleRate)
+	var changes []Change
+	for k, s := range scores {
+		if math.IsNaN(s) || s <= d.cfg.Threshold {
+			continue
+		}
+		peak := true
+		for j := max(k-gap+1, 0); j < min(k+gap, len(scores)) && peak; j++ {
+			// 分数相同时保留较早的点
+			if j != k && !math.IsNaN(scores[j]) && (scores[j] > s || (scores[j] == s && j < k)) {
+				peak = false
+			}
+		}
+		if !peak {
+			continue
+		}
+		confidence := 1.0
+		if d.cfg.ConfidenceScale > 0 {
+			confidence = 1 - math.Exp(-(s-d.cfg.Threshold)/d.cfg.ConfidenceScale)
+		}
+		changes = append(changes, Change{Time: float64(k*hop) / audio.SampleRate, Score: s, Confidence: confidence})
+	}
+	return changes
+}
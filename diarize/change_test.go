@@ -0,0 +1,72 @@
+package diarize
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/seastart/3dspeaker-onnx-go/audio"
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// fakeExtractor 根据正、负样本的比例生成嵌入向量：正样本代表说话人A，负样本代表说话人B
+type fakeExtractor struct {
+	rng *rand.Rand
+}
+
+func (f *fakeExtractor) ExtractEmbedding(pcm []int16) (*speaker.Embedding, error) {
+	data := make([]float32, 8)
+	for _, v := range pcm {
+		if v > 0 {
+			data[0]++
+		} else {
+			data[1]++
+		}
+	}
+	data[0] /= float32(len(pcm))
+	data[1] /= float32(len(pcm))
+	for i := 2; i < len(data); i++ {
+		data[i] = float32(0.05 * f.rng.NormFloat64())
+	}
+	return speaker.NewEmbedding(data), nil
+}
+
+// TestChangeDetector 测试两种方法都能在说话人切换处给出变化点
+func TestChangeDetector(t *testing.T) {
+	// 说话人A 6秒，B 5秒，A 6秒
+	var pcm []int16
+	for _, seg := range []struct {
+		seconds int
+		value   int16
+	}{{6, 100}, {5, -100}, {6, 100}} {
+		for i := 0; i < seg.seconds*audio.SampleRate; i++ {
+			pcm = append(pcm, seg.value)
+		}
+	}
+
+	for _, method := range []ChangeMethod{CosineChange, BICChange} {
+		d, err := NewChangeDetector(&fakeExtractor{rand.New(rand.NewSource(1))}, DefaultChangeConfig(method))
+		if err != nil {
+			t.Fatalf("%s: 创建变化检测器失败: %v", method, err)
+		}
+		changes, err := d.Detect(pcm)
+		if err != nil {
+			t.Fatalf("%s: 检测失败: %v", method, err)
+		}
+		if len(changes) != 2 {
+			t.Fatalf("%s: 应检测到2个变化点，实际为%+v", method, changes)
+		}
+		for i, want := range []float64{6, 11} {
+			if math.Abs(changes[i].Time-want) > 0.25 || changes[i].Confidence <= 0 || changes[i].Confidence >= 1 {
+				t.Fatalf("%s: 第%d个变化点不正确: %+v，应约为%v秒", method, i, changes[i], want)
+			}
+		}
+	}
+
+	if _, err := NewChangeDetector(&fakeExtractor{}, ChangeConfig{Method: BICChange, WindowSeconds: 2, HopSeconds: 0.5, ContextSeconds: 1}); err == nil {
+		t.Fatal("上下文时长小于窗口长度时应返回错误")
+	}
+	if _, err := NewChangeDetector(&fakeExtractor{}, ChangeConfig{Method: CosineChange, WindowSeconds: 2, HopSeconds: 1e-5}); err == nil {
+		t.Fatal("不足一个样本的步长应返回错误")
+	}
+}
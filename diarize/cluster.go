@@ -0,0 +1,322 @@
+package diarize
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/seastart/3dspeaker-onnx-go/internal/mat"
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// merge 层次聚类中的一次合并
+type merge struct {
+	a, b int
+	dist float32
+}
+
+// Agglomerative 使用平均链接的凝聚层次聚类，距离为1-余弦相似度
+// numSpeakers>0时聚成指定数量的类，否则合并所有平均余弦相似度不低于threshold的类
+//
+// 参数:
+//   - embs: L2归一化的嵌入向量
+//   - threshold: 停止合并的余弦相似度阈值
+//   - numSpeakers: 说话人数，<=0表示由阈值决定
+//
+// 返回:
+//   - 每个嵌入向量的类别标签，按首次出现的顺序从0编号
+func Agglomerative(embs [][]float32, threshold float64, numSpeakers int) []int {
+	n := len(embs)
+	if n == 0 {
+		return nil
+	}
+	merges := nnChain(embs)
+	// 平均链接满足单调性，按距离排序后依次合并即可得到任意高度的切分
+	sort.SliceStable(merges, func(i, j int) bool { return merges[i].dist < merges[j].dist })
+
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	clusters := n
+	for _, m := range merges {
+		if numSpeakers > 0 {
+			if clusters <= numSpeakers {
+				break
+			}
+		} else if float64(m.dist) > 1-threshold {
+			break
+		}
+		parent[find(m.b)] = find(m.a)
+		clusters--
+	}
+	labels := make([]int, n)
+	for i := range labels {
+		labels[i] = find(i)
+	}
+	return relabel(labels)
+}
+
+// nnChain 使用最近邻链算法计算平均链接的完整合并序列，时间复杂度O(n²)
+func nnChain(embs [][]float32) []merge {
+	n := len(embs)
+	dist := make([][]float32, n)
+	for i := range dist {
+		dist[i] = make([]float32, n)
+		for j := 0; j < i; j++ {
+			d := 1 - speaker.Dot(embs[i], embs[j])
+			dist[i][j], dist[j][i] = d, d
+		}
+	}
+	size := make([]int, n)
+	active := make([]bool, n)
+	for i := range size {
+		size[i], active[i] = 1, true
+	}
+
+	merges := make([]merge, 0, n-1)
+	var chain []int
+	for len(merges) < n-1 {
+		if len(chain) == 0 {
+			for i := range active {
+				if active[i] {
+					chain = append(chain, i)
+					break
+				}
+			}
+		}
+		a := chain[len(chain)-1]
+		// 并列时优先选择链上的前一个节点，保证算法终止
+		b, best := -1, float32(math.Inf(1))
+		if len(chain) >= 2 {
+			b, best = chain[len(chain)-2], dist[a][chain[len(chain)-2]]
+		}
+		for k := range active {
+			if active[k] && k != a && dist[a][k] < best {
+				b, best = k, dist[a][k]
+			}
+		}
+		if len(chain) < 2 || b != chain[len(chain)-2] {
+			chain = append(chain, b)
+			continue
+		}
+
+		chain = chain[:len(chain)-2]
+		merges = append(merges, merge{a: a, b: b, dist: best})
+		// Lance-Williams更新，合并后的类占用a的位置
+		for k := range active {
+			if active[k] && k != a && k != b {
+				d := (float32(size[a])*dist[a][k] + float32(size[b])*dist[b][k]) / float32(size[a]+size[b])
+				dist[a][k], dist[k][a] = d, d
+			}
+		}
+		size[a] += size[b]
+		active[b] = false
+	}
+	return merges
+}
+
+// SpectralConfig 谱聚类配置
+type SpectralConfig struct {
+	NumSpeakers int     // 说话人数，<=0时按特征值间隔估计
+	MaxSpeakers int     // 估计说话人数时的上限
+	PruneRatio  float64 // 亲和矩阵每行保留的最大元素比例，其余置0
+}
+
+// DefaultSpectralConfig 返回默认的谱聚类配置
+func DefaultSpectralConfig() SpectralConfig {
+	return SpectralConfig{MaxSpeakers: 10, PruneRatio: 0.2}
+}
+
+// Spectral 对嵌入向量做谱聚类
+// 亲和矩阵为截断到非负的余弦相似度，每行只保留最大的一部分元素后对称化；
+// 对归一化拉普拉斯矩阵做特征分解，按最大特征值间隔估计说话人数，再对前k个特征向量做k-means
+//
+// 参数:
+//   - embs: L2归一化的嵌入向量
+//   - cfg: 谱聚类配置
+//
+// 返回:
+//   - 每个嵌入向量的类别标签，按首次出现的顺序从0编号
+func Spectral(embs [][]float32, cfg SpectralConfig) []int {
+	n := len(embs)
+	if n <= 2 {
+		return Agglomerative(embs, 0.5, cfg.NumSpeakers)
+	}
+
+	// 亲和矩阵
+	affinity := mat.New(n, n)
+	keep := max(int(math.Ceil(cfg.PruneRatio*float64(n))), 1)
+	row := make([]float64, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			row[j] = math.Max(float64(speaker.Dot(embs[i], embs[j])), 0)
+		}
+		sorted := append([]float64(nil), row...)
+		sort.Sort(sort.Reverse(sort.Float64Slice(sorted)))
+		cut := sorted[min(keep, n)-1]
+		for j, v := range row {
+			if v >= cut {
+				affinity.Set(i, j, v)
+			}
+		}
+	}
+	affinity.Symmetrize()
+
+	// D^{-1/2} A D^{-1/2}的最大特征值对应归一化拉普拉斯矩阵的最小特征值
+	degree := make([]float64, n)
+	for i := 0; i < n; i++ {
+		for _, v := range affinity.Row(i) {
+			degree[i] += v
+		}
+		degree[i] = 1 / math.Sqrt(math.Max(degree[i], 1e-10))
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			affinity.Set(i, j, affinity.At(i, j)*degree[i]*degree[j])
+		}
+	}
+	values, vectors := mat.SymEigen(affinity)
+
+	k := cfg.NumSpeakers
+	if k <= 0 {
+		k = eigengap(values, cfg.MaxSpeakers)
+	}
+	k = min(k, n)
+
+	// 取前k个特征向量并按行归一化
+	points := make([][]float64, n)
+	for i := range points {
+		p := make([]float64, k)
+		var s float64
+		for j := 0; j < k; j++ {
+			p[j] = vectors.At(i, j)
+			s += p[j] * p[j]
+		}
+		if s > 0 {
+			s = math.Sqrt(s)
+			for j := range p {
+				p[j] /= s
+			}
+		}
+		points[i] = p
+	}
+	return relabel(kmeans(points, k, 20))
+}
+
+// eigengap 根据降序排列的特征值中最大的相邻间隔估计类别数，结果在[1,maxK]之间
+func eigengap(values []float64, maxK int) int {
+	if maxK <= 0 {
+		maxK = 10
+	}
+	k, best := 1, -1.0
+	for i := 0; i < min(maxK, len(values)-1); i++ {
+		if gap := values[i] - values[i+1]; gap > best {
+			k, best = i+1, gap
+		}
+	}
+	return k
+}
+
+// kmeans 使用k-means++初始化的k-means聚类，随机种子固定以保证结果可复现
+func kmeans(points [][]float64, k, iterations int) []int {
+	n := len(points)
+	labels := make([]int, n)
+	if k <= 1 {
+		return labels
+	}
+	rng := rand.New(rand.NewSource(1))
+	sqDist := func(a, b []float64) float64 {
+		var s float64
+		for i := range a {
+			d := a[i] - b[i]
+			s += d * d
+		}
+		return s
+	}
+
+	centers := [][]float64{append([]float64(nil), points[rng.Intn(n)]...)}
+	closest := make([]float64, n)
+	for len(centers) < k {
+		var total float64
+		for i, p := range points {
+			closest[i] = math.Inf(1)
+			for _, c := range centers {
+				closest[i] = math.Min(closest[i], sqDist(p, c))
+			}
+			total += closest[i]
+		}
+		next := 0
+		if total > 0 {
+			r := rng.Float64() * total
+			for next = 0; next < n-1; next++ {
+				if r -= closest[next]; r <= 0 {
+					break
+				}
+			}
+		}
+		centers = append(centers, append([]float64(nil), points[next]...))
+	}
+
+	for it := 0; it < iterations; it++ {
+		changed := it == 0
+		for i, p := range points {
+			best, bestDist := 0, math.Inf(1)
+			for c, center := range centers {
+				if d := sqDist(p, center); d < bestDist {
+					best, bestDist = c, d
+				}
+			}
+			if labels[i] != best {
+				labels[i], changed = best, true
+			}
+		}
+		if !changed {
+			break
+		}
+		// 更新中心，没有分到点的中心保持不变
+		sums := make([][]float64, k)
+		counts := make([]int, k)
+		for i, p := range points {
+			c := labels[i]
+			if sums[c] == nil {
+				sums[c] = make([]float64, len(p))
+			}
+			counts[c]++
+			for j, v := range p {
+				sums[c][j] += v
+			}
+		}
+		for c := range centers {
+			if counts[c] == 0 {
+				continue
+			}
+			for j := range centers[c] {
+				centers[c][j] = sums[c][j] / float64(counts[c])
+			}
+		}
+	}
+	return labels
+}
+
+// relabel 按首次出现的顺序将标签重新编号为0,1,2...
+func relabel(labels []int) []int {
+	mapping := make(map[int]int)
+	out := make([]int, len(labels))
+	for i, l := range labels {
+		id, ok := mapping[l]
+		if !ok {
+			id = len(mapping)
+			mapping[l] = id
+		}
+		out[i] = id
+	}
+	return out
+}
@@ -0,0 +1,344 @@
+package diarize
+
+import (
+	"math"
+	"sort"
+)
+
+// DERConfig 说话人日志错误率的评分配置
+type DERConfig struct {
+	// Collar 参考片段每个边界两侧不计分的时长（秒），md-eval中常用0.25
+	Collar float64
+	// SkipOverlap 为true时不对参考标注中多人同时说话的区域计分
+	SkipOverlap bool
+}
+
+// DERResult 说话人日志的评分结果，时长单位均为秒
+type DERResult struct {
+	Total      float64 `json:"total"`       // 计分区域内的参考说话人时长（重叠区域按说话人数累计）
+	Missed     float64 `json:"missed"`      // 漏检的语音时长
+	FalseAlarm float64 `json:"false_alarm"` // 虚警的语音时长
+	Confusion  float64 `json:"confusion"`   // 说话人混淆时长
+	DER        float64 `json:"der"`         // 说话人日志错误率，(漏检+虚警+混淆)/总时长
+	JER        float64 `json:"jer"`         // Jaccard错误率，各参考说话人1-Jaccard指数的平均值
+	// Mapping 假设说话人到参考说话人的最优映射（使重叠时长最大），未映射的假设说话人不在其中
+	Mapping map[string]string `json:"mapping,omitempty"`
+
+	// jerSum、jerCount 用于多个录音的JER汇总
+	jerSum   float64
+	jerCount int
+}
+
+// MissedRate 返回漏检率
+func (r *DERResult) MissedRate() float64 { return ratio(r.Missed, r.Total) }
+
+// FalseAlarmRate 返回虚警率
+func (r *DERResult) FalseAlarmRate() float64 { return ratio(r.FalseAlarm, r.Total) }
+
+// ConfusionRate 返回说话人混淆率
+func (r *DERResult) ConfusionRate() float64 { return ratio(r.Confusion, r.Total) }
+
+// ratio 计算a/b，b为0时返回0
+func ratio(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
+
+// interval 一个基本区间，区间内参考和假设的活跃说话人不变
+type interval struct {
+	dur      float64
+	ref, hyp []string
+}
+
+// event 扫描线事件
+type event struct {
+	t       float64
+	kind    int // 0参考 1假设 2不计分区域
+	delta   int
+	speaker string
+}
+
+// elementaryIntervals 将参考和假设片段切分为活跃说话人不变的基本区间，并去掉不计分的区域
+func elementaryIntervals(ref, hyp []Turn, cfg DERConfig) []interval {
+	var events []event
+	for _, t := range ref {
+		if t.End <= t.Start {
+			continue
+		}
+		events = append(events, event{t.Start, 0, 1, t.Speaker}, event{t.End, 0, -1, t.Speaker})
+		if cfg.Collar > 0 {
+			for _, b := range []float64{t.Start, t.End} {
+				events = append(events, event{b - cfg.Collar, 2, 1, ""}, event{b + cfg.Collar, 2, -1, ""})
+			}
+		}
+	}
+	for _, t := range hyp {
+		if t.End > t.Start {
+			events = append(events, event{t.Start, 1, 1, t.Speaker}, event{t.End, 1, -1, t.Speaker})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].t < events[j].t })
+
+	// 同一说话人的片段可能互相重叠，按计数维护活跃集合
+	active := [2]map[string]int{make(map[string]int), make(map[string]int)}
+	noScore := 0
+	var out []interval
+	for i := 0; i < len(events); {
+		t := events[i].t
+		for ; i < len(events) && events[i].t == t; i++ {
+			e := events[i]
+			if e.kind == 2 {
+				noScore += e.delta
+				continue
+			}
+			if active[e.kind][e.speaker] += e.delta; active[e.kind][e.speaker] == 0 {
+				delete(active[e.kind], e.speaker)
+			}
+		}
+		if i == len(events) || noScore > 0 || len(active[0])+len(active[1]) == 0 {
+			continue
+		}
+		if cfg.SkipOverlap && len(active[0]) > 1 {
+			continue
+		}
+		out = append(out, interval{dur: events[i].t - t, ref: sortedSpeakers(active[0]), hyp: sortedSpeakers(active[1])})
+	}
+	return out
+}
+
+// sortedSpeakers 返回活跃集合中的说话人
+func sortedSpeakers(m map[string]int) []string {
+	out := make([]string, 0, len(m))
+	for s := range m {
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ComputeDER 计算单个录音的说话人日志错误率和Jaccard错误率
+// 参考与假设说话人之间的一对一映射用匈牙利算法求解：DER使用重叠时长最大的映射，JER使用Jaccard指数之和最大的映射
+//
+// 参数:
+//   - ref: 参考标注
+//   - hyp: 系统输出
+//   - cfg: 评分配置
+//
+// 返回:
+//   - 评分结果
+func ComputeDER(ref, hyp []Turn, cfg DERConfig) DERResult {
+	intervals := elementaryIntervals(ref, hyp, cfg)
+
+	refIndex, hypIndex := map[string]int{}, map[string]int{}
+	for _, iv := range intervals {
+		for _, s := range iv.ref {
+			if _, ok := refIndex[s]; !ok {
+				refIndex[s] = len(refIndex)
+			}
+		}
+		for _, s := range iv.hyp {
+			if _, ok := hypIndex[s]; !ok {
+				hypIndex[s] = len(hypIndex)
+			}
+		}
+	}
+	// 各说话人的时长和参考-假设说话人的共同时长
+	refDur := make([]float64, len(refIndex))
+	hypDur := make([]float64, len(hypIndex))
+	overlap := make([][]float64, len(refIndex))
+	for i := range overlap {
+		overlap[i] = make([]float64, len(hypIndex))
+	}
+
+	var result DERResult
+	for _, iv := range intervals {
+		nr, nh := len(iv.ref), len(iv.hyp)
+		result.Total += iv.dur * float64(nr)
+		result.Missed += iv.dur * float64(max(nr-nh, 0))
+		result.FalseAlarm += iv.dur * float64(max(nh-nr, 0))
+		for _, r := range iv.ref {
+			refDur[refIndex[r]] += iv.dur
+			for _, h := range iv.hyp {
+				overlap[refIndex[r]][hypIndex[h]] += iv.dur
+			}
+		}
+		for _, h := range iv.hyp {
+			hypDur[hypIndex[h]] += iv.dur
+		}
+	}
+
+	// DER映射: 使正确归属的时长最大
+	refNames, hypNames := names(refIndex), names(hypIndex)
+	assign := hungarian(negate(overlap))
+	refOf := make(map[string]string)
+	for r, name := range refNames {
+		if h := assign[r]; h >= 0 {
+			refOf[hypNames[h]] = name
+		}
+	}
+	// 混淆时长为双方都有人说话的时长减去映射后归属正确的时长
+	var matchedTotal, correct float64
+	for _, iv := range intervals {
+		matchedTotal += iv.dur * float64(min(len(iv.ref), len(iv.hyp)))
+		for _, h := range iv.hyp {
+			for _, r := range iv.ref {
+				if refOf[h] == r {
+					correct += iv.dur
+				}
+			}
+		}
+	}
+	result.Confusion = matchedTotal - correct
+	result.DER = ratio(result.Missed+result.FalseAlarm+result.Confusion, result.Total)
+	result.Mapping = make(map[string]string)
+	for h, r := range refOf {
+		if overlap[refIndex[r]][hypIndex[h]] > 0 {
+			result.Mapping[h] = r
+		}
+	}
+
+	// JER映射: 使Jaccard指数之和最大，未映射的参考说话人错误率为1
+	jaccard := make([][]float64, len(refIndex))
+	for r := range jaccard {
+		jaccard[r] = make([]float64, len(hypIndex))
+		for h := range jaccard[r] {
+			jaccard[r][h] = ratio(overlap[r][h], refDur[r]+hypDur[h]-overlap[r][h])
+		}
+	}
+	assign = hungarian(negate(jaccard))
+	for r := range refDur {
+		result.jerCount++
+		result.jerSum += 1
+		if h := assign[r]; h >= 0 {
+			result.jerSum -= jaccard[r][h]
+		}
+	}
+	result.JER = ratio(result.jerSum, float64(result.jerCount))
+	return result
+}
+
+// ScoreFiles 对多个录音评分并汇总，参考标注中有但系统输出中没有的录音计为全部漏检，
+// 只出现在系统输出中的录音被忽略
+//
+// 参数:
+//   - ref: 录音标识到参考标注的映射（例如ReadRTTM的结果）
+//   - hyp: 录音标识到系统输出的映射
+//   - cfg: 评分配置
+//
+// 返回:
+//   - 汇总结果（不含Mapping）和每个录音的结果
+func ScoreFiles(ref, hyp map[string][]Turn, cfg DERConfig) (DERResult, map[string]DERResult) {
+	var total DERResult
+	perFile := make(map[string]DERResult, len(ref))
+	for id, turns := range ref {
+		r := ComputeDER(turns, hyp[id], cfg)
+		perFile[id] = r
+		total.Total += r.Total
+		total.Missed += r.Missed
+		total.FalseAlarm += r.FalseAlarm
+		total.Confusion += r.Confusion
+		total.jerSum += r.jerSum
+		total.jerCount += r.jerCount
+	}
+	total.DER = ratio(total.Missed+total.FalseAlarm+total.Confusion, total.Total)
+	total.JER = ratio(total.jerSum, float64(total.jerCount))
+	return total, perFile
+}
+
+// names 返回按下标排列的名称
+func names(index map[string]int) []string {
+	out := make([]string, len(index))
+	for name, i := range index {
+		out[i] = name
+	}
+	return out
+}
+
+// negate 返回矩阵的相反数，用于将最大化问题转为最小化问题
+func negate(m [][]float64) [][]float64 {
+	out := make([][]float64, len(m))
+	for i, row := range m {
+		out[i] = make([]float64, len(row))
+		for j, v := range row {
+			out[i][j] = -v
+		}
+	}
+	return out
+}
+
+// hungarian 用匈牙利算法求解最小代价的一对一指派，矩阵可以不是方阵
+//
+// 返回:
+//   - 每一行指派的列下标，未指派时为-1
+func hungarian(cost [][]float64) []int {
+	rows := len(cost)
+	if rows == 0 {
+		return nil
+	}
+	cols := len(cost[0])
+	n := max(rows, cols)
+	// 补零为方阵，下标从1开始
+	a := make([][]float64, n+1)
+	for i := range a {
+		a[i] = make([]float64, n+1)
+	}
+	for i := 0; i < rows; i++ {
+		copy(a[i+1][1:], cost[i])
+	}
+
+	u, v := make([]float64, n+1), make([]float64, n+1)
+	p, way := make([]int, n+1), make([]int, n+1)
+	for i := 1; i <= n; i++ {
+		p[0] = i
+		j0 := 0
+		minv := make([]float64, n+1)
+		used := make([]bool, n+1)
+		for j := range minv {
+			minv[j] = math.Inf(1)
+		}
+		for {
+			used[j0] = true
+			i0, delta, j1 := p[j0], math.Inf(1), 0
+			for j := 1; j <= n; j++ {
+				if used[j] {
+					continue
+				}
+				if cur := a[i0][j] - u[i0] - v[j]; cur < minv[j] {
+					minv[j], way[j] = cur, j0
+				}
+				if minv[j] < delta {
+					delta, j1 = minv[j], j
+				}
+			}
+			for j := 0; j <= n; j++ {
+				if used[j] {
+					u[p[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			if j0 = j1; p[j0] == 0 {
+				break
+			}
+		}
+		for j0 != 0 {
+			j1 := way[j0]
+			p[j0] = p[j1]
+			j0 = j1
+		}
+	}
+
+	assign := make([]int, rows)
+	for i := range assign {
+		assign[i] = -1
+	}
+	for j := 1; j <= n; j++ {
+		if i := p[j] - 1; i >= 0 && i < rows && j-1 < cols {
+			assign[i] = j - 1
+		}
+	}
+	return assign
+}
@@ -0,0 +1,60 @@
+package diarize
+
+import (
+	"math"
+	"testing"
+)
+
+// TestHungarian 测试最小代价指派
+func TestHungarian(t *testing.T) {
+	assign := hungarian([][]float64{{4, 1, 3}, {2, 0, 5}, {3, 2, 2}})
+	if assign[0] != 1 || assign[1] != 0 || assign[2] != 2 {
+		t.Fatalf("指派结果不正确: %v", assign)
+	}
+	// 行多于列时有行未指派
+	assign = hungarian([][]float64{{1}, {0}})
+	if assign[0] != -1 || assign[1] != 0 {
+		t.Fatalf("非方阵的指派结果不正确: %v", assign)
+	}
+}
+
+// TestComputeDER 测试漏检、虚警、混淆、边界容差和重叠排除
+func TestComputeDER(t *testing.T) {
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	ref := []Turn{{0, 10, "A"}, {10, 20, "B"}}
+	hyp := []Turn{{0, 9, "x"}, {9, 20, "y"}, {20, 22, "z"}}
+
+	r := ComputeDER(ref, hyp, DERConfig{})
+	if !near(r.Total, 20) || !near(r.Missed, 0) || !near(r.FalseAlarm, 2) || !near(r.Confusion, 1) || !near(r.DER, 0.15) {
+		t.Fatalf("DER结果不正确: %+v", r)
+	}
+	if r.Mapping["x"] != "A" || r.Mapping["y"] != "B" {
+		t.Fatalf("说话人映射不正确: %v", r.Mapping)
+	}
+	if want := (0.1 + 1.0/11) / 2; !near(r.JER, want) {
+		t.Fatalf("JER不正确: %v，应为%v", r.JER, want)
+	}
+
+	r = ComputeDER(ref, hyp, DERConfig{Collar: 0.5})
+	if !near(r.Total, 18) || !near(r.FalseAlarm, 1.5) || !near(r.Confusion, 0.5) || !near(r.DER, 2.0/18) {
+		t.Fatalf("带边界容差的DER结果不正确: %+v", r)
+	}
+
+	overlapRef := []Turn{{0, 10, "A"}, {5, 10, "B"}}
+	r = ComputeDER(overlapRef, []Turn{{0, 10, "x"}}, DERConfig{})
+	if !near(r.Total, 15) || !near(r.Missed, 5) || !near(r.DER, 5.0/15) {
+		t.Fatalf("重叠区域的DER结果不正确: %+v", r)
+	}
+	r = ComputeDER(overlapRef, []Turn{{0, 10, "x"}}, DERConfig{SkipOverlap: true})
+	if !near(r.Total, 5) || !near(r.DER, 0) {
+		t.Fatalf("排除重叠后的DER结果不正确: %+v", r)
+	}
+
+	total, perFile := ScoreFiles(map[string][]Turn{"a": ref, "b": {{0, 4, "C"}}}, map[string][]Turn{"a": hyp}, DERConfig{})
+	if len(perFile) != 2 || !near(total.Total, 24) || !near(total.Missed, 4) || !near(total.DER, 7.0/24) {
+		t.Fatalf("多个录音的汇总结果不正确: %+v", total)
+	}
+	if want := (0.1 + 1.0/11 + 1) / 3; !near(total.JER, want) {
+		t.Fatalf("汇总JER不正确: %v，应为%v", total.JER, want)
+	}
+}
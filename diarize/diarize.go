@@ -0,0 +1,371 @@
+// Package diarize 提供说话人日志（"谁在什么时候说话"）：
+// 语音活动检测、滑动窗口嵌入向量提取、聚类和重分割，输出带时间戳的说话人片段
+package diarize
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/seastart/3dspeaker-onnx-go/audio"
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// Method 聚类方法
+type Method int
+
+const (
+	// AgglomerativeClustering 平均链接的凝聚层次聚类，按余弦相似度阈值停止
+	AgglomerativeClustering Method = iota
+	// SpectralClustering 谱聚类，按特征值间隔估计说话人数
+	SpectralClustering
+)
+
+// String 返回聚类方法的名称
+func (m Method) String() string {
+	switch m {
+	case AgglomerativeClustering:
+		return "ahc"
+	case SpectralClustering:
+		return "spectral"
+	default:
+		return fmt.Sprintf("Method(%d)", int(m))
+	}
+}
+
+// ParseMethod 解析聚类方法名称（ahc、spectral）
+func ParseMethod(name string) (Method, error) {
+	switch name {
+	case "ahc", "agglomerative":
+		return AgglomerativeClustering, nil
+	case "spectral":
+		return SpectralClustering, nil
+	default:
+		return 0, fmt.Errorf("未知的聚类方法: %s", name)
+	}
+}
+
+// Config 说话人日志配置
+type Config struct {
+	VAD audio.VADConfig // 语音活动检测配置
+
+	WindowSeconds    float64 // 提取嵌入向量的窗口长度（秒）
+	HopSeconds       float64 // 窗口移动步长（秒）
+	MinWindowSeconds float64 // 短于该时长的语音段不提取嵌入向量
+
+	Method      Method  // 聚类方法
+	Threshold   float64 // 凝聚层次聚类停止合并的余弦相似度阈值
+	NumSpeakers int     // 已知说话人数，<=0表示自动估计
+	MaxSpeakers int     // 谱聚类估计说话人数的上限
+
+	ResegmentIterations int     // 重分割迭代次数，0表示不做重分割
+	MinTurnSeconds      float64 // 重分割后短于该时长的片段并入相邻片段
+}
+
+// DefaultConfig 返回默认的说话人日志配置
+func DefaultConfig() Config {
+	return Config{
+		VAD:                 audio.DefaultVADConfig(),
+		WindowSeconds:       1.5,
+		HopSeconds:          0.75,
+		MinWindowSeconds:    0.2,
+		Method:              AgglomerativeClustering,
+		Threshold:           0.5,
+		MaxSpeakers:         10,
+		ResegmentIterations: 5,
+		MinTurnSeconds:      0.5,
+	}
+}
+
+// Turn 一个说话人片段
+type Turn struct {
+	Start   float64 `json:"start"`   // 起始时间（秒）
+	End     float64 `json:"end"`     // 结束时间（秒）
+	Speaker string  `json:"speaker"` // 说话人标签
+}
+
+// Duration 返回片段时长（秒）
+func (t Turn) Duration() float64 {
+	return t.End - t.Start
+}
+
+// SpeakerLabel 返回第i个说话人的标签
+func SpeakerLabel(i int) string {
+	return fmt.Sprintf("spk%d", i)
+}
+
+// Diarizer 说话人日志处理器
+type Diarizer struct {
+	model *speaker.ModelHandle
+	cfg   Config
+}
+
+// New 使用已加载的模型创建说话人日志处理器
+func New(model *speaker.ModelHandle, cfg Config) (*Diarizer, error) {
+	if model == nil {
+		return nil, errors.New("模型为空")
+	}
+	if _, _, err := windowSamples(cfg.WindowSeconds, cfg.HopSeconds); err != nil {
+		return nil, err
+	}
+	return &Diarizer{model: model, cfg: cfg}, nil
+}
+
+// windowSamples 将窗口长度和步长换算为样本数，换算后不足一个样本时返回错误（步长为0时按窗口移动的循环不会前进）
+func windowSamples(windowSeconds, hopSeconds float64) (win, hop int, err error) {
+	win, hop = int(windowSeconds*audio.SampleRate), int(hopSeconds*audio.SampleRate)
+	if win < 1 || hop < 1 {
+		return 0, 0, fmt.Errorf("窗口长度和步长必须至少为一个样本: %v %v", windowSeconds, hopSeconds)
+	}
+	return win, hop, nil
+}
+
+// window 一个提取了嵌入向量的窗口，[Start,End)为该窗口负责标注的样本区间
+type window struct {
+	audio.Segment
+	emb []float32
+}
+
+// Diarize 对一段音频做说话人日志[必须是16khz单声道音频]
+//
+// 参数:
+//   - pcmData: PCM音频数据，int16格式
+//
+// 返回:
+//   - 按时间排序的说话人片段，没有检测到语音时为空
+//   - 可能的错误
+func (d *Diarizer) Diarize(pcmData []int16) ([]Turn, error) {
+	segs := audio.DetectSpeech(pcmData, audio.SampleRate, d.cfg.VAD)
+	windows, err := d.embedWindows(pcmData, segs)
+	if err != nil {
+		return nil, err
+	}
+	if len(windows) == 0 {
+		return nil, nil
+	}
+
+	embs := make([][]float32, len(windows))
+	for i, w := range windows {
+		embs[i] = w.emb
+	}
+	labels := d.cluster(embs)
+	labels = resegment(embs, labels, d.cfg.ResegmentIterations)
+
+	turns := buildTurns(windows, labels)
+	if d.cfg.ResegmentIterations > 0 {
+		turns = relabelTurns(absorbShortTurns(turns, d.cfg.MinTurnSeconds))
+	}
+	return turns, nil
+}
+
+// cluster 按配置的方法聚类
+func (d *Diarizer) cluster(embs [][]float32) []int {
+	if d.cfg.Method == SpectralClustering {
+		cfg := DefaultSpectralConfig()
+		cfg.NumSpeakers = d.cfg.NumSpeakers
+		if d.cfg.MaxSpeakers > 0 {
+			cfg.MaxSpeakers = d.cfg.MaxSpeakers
+		}
+		return Spectral(embs, cfg)
+	}
+	return Agglomerative(embs, d.cfg.Threshold, d.cfg.NumSpeakers)
+}
+
+// embedWindows 在每个语音段内按滑动窗口提取归一化的嵌入向量
+// 每个窗口负责标注到相邻窗口中心的中点为止的区间，这些区间恰好覆盖整个语音段
+func (d *Diarizer) embedWindows(pcmData []int16, segs []audio.Segment) ([]window, error) {
+	win := int(d.cfg.WindowSeconds * audio.SampleRate)
+	hop := int(d.cfg.HopSeconds * audio.SampleRate)
+	minLen := int(d.cfg.MinWindowSeconds * audio.SampleRate)
+
+	var windows []window
+	for _, seg := range segs {
+		if seg.Len() < minLen {
+			continue
+		}
+		var spans []audio.Segment
+		for _, start := range windowStarts(seg.Len(), win, hop) {
+			span := audio.Segment{Start: seg.Start + start, End: min(seg.Start+start+win, seg.End)}
+			emb := make([]float32, d.model.Dimension())
+			_, err := d.model.ExtractEmbeddingInto(pcmData[span.Start:span.End], emb, true)
+			if errors.Is(err, speaker.ErrZeroNorm) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("提取窗口嵌入向量失败: %w", err)
+			}
+			spans = append(spans, span)
+			windows = append(windows, window{Segment: span, emb: emb})
+		}
+		// 按相邻窗口中心的中点划分负责区间
+		first := len(windows) - len(spans)
+		for i := range spans {
+			w := &windows[first+i]
+			w.Start, w.End = seg.Start, seg.End
+			if i > 0 {
+				w.Start = midpoint(spans[i-1], spans[i])
+			}
+			if i < len(spans)-1 {
+				w.End = midpoint(spans[i], spans[i+1])
+			}
+		}
+	}
+	return windows, nil
+}
+
+// windowStarts 返回长度为n的区间内各窗口的起点，最后一个窗口与区间末尾对齐
+func windowStarts(n, win, hop int) []int {
+	if n <= win {
+		return []int{0}
+	}
+	var starts []int
+	for start := 0; start+win < n; start += hop {
+		starts = append(starts, start)
+	}
+	if last := n - win; starts[len(starts)-1] != last {
+		starts = append(starts, last)
+	}
+	return starts
+}
+
+// midpoint 返回两个窗口中心的中点
+func midpoint(a, b audio.Segment) int {
+	return (a.Start + a.End + b.Start + b.End) / 4
+}
+
+// resegment 用类中心重新分配窗口标签，迭代到不再变化或达到迭代次数
+func resegment(embs [][]float32, labels []int, iterations int) []int {
+	labels = append([]int(nil), labels...)
+	for it := 0; it < iterations; it++ {
+		centroids := centroids(embs, labels)
+		changed := false
+		for i, e := range embs {
+			best, bestScore := labels[i], float32(math.Inf(-1))
+			for c, centroid := range centroids {
+				if centroid == nil {
+					continue
+				}
+				if s := speaker.Dot(e, centroid); s > bestScore {
+					best, bestScore = c, s
+				}
+			}
+			if best != labels[i] {
+				labels[i], changed = best, true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	return relabel(labels)
+}
+
+// centroids 计算每个类的归一化平均向量，空类为nil
+func centroids(embs [][]float32, labels []int) [][]float32 {
+	k := 0
+	for _, l := range labels {
+		k = max(k, l+1)
+	}
+	out := make([][]float32, k)
+	for i, e := range embs {
+		c := out[labels[i]]
+		if c == nil {
+			c = make([]float32, len(e))
+			out[labels[i]] = c
+		}
+		for j, v := range e {
+			c[j] += v
+		}
+	}
+	for _, c := range out {
+		if c == nil {
+			continue
+		}
+		if n := float32(math.Sqrt(float64(speaker.Dot(c, c)))); n > 0 {
+			for j := range c {
+				c[j] /= n
+			}
+		}
+	}
+	return out
+}
+
+// buildTurns 将窗口标签转换为说话人片段，合并相邻的同一说话人区间
+func buildTurns(windows []window, labels []int) []Turn {
+	var turns []Turn
+	for i, w := range windows {
+		start := float64(w.Start) / audio.SampleRate
+		end := float64(w.End) / audio.SampleRate
+		label := SpeakerLabel(labels[i])
+		if n := len(turns); n > 0 && turns[n-1].Speaker == label && start-turns[n-1].End < 1e-9 {
+			turns[n-1].End = end
+			continue
+		}
+		turns = append(turns, Turn{Start: start, End: end, Speaker: label})
+	}
+	return turns
+}
+
+// absorbShortTurns 将短于minSeconds且两侧紧邻其他片段的片段并入较长的相邻片段
+func absorbShortTurns(turns []Turn, minSeconds float64) []Turn {
+	if minSeconds <= 0 || len(turns) < 2 {
+		return turns
+	}
+	out := append([]Turn(nil), turns...)
+	for {
+		shortest := -1
+		for i, t := range out {
+			if t.Duration() >= minSeconds {
+				continue
+			}
+			prev := i > 0 && out[i-1].End >= t.Start-1e-9
+			next := i < len(out)-1 && out[i+1].Start <= t.End+1e-9
+			if (prev || next) && (shortest < 0 || t.Duration() < out[shortest].Duration()) {
+				shortest = i
+			}
+		}
+		if shortest < 0 {
+			break
+		}
+		i := shortest
+		t := out[i]
+		prev := i > 0 && out[i-1].End >= t.Start-1e-9
+		next := i < len(out)-1 && out[i+1].Start <= t.End+1e-9
+		if prev && (!next || out[i-1].Duration() >= out[i+1].Duration()) {
+			out[i-1].End = t.End
+		} else {
+			out[i+1].Start = t.Start
+		}
+		out = append(out[:i], out[i+1:]...)
+		// 并入后可能与另一侧片段属于同一说话人，合并之
+		out = mergeAdjacent(out)
+	}
+	return out
+}
+
+// mergeAdjacent 合并首尾相接的同一说话人片段
+func mergeAdjacent(turns []Turn) []Turn {
+	var out []Turn
+	for _, t := range turns {
+		if n := len(out); n > 0 && out[n-1].Speaker == t.Speaker && t.Start-out[n-1].End < 1e-9 {
+			out[n-1].End = t.End
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// relabelTurns 按首次出现的顺序重新编号说话人标签，去掉并入后消失的说话人留下的空缺
+func relabelTurns(turns []Turn) []Turn {
+	mapping := make(map[string]string)
+	for i, t := range turns {
+		label, ok := mapping[t.Speaker]
+		if !ok {
+			label = SpeakerLabel(len(mapping))
+			mapping[t.Speaker] = label
+		}
+		turns[i].Speaker = label
+	}
+	return turns
+}
@@ -0,0 +1,116 @@
+package diarize
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/seastart/3dspeaker-onnx-go/audio"
+)
+
+// clusteredEmbeddings 生成围绕k个随机中心的归一化向量，返回向量和真实标签
+func clusteredEmbeddings(rng *rand.Rand, k, perCluster, dim int, spread float64) ([][]float32, []int) {
+	var embs [][]float32
+	var labels []int
+	for c := 0; c < k; c++ {
+		center := make([]float64, dim)
+		for j := range center {
+			center[j] = rng.NormFloat64()
+		}
+		for i := 0; i < perCluster; i++ {
+			v := make([]float32, dim)
+			var n float64
+			for j := range v {
+				x := center[j] + spread*rng.NormFloat64()
+				v[j] = float32(x)
+				n += x * x
+			}
+			for j := range v {
+				v[j] /= float32(math.Sqrt(n))
+			}
+			embs = append(embs, v)
+			labels = append(labels, c)
+		}
+	}
+	// 打乱顺序
+	rng.Shuffle(len(embs), func(i, j int) {
+		embs[i], embs[j] = embs[j], embs[i]
+		labels[i], labels[j] = labels[j], labels[i]
+	})
+	return embs, labels
+}
+
+// samePartition 判断两组标签是否为同一划分
+func samePartition(a, b []int) bool {
+	ra, rb := relabel(a), relabel(b)
+	for i := range ra {
+		if ra[i] != rb[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// TestClustering 测试层次聚类和谱聚类能恢复真实的类别
+func TestClustering(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	embs, truth := clusteredEmbeddings(rng, 3, 20, 32, 0.3)
+
+	if labels := Agglomerative(embs, 0.5, 0); !samePartition(labels, truth) {
+		t.Fatalf("层次聚类结果不正确: %v", labels)
+	}
+	if labels := Spectral(embs, DefaultSpectralConfig()); !samePartition(labels, truth) {
+		t.Fatalf("谱聚类结果不正确: %v", labels)
+	}
+	// 指定说话人数
+	labels := Agglomerative(embs, 0.5, 2)
+	if relabel(labels)[len(labels)-1] > 1 {
+		t.Fatalf("指定2个说话人时类别数不正确: %v", labels)
+	}
+	if labels := Agglomerative(embs[:1], 0.5, 0); len(labels) != 1 || labels[0] != 0 {
+		t.Fatalf("单个向量的聚类结果不正确: %v", labels)
+	}
+	if labels := resegment(embs, truth, 5); !samePartition(labels, truth) {
+		t.Fatalf("重分割不应改变正确的划分: %v", labels)
+	}
+}
+
+// TestTurns 测试窗口划分和说话人片段的生成
+func TestTurns(t *testing.T) {
+	if starts := windowStarts(100, 30, 20); len(starts) != 5 || starts[4] != 70 {
+		t.Fatalf("窗口起点不正确: %v", starts)
+	}
+	if starts := windowStarts(20, 30, 20); len(starts) != 1 || starts[0] != 0 {
+		t.Fatalf("短区间的窗口起点不正确: %v", starts)
+	}
+	// 换算后不足一个样本的步长会使窗口循环无法前进
+	if _, _, err := windowSamples(1.5, 1e-5); err == nil {
+		t.Fatal("不足一个样本的步长应返回错误")
+	}
+	if win, hop, err := windowSamples(1.5, 0.75); err != nil || win != 24000 || hop != 12000 {
+		t.Fatalf("窗口样本数不正确: %d %d %v", win, hop, err)
+	}
+
+	sr := audio.SampleRate
+	windows := []window{
+		{Segment: audio.Segment{Start: 0, End: sr}},
+		{Segment: audio.Segment{Start: sr, End: 2 * sr}},
+		{Segment: audio.Segment{Start: 2 * sr, End: 2*sr + sr/5}},
+		{Segment: audio.Segment{Start: 2*sr + sr/5, End: 4 * sr}},
+		{Segment: audio.Segment{Start: 5 * sr, End: 6 * sr}},
+	}
+	turns := buildTurns(windows, []int{0, 0, 1, 0, 1})
+	if len(turns) != 4 || turns[0].End != 2 || turns[3].Start != 5 {
+		t.Fatalf("说话人片段不正确: %+v", turns)
+	}
+	turns = relabelTurns(absorbShortTurns(turns, 0.5))
+	want := []Turn{{0, 4, "spk0"}, {5, 6, "spk1"}}
+	if len(turns) != len(want) {
+		t.Fatalf("并入短片段后结果不正确: %+v", turns)
+	}
+	for i := range want {
+		if math.Abs(turns[i].Start-want[i].Start) > 1e-9 || math.Abs(turns[i].End-want[i].End) > 1e-9 || turns[i].Speaker != want[i].Speaker {
+			t.Fatalf("并入短片段后结果不正确: %+v", turns)
+		}
+	}
+}
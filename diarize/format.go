@@ -0,0 +1,353 @@
+package diarize
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Format 说话人片段的文件格式
+type Format int
+
+const (
+	// RTTM NIST RTTM格式，每行一个SPEAKER记录
+	RTTM Format = iota
+	// JSON 片段对象数组
+	JSON
+	// WebVTT 字幕格式，说话人写在<v>标签中
+	WebVTT
+	// SRT 字幕格式，说话人写在方括号中
+	SRT
+	// Audacity Audacity标签轨道，每行为"起始\t结束\t标签"
+	Audacity
+)
+
+// String 返回格式名称
+func (f Format) String() string {
+	switch f {
+	case RTTM:
+		return "rttm"
+	case JSON:
+		return "json"
+	case WebVTT:
+		return "vtt"
+	case SRT:
+		return "srt"
+	case Audacity:
+		return "audacity"
+	default:
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+}
+
+// ParseFormat 解析格式名称（rttm、json、vtt、srt、audacity），也接受文件扩展名形式
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimPrefix(name, ".")) {
+	case "rttm":
+		return RTTM, nil
+	case "json":
+		return JSON, nil
+	case "vtt", "webvtt":
+		return WebVTT, nil
+	case "srt":
+		return SRT, nil
+	case "audacity", "txt", "labels":
+		return Audacity, nil
+	default:
+		return 0, fmt.Errorf("未知的片段格式: %s", name)
+	}
+}
+
+// Write 按指定格式写出说话人片段，fileID只用于RTTM
+func Write(w io.Writer, format Format, fileID string, turns []Turn) error {
+	switch format {
+	case RTTM:
+		return WriteRTTM(w, fileID, turns)
+	case JSON:
+		return WriteJSON(w, turns)
+	case WebVTT:
+		return WriteWebVTT(w, turns)
+	case SRT:
+		return WriteSRT(w, turns)
+	case Audacity:
+		return WriteAudacity(w, turns)
+	default:
+		return fmt.Errorf("不支持的片段格式: %s", format)
+	}
+}
+
+// Read 按指定格式读取说话人片段；RTTM文件包含多个录音时返回错误，此时请使用ReadRTTM
+func Read(r io.Reader, format Format) ([]Turn, error) {
+	switch format {
+	case RTTM:
+		files, err := ReadRTTM(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) > 1 {
+			return nil, fmt.Errorf("RTTM包含%d个录音，请使用ReadRTTM", len(files))
+		}
+		for _, turns := range files {
+			return turns, nil
+		}
+		return nil, nil
+	case JSON:
+		return ReadJSON(r)
+	case WebVTT:
+		return ReadWebVTT(r)
+	case SRT:
+		return ReadSRT(r)
+	case Audacity:
+		return ReadAudacity(r)
+	default:
+		return nil, fmt.Errorf("不支持的片段格式: %s", format)
+	}
+}
+
+// WriteRTTM 写出NIST RTTM格式
+// 每个片段一行: SPEAKER <fileID> 1 <起始> <时长> <NA> <NA> <说话人> <NA> <NA>
+func WriteRTTM(w io.Writer, fileID string, turns []Turn) error {
+	if fileID == "" || strings.ContainsAny(fileID, " \t\n") {
+		return fmt.Errorf("RTTM录音标识不能为空或包含空白: %q", fileID)
+	}
+	bw := bufio.NewWriter(w)
+	for _, t := range turns {
+		if strings.ContainsAny(t.Speaker, " \t\n") {
+			return fmt.Errorf("RTTM说话人标签不能包含空白: %q", t.Speaker)
+		}
+		fmt.Fprintf(bw, "SPEAKER %s 1 %.3f %.3f <NA> <NA> %s <NA> <NA>\n", fileID, t.Start, t.Duration(), t.Speaker)
+	}
+	return bw.Flush()
+}
+
+// ReadRTTM 读取NIST RTTM格式，只处理SPEAKER记录，忽略其他类型的记录和以;;开头的注释
+//
+// 返回:
+//   - 录音标识到按起始时间排序的说话人片段的映射
+//   - 可能的错误
+func ReadRTTM(r io.Reader) (map[string][]Turn, error) {
+	files := make(map[string][]Turn)
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, ";;") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if fields[0] != "SPEAKER" {
+			continue
+		}
+		if len(fields) < 8 {
+			return nil, fmt.Errorf("RTTM第%d行字段不足: %s", line, text)
+		}
+		start, err1 := strconv.ParseFloat(fields[3], 64)
+		dur, err2 := strconv.ParseFloat(fields[4], 64)
+		if err := errors.Join(err1, err2); err != nil {
+			return nil, fmt.Errorf("RTTM第%d行时间格式错误: %w", line, err)
+		}
+		if dur < 0 {
+			return nil, fmt.Errorf("RTTM第%d行时长为负数: %s", line, text)
+		}
+		files[fields[1]] = append(files[fields[1]], Turn{Start: start, End: start + dur, Speaker: fields[7]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取RTTM失败: %w", err)
+	}
+	for _, turns := range files {
+		sortTurns(turns)
+	}
+	return files, nil
+}
+
+// WriteJSON 写出片段对象数组，例如[{"start":0,"end":1.5,"speaker":"spk0"}]
+func WriteJSON(w io.Writer, turns []Turn) error {
+	if turns == nil {
+		turns = []Turn{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(turns)
+}
+
+// ReadJSON 读取WriteJSON写出的片段对象数组
+func ReadJSON(r io.Reader) ([]Turn, error) {
+	var turns []Turn
+	if err := json.NewDecoder(r).Decode(&turns); err != nil {
+		return nil, fmt.Errorf("解析JSON片段失败: %w", err)
+	}
+	for i, t := range turns {
+		if t.End < t.Start {
+			return nil, fmt.Errorf("第%d个片段的结束时间早于起始时间", i+1)
+		}
+	}
+	sortTurns(turns)
+	return turns, nil
+}
+
+// formatTimestamp 将秒数格式化为HH:MM:SS<sep>mmm
+func formatTimestamp(seconds float64, sep string) string {
+	ms := int64(math.Round(seconds * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
+
+// cueTiming 匹配字幕的时间行，小时部分可省略（WebVTT）
+var cueTiming = regexp.MustCompile(`^((?:\d+:)?\d{2}:\d{2}[.,]\d{3})\s+-->\s+((?:\d+:)?\d{2}:\d{2}[.,]\d{3})`)
+
+// parseTimestamp 解析[HH:]MM:SS.mmm或HH:MM:SS,mmm格式的时间
+func parseTimestamp(s string) (float64, error) {
+	s = strings.Replace(s, ",", ".", 1)
+	parts := strings.Split(s, ":")
+	var seconds float64
+	for _, p := range parts {
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			return 0, fmt.Errorf("时间格式错误: %s", s)
+		}
+		seconds = seconds*60 + v
+	}
+	return seconds, nil
+}
+
+// WriteWebVTT 写出WebVTT字幕，每个片段一个cue，说话人写在<v>标签中
+func WriteWebVTT(w io.Writer, turns []Turn) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("WEBVTT\n")
+	for i, t := range turns {
+		fmt.Fprintf(bw, "\n%d\n%s --> %s\n<v %s>%s</v>\n", i+1,
+			formatTimestamp(t.Start, "."), formatTimestamp(t.End, "."), t.Speaker, t.Speaker)
+	}
+	return bw.Flush()
+}
+
+// vttVoice 匹配WebVTT的<v 说话人>标签
+var vttVoice = regexp.MustCompile(`<v(?:\.[^ >]*)?\s+([^>]+)>`)
+
+// ReadWebVTT 读取WebVTT字幕，说话人取cue文本中<v>标签的名称，没有<v>标签时取整行文本
+func ReadWebVTT(r io.Reader) ([]Turn, error) {
+	return readCues(r, func(text string) string {
+		if m := vttVoice.FindStringSubmatch(text); m != nil {
+			return strings.TrimSpace(m[1])
+		}
+		return text
+	})
+}
+
+// WriteSRT 写出SRT字幕，说话人写在方括号中
+func WriteSRT(w io.Writer, turns []Turn) error {
+	bw := bufio.NewWriter(w)
+	for i, t := range turns {
+		if i > 0 {
+			bw.WriteString("\n")
+		}
+		fmt.Fprintf(bw, "%d\n%s --> %s\n[%s]\n", i+1,
+			formatTimestamp(t.Start, ","), formatTimestamp(t.End, ","), t.Speaker)
+	}
+	return bw.Flush()
+}
+
+// srtSpeaker 匹配行首的[说话人]或"说话人:"
+var srtSpeaker = regexp.MustCompile(`^\[([^\]]+)\]|^([^:：\s]+)[:：]`)
+
+// ReadSRT 读取SRT字幕，说话人取cue文本开头的[说话人]或"说话人:"，否则取整行文本
+func ReadSRT(r io.Reader) ([]Turn, error) {
+	return readCues(r, func(text string) string {
+		if m := srtSpeaker.FindStringSubmatch(text); m != nil {
+			return m[1] + m[2]
+		}
+		return text
+	})
+}
+
+// readCues 读取WebVTT/SRT的cue，speakerOf从cue的第一行文本中提取说话人
+func readCues(r io.Reader, speakerOf func(text string) string) ([]Turn, error) {
+	var turns []Turn
+	scanner := bufio.NewScanner(r)
+	line := 0
+	// 已读到时间行、正在等待cue文本
+	var pending *Turn
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if m := cueTiming.FindStringSubmatch(text); m != nil {
+			start, err1 := parseTimestamp(m[1])
+			end, err2 := parseTimestamp(m[2])
+			if err := errors.Join(err1, err2); err != nil {
+				return nil, fmt.Errorf("第%d行: %w", line, err)
+			}
+			if pending != nil {
+				return nil, fmt.Errorf("第%d行: 上一个cue没有文本", line)
+			}
+			pending = &Turn{Start: start, End: end}
+			continue
+		}
+		if pending != nil && text != "" {
+			pending.Speaker = speakerOf(text)
+			turns = append(turns, *pending)
+			pending = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取字幕失败: %w", err)
+	}
+	if pending != nil {
+		return nil, errors.New("最后一个cue没有文本")
+	}
+	sortTurns(turns)
+	return turns, nil
+}
+
+// WriteAudacity 写出Audacity标签轨道，每行为"起始\t结束\t说话人"
+func WriteAudacity(w io.Writer, turns []Turn) error {
+	bw := bufio.NewWriter(w)
+	for _, t := range turns {
+		fmt.Fprintf(bw, "%.6f\t%.6f\t%s\n", t.Start, t.End, t.Speaker)
+	}
+	return bw.Flush()
+}
+
+// ReadAudacity 读取Audacity标签轨道，忽略频率范围行（以\开头）
+func ReadAudacity(r io.Reader) ([]Turn, error) {
+	var turns []Turn
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "\\") {
+			continue
+		}
+		fields := strings.SplitN(text, "\t", 3)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("标签第%d行格式错误: %s", line, text)
+		}
+		start, err1 := strconv.ParseFloat(strings.TrimSpace(fields[0]), 64)
+		end, err2 := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+		if err := errors.Join(err1, err2); err != nil {
+			return nil, fmt.Errorf("标签第%d行时间格式错误: %w", line, err)
+		}
+		turn := Turn{Start: start, End: end}
+		if len(fields) == 3 {
+			turn.Speaker = strings.TrimSpace(fields[2])
+		}
+		turns = append(turns, turn)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取标签失败: %w", err)
+	}
+	sortTurns(turns)
+	return turns, nil
+}
+
+// sortTurns 按起始时间排序片段
+func sortTurns(turns []Turn) {
+	sort.SliceStable(turns, func(i, j int) bool { return turns[i].Start < turns[j].Start })
+}
@@ -0,0 +1,73 @@
+package diarize
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestFormats 测试各格式写出后读回的结果一致
+func TestFormats(t *testing.T) {
+	turns := []Turn{
+		{Start: 0.5, End: 2.25, Speaker: "spk0"},
+		{Start: 2.25, End: 3661.125, Speaker: "spk1"},
+	}
+	for _, format := range []Format{RTTM, JSON, WebVTT, SRT, Audacity} {
+		var buf bytes.Buffer
+		if err := Write(&buf, format, "meeting1", turns); err != nil {
+			t.Fatalf("%s: 写出失败: %v", format, err)
+		}
+		parsed, err := ParseFormat(format.String())
+		if err != nil || parsed != format {
+			t.Fatalf("%s: 解析格式名称失败: %v", format, err)
+		}
+		got, err := Read(&buf, format)
+		if err != nil {
+			t.Fatalf("%s: 读取失败: %v\n%s", format, err, buf.String())
+		}
+		if len(got) != len(turns) {
+			t.Fatalf("%s: 片段数不一致: %+v", format, got)
+		}
+		for i := range turns {
+			if math.Abs(got[i].Start-turns[i].Start) > 1e-3 || math.Abs(got[i].End-turns[i].End) > 1e-3 || got[i].Speaker != turns[i].Speaker {
+				t.Fatalf("%s: 第%d个片段不一致: %+v vs %+v", format, i, got[i], turns[i])
+			}
+		}
+	}
+}
+
+// TestReadRTTM 测试读取包含多个录音和非SPEAKER记录的RTTM
+func TestReadRTTM(t *testing.T) {
+	input := `;; comment
+SPEAKER a 1 5.0 1.0 <NA> <NA> B <NA> <NA>
+SPEAKER a 1 0.0 2.5 <NA> <NA> A <NA> <NA>
+SPKR-INFO a 1 <NA> <NA> <NA> unknown A <NA> <NA>
+SPEAKER b 1 1.0 1.0 <NA> <NA> C <NA> <NA>
+`
+	files, err := ReadRTTM(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("读取RTTM失败: %v", err)
+	}
+	if len(files) != 2 || len(files["a"]) != 2 || files["a"][0].Speaker != "A" || files["a"][1].End != 6 {
+		t.Fatalf("RTTM解析结果不正确: %+v", files)
+	}
+	if _, err := Read(strings.NewReader(input), RTTM); err == nil {
+		t.Fatal("多个录音时Read应返回错误")
+	}
+	if err := WriteRTTM(&bytes.Buffer{}, "has space", nil); err == nil {
+		t.Fatal("录音标识包含空白时应返回错误")
+	}
+
+	// 带有其他写法的字幕
+	vtt := "WEBVTT\n\n00:01.000 --> 00:02.000\n<v.loud Alice Smith>hello\n"
+	turns, err := ReadWebVTT(strings.NewReader(vtt))
+	if err != nil || len(turns) != 1 || turns[0].Speaker != "Alice Smith" || turns[0].Start != 1 {
+		t.Fatalf("WebVTT解析结果不正确: %+v %v", turns, err)
+	}
+	srt := "1\n00:00:01,000 --> 00:00:02,000\nBob: hi\n"
+	turns, err = ReadSRT(strings.NewReader(srt))
+	if err != nil || len(turns) != 1 || turns[0].Speaker != "Bob" {
+		t.Fatalf("SRT解析结果不正确: %+v %v", turns, err)
+	}
+}
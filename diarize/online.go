@@ -0,0 +1,315 @@
+package diarize
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/seastart/3dspeaker-onnx-go/audio"
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// FeatureExtractor 能在FBANK特征上提取嵌入向量的提取器，*speaker.Speaker和*speaker.ModelHandle都实现了该接口
+// 在线说话人日志使用它增量计算特征，重叠的窗口不再重复计算
+type FeatureExtractor interface {
+	Extractor
+	NewFbankStream() (*speaker.FbankStream, error)
+	ExtractEmbeddingFromFeatures(f *speaker.Features) (*speaker.Embedding, error)
+}
+
+// OnlineConfig 在线说话人日志配置
+type OnlineConfig struct {
+	WindowSeconds float64 // 提取嵌入向量的窗口长度（秒），决定输出的延迟
+	HopSeconds    float64 // 窗口移动步长（秒），每个窗口标注中心附近长度为HopSeconds的区间
+	SilenceDB     float64 // 窗口平均功率低于该值（dBFS）时视为静音，不做标注
+
+	// NoveltyThreshold 与所有已有说话人中心的余弦相似度都低于该值时创建新说话人
+	NoveltyThreshold float64
+	// MaxSpeakers 说话人数上限，达到后新窗口总是分配给最相似的说话人；<=0表示不限制
+	MaxSpeakers int
+	// LookbackSeconds 可修订的时长（秒）：最近这段时间内的标签会随说话人中心的更新重新分配，更早的标签不再改变
+	LookbackSeconds float64
+}
+
+// DefaultOnlineConfig 返回默认的在线说话人日志配置
+func DefaultOnlineConfig() OnlineConfig {
+	return OnlineConfig{
+		WindowSeconds:    1.5,
+		HopSeconds:       0.75,
+		SilenceDB:        -55,
+		NoveltyThreshold: 0.5,
+		MaxSpeakers:      10,
+		LookbackSeconds:  5,
+	}
+}
+
+// Label 在线说话人日志输出的一个标签
+type Label struct {
+	Turn
+	// Final 为true时该标签不会再被修订
+	Final bool `json:"final"`
+	// Revised 为true时表示之前输出过该区间的标签，本次为修订后的结果
+	Revised bool `json:"revised"`
+}
+
+// onlineWindow 一个尚在可修订范围内的窗口
+type onlineWindow struct {
+	start, end int // 该窗口标注的样本区间
+	emb        []float32
+	speaker    int
+}
+
+// OnlineDiarizer 在线说话人日志处理器，按块输入PCM，输出带有限延迟的临时说话人标签
+// 非并发安全
+type OnlineDiarizer struct {
+	extractor Extractor
+	cfg       OnlineConfig
+
+	buffer []int16 // 尚未完全处理的样本，buffer[0]对应的全局样本下标为offset
+	offset int
+	total  int // 已输入的样本总数
+
+	// 提取器支持特征输入且步长为帧移的整数倍时，特征随输入增量计算
+	fbank      *speaker.FbankStream
+	features   []float32 // 尚未完全处理的特征帧，第一帧对应的全局帧下标为frameStart
+	frameStart int
+
+	sums    [][]float32 // 每个说话人的嵌入向量之和
+	counts  []int
+	pending []onlineWindow // 可修订范围内的窗口
+	final   []Turn         // 已确定的片段
+}
+
+// NewOnlineDiarizer 创建在线说话人日志处理器
+// extractor实现FeatureExtractor且步长为帧移的整数倍时增量计算特征，否则每个窗口重新计算特征
+func NewOnlineDiarizer(extractor Extractor, cfg OnlineConfig) (*OnlineDiarizer, error) {
+	if extractor == nil {
+		return nil, errors.New("嵌入向量提取器为空")
+	}
+	win, hop, err := windowSamples(cfg.WindowSeconds, cfg.HopSeconds)
+	if err != nil {
+		return nil, err
+	}
+	if hop > win {
+		return nil, fmt.Errorf("步长%v不能大于窗口长度%v", cfg.HopSeconds, cfg.WindowSeconds)
+	}
+	d := &OnlineDiarizer{extractor: extractor, cfg: cfg}
+	if fx, ok := extractor.(FeatureExtractor); ok {
+		fbank, err := fx.NewFbankStream()
+		if err != nil {
+			return nil, fmt.Errorf("创建流式特征提取器失败: %w", err)
+		}
+		info := fbank.Info()
+		if hop%info.FrameShift == 0 && info.NumFrames(win) > 0 {
+			d.fbank = fbank
+		} else {
+			fbank.Close()
+		}
+	}
+	return d, nil
+}
+
+// Close 释放流式特征提取器，之后不能再输入音频
+func (d *OnlineDiarizer) Close() {
+	if d.fbank != nil {
+		d.fbank.Close()
+	}
+}
+
+// Process 输入一块PCM数据[必须是16khz单声道音频]，返回本次新增、修订或确定的标签
+// 一个区间的标签最晚在其后WindowSeconds的音频输入后给出，并在LookbackSeconds之后确定
+//
+// 参数:
+//   - chunk: PCM数据块，长度任意
+//
+// 返回:
+//   - 按时间排序的标签
+//   - 可能的错误
+func (d *OnlineDiarizer) Process(chunk []int16) ([]Label, error) {
+	d.buffer = append(d.buffer, chunk...)
+	d.total += len(chunk)
+	if d.fbank != nil {
+		f, err := d.fbank.Accept(chunk)
+		if err != nil {
+			return nil, fmt.Errorf("流式计算特征失败: %w", err)
+		}
+		d.features = append(d.features, f.Data...)
+	}
+
+	win := int(d.cfg.WindowSeconds * audio.SampleRate)
+	hop := int(d.cfg.HopSeconds * audio.SampleRate)
+	var labels []Label
+	for len(d.buffer) >= win {
+		w, ok, err := d.embedWindow(d.buffer[:win], d.offset, win, hop)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			w.speaker = d.assign(w.emb)
+			d.pending = append(d.pending, w)
+			labels = append(labels, d.label(w, false, false))
+		}
+		d.buffer = d.buffer[hop:]
+		d.offset += hop
+		if d.fbank != nil {
+			// 丢弃下一个窗口之前的特征帧
+			info := d.fbank.Info()
+			drop := d.offset/info.FrameShift - d.frameStart
+			d.features = d.features[drop*info.NumBins:]
+			d.frameStart += drop
+		}
+	}
+	labels = append(labels, d.revise()...)
+	labels = append(labels, d.finalize(d.total-int(d.cfg.LookbackSeconds*audio.SampleRate))...)
+	return labels, nil
+}
+
+// Flush 确定所有尚可修订的标签并返回，之后仍可继续输入
+func (d *OnlineDiarizer) Flush() []Label {
+	return d.finalize(math.MaxInt)
+}
+
+// Turns 返回目前为止已确定的说话人片段，相邻的同一说话人片段已合并
+func (d *OnlineDiarizer) Turns() []Turn {
+	return append([]Turn(nil), d.final...)
+}
+
+// NumSpeakers 返回目前为止出现的说话人数
+func (d *OnlineDiarizer) NumSpeakers() int {
+	return len(d.sums)
+}
+
+// embedWindow 提取一个窗口的归一化嵌入向量，静音窗口返回ok=false
+// 窗口标注中心附近长度为hop的区间
+func (d *OnlineDiarizer) embedWindow(pcm []int16, offset, win, hop int) (onlineWindow, bool, error) {
+	if audio.PowerDB(pcm) < d.cfg.SilenceDB {
+		return onlineWindow{}, false, nil
+	}
+	emb, err := d.extract(pcm, offset, win)
+	if errors.Is(err, speaker.ErrZeroNorm) {
+		return onlineWindow{}, false, nil
+	}
+	if err != nil {
+		return onlineWindow{}, false, fmt.Errorf("提取窗口嵌入向量失败: %w", err)
+	}
+	data := append([]float32(nil), emb.GetData()...)
+	if n := float32(math.Sqrt(float64(speaker.Dot(data, data)))); n > 0 {
+		for i := range data {
+			data[i] /= n
+		}
+	}
+	start := offset + (win-hop)/2
+	return onlineWindow{start: start, end: start + hop, emb: data}, true, nil
+}
+
+// extract 提取从offset开始的窗口的嵌入向量，增量计算特征时直接使用缓存的特征帧
+func (d *OnlineDiarizer) extract(pcm []int16, offset, win int) (*speaker.Embedding, error) {
+	if d.fbank == nil {
+		return d.extractor.ExtractEmbedding(pcm)
+	}
+	info := d.fbank.Info()
+	first := offset/info.FrameShift - d.frameStart
+	n := info.NumFrames(win)
+	feats := &speaker.Features{Data: d.features[first*info.NumBins : (first+n)*info.NumBins], NumBins: info.NumBins}
+	return d.extractor.(FeatureExtractor).ExtractEmbeddingFromFeatures(feats)
+}
+
+// similarity 计算归一化向量与说话人中心的余弦相似度
+func (d *OnlineDiarizer) similarity(emb []float32, spk int) float32 {
+	sum := d.sums[spk]
+	n := float32(math.Sqrt(float64(speaker.Dot(sum, sum))))
+	if n == 0 {
+		return -1
+	}
+	return speaker.Dot(emb, sum) / n
+}
+
+// nearest 返回与嵌入向量最相似的说话人及其相似度，没有说话人时返回-1
+func (d *OnlineDiarizer) nearest(emb []float32) (int, float32) {
+	best, bestSim := -1, float32(math.Inf(-1))
+	for spk := range d.sums {
+		if d.counts[spk] == 0 {
+			continue
+		}
+		if s := d.similarity(emb, spk); s > bestSim {
+			best, bestSim = spk, s
+		}
+	}
+	return best, bestSim
+}
+
+// assign 将新窗口分配给最相似的说话人，相似度低于阈值时创建新说话人，并更新说话人中心
+func (d *OnlineDiarizer) assign(emb []float32) int {
+	spk, sim := d.nearest(emb)
+	full := d.cfg.MaxSpeakers > 0 && len(d.sums) >= d.cfg.MaxSpeakers
+	if spk < 0 || (float64(sim) < d.cfg.NoveltyThreshold && !full) {
+		d.sums = append(d.sums, make([]float32, len(emb)))
+		d.counts = append(d.counts, 0)
+		spk = len(d.sums) - 1
+	}
+	d.move(emb, -1, spk)
+	return spk
+}
+
+// move 将嵌入向量从一个说话人中心移到另一个，from为-1表示新加入
+func (d *OnlineDiarizer) move(emb []float32, from, to int) {
+	if from >= 0 {
+		for i, v := range emb {
+			d.sums[from][i] -= v
+		}
+		d.counts[from]--
+	}
+	for i, v := range emb {
+		d.sums[to][i] += v
+	}
+	d.counts[to]++
+}
+
+// revise 用更新后的说话人中心重新分配可修订范围内的窗口，返回标签发生变化的窗口
+func (d *OnlineDiarizer) revise() []Label {
+	var labels []Label
+	for i := range d.pending {
+		w := &d.pending[i]
+		best, _ := d.nearest(w.emb)
+		if best < 0 || best == w.speaker {
+			continue
+		}
+		d.move(w.emb, w.speaker, best)
+		w.speaker = best
+		labels = append(labels, d.label(*w, false, true))
+	}
+	return labels
+}
+
+// finalize 确定标注区间在limit之前结束的窗口，返回其最终标签
+func (d *OnlineDiarizer) finalize(limit int) []Label {
+	n := 0
+	for n < len(d.pending) && d.pending[n].end <= limit {
+		n++
+	}
+	labels := make([]Label, 0, n)
+	for _, w := range d.pending[:n] {
+		l := d.label(w, true, false)
+		labels = append(labels, l)
+		if k := len(d.final); k > 0 && d.final[k-1].Speaker == l.Speaker && l.Start-d.final[k-1].End < 1e-9 {
+			d.final[k-1].End = l.End
+		} else {
+			d.final = append(d.final, l.Turn)
+		}
+	}
+	d.pending = append(d.pending[:0], d.pending[n:]...)
+	return labels
+}
+
+// label 生成窗口的标签
+func (d *OnlineDiarizer) label(w onlineWindow, final, revised bool) Label {
+	return Label{
+		Turn: Turn{
+			Start:   float64(w.start) / audio.SampleRate,
+			End:     float64(w.end) / audio.SampleRate,
+			Speaker: SpeakerLabel(w.speaker),
+		},
+		Final:   final,
+		Revised: revised,
+	}
+}
@@ -0,0 +1,74 @@
+package diarize
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/seastart/3dspeaker-onnx-go/audio"
+)
+
+// TestOnlineDiarizer 测试按块输入时的说话人标注和延迟
+func TestOnlineDiarizer(t *testing.T) {
+	// 说话人A 5秒，静音1秒，B 5秒，A 5秒
+	var pcm []int16
+	for _, seg := range []struct {
+		seconds int
+		value   int16
+	}{{5, 100}, {1, 0}, {5, -100}, {5, 100}} {
+		for i := 0; i < seg.seconds*audio.SampleRate; i++ {
+			pcm = append(pcm, seg.value)
+		}
+	}
+
+	cfg := DefaultOnlineConfig()
+	tiny := cfg
+	tiny.HopSeconds = 1e-5
+	if _, err := NewOnlineDiarizer(&fakeExtractor{}, tiny); err == nil {
+		t.Fatal("不足一个样本的步长应返回错误")
+	}
+	d, err := NewOnlineDiarizer(&fakeExtractor{rand.New(rand.NewSource(1))}, cfg)
+	if err != nil {
+		t.Fatalf("创建在线处理器失败: %v", err)
+	}
+	chunk := audio.SampleRate * 3 / 10
+	var labels []Label
+	for start := 0; start < len(pcm); start += chunk {
+		end := min(start+chunk, len(pcm))
+		out, err := d.Process(pcm[start:end])
+		if err != nil {
+			t.Fatalf("处理失败: %v", err)
+		}
+		for _, l := range out {
+			// 输出延迟不超过窗口长度加一个数据块
+			if now := float64(end) / audio.SampleRate; !l.Final && !l.Revised && now-l.End > cfg.WindowSeconds+0.3 {
+				t.Fatalf("标签延迟过大: %+v，当前时间%.2f", l, now)
+			}
+		}
+		labels = append(labels, out...)
+	}
+	labels = append(labels, d.Flush()...)
+
+	if d.NumSpeakers() != 2 {
+		t.Fatalf("说话人数应为2，实际为%d", d.NumSpeakers())
+	}
+	var finals int
+	for _, l := range labels {
+		if l.Final {
+			finals++
+		}
+	}
+	if finals == 0 || len(d.pending) != 0 {
+		t.Fatalf("Flush后所有标签都应确定: %d %d", finals, len(d.pending))
+	}
+
+	turns := d.Turns()
+	want := []Turn{{0.375, 5, "spk0"}, {6, 11, "spk1"}, {11, 15.625, "spk0"}}
+	if len(turns) != len(want) {
+		t.Fatalf("片段数不正确: %+v", turns)
+	}
+	for i, w := range want {
+		if turns[i].Speaker != w.Speaker || turns[i].Start < w.Start-0.8 || turns[i].Start > w.Start+0.8 || turns[i].End < w.End-0.8 || turns[i].End > w.End+0.8 {
+			t.Fatalf("第%d个片段不正确: %+v，应约为%+v", i, turns[i], w)
+		}
+	}
+}
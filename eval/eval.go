@@ -0,0 +1,215 @@
+// Package eval 提供说话人确认系统的评估指标：EER、minDCF、actDCF、Cllr以及DET/ROC曲线
+//
+// 本包只依赖分数列表，不依赖模型，可用于评估任意打分后端
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// DCFParams 检测代价函数参数
+type DCFParams struct {
+	PTarget float64 // 目标说话人（同一人）的先验概率
+	CMiss   float64 // 漏检代价
+	CFA     float64 // 虚警代价
+}
+
+// DefaultDCFParams 默认参数，与VoxCeleb/NIST SRE常用设置一致
+var DefaultDCFParams = DCFParams{PTarget: 0.01, CMiss: 1, CFA: 1}
+
+// validate 校验参数
+func (p DCFParams) validate() error {
+	if p.PTarget <= 0 || p.PTarget >= 1 {
+		return fmt.Errorf("目标先验概率必须在(0,1)内，实际为: %v", p.PTarget)
+	}
+	if p.CMiss <= 0 || p.CFA <= 0 {
+		return fmt.Errorf("代价必须大于0，实际为: CMiss=%v, CFA=%v", p.CMiss, p.CFA)
+	}
+	return nil
+}
+
+// cost 计算给定漏检率和虚警率下的归一化检测代价
+func (p DCFParams) cost(pMiss, pFA float64) float64 {
+	dcf := p.CMiss*p.PTarget*pMiss + p.CFA*(1-p.PTarget)*pFA
+	return dcf / math.Min(p.CMiss*p.PTarget, p.CFA*(1-p.PTarget))
+}
+
+// bayesThreshold 返回贝叶斯最优的对数似然比阈值
+func (p DCFParams) bayesThreshold() float64 {
+	return math.Log(p.CFA*(1-p.PTarget)) - math.Log(p.CMiss*p.PTarget)
+}
+
+// Report 评估结果
+type Report struct {
+	NumTargets      int     // 同一人试验数
+	NumNonTargets   int     // 不同人试验数
+	EER             float64 // 等错误率[0,1]
+	EERThreshold    float64 // 等错误率对应的分数阈值
+	MinDCF          float64 // 最小归一化检测代价
+	MinDCFThreshold float64 // 最小检测代价对应的分数阈值
+	ActDCF          float64 // 将分数视为LLR、按贝叶斯阈值判决时的实际检测代价
+	Cllr            float64 // 将分数视为LLR时的对数似然比代价（比特）
+}
+
+// Evaluate 计算全部评估指标
+// ActDCF和Cllr只对已校准为对数似然比的分数有意义，原始余弦分数需先经过speaker.Calibrator转换
+//
+// 参数:
+//   - targets: 同一人试验的分数
+//   - nonTargets: 不同人试验的分数
+//   - params: 检测代价函数参数
+//
+// 返回:
+//   - 评估结果和可能的错误
+func Evaluate(targets, nonTargets []float64, params DCFParams) (Report, error) {
+	if len(targets) == 0 || len(nonTargets) == 0 {
+		return Report{}, errors.New("评估需要同一人和不同人的分数")
+	}
+	if err := params.validate(); err != nil {
+		return Report{}, err
+	}
+
+	curve := DETCurve(targets, nonTargets)
+	report := Report{
+		NumTargets:    len(targets),
+		NumNonTargets: len(nonTargets),
+	}
+	report.EER, report.EERThreshold = eerFromCurve(curve)
+	report.MinDCF, report.MinDCFThreshold = minDCFFromCurve(curve, params)
+	report.ActDCF = ActualDCF(targets, nonTargets, params)
+	report.Cllr = Cllr(targets, nonTargets)
+	return report, nil
+}
+
+// EER 计算等错误率及其对应的分数阈值
+func EER(targets, nonTargets []float64) (eer, threshold float64) {
+	return eerFromCurve(DETCurve(targets, nonTargets))
+}
+
+// MinDCF 计算最小归一化检测代价及其对应的分数阈值
+func MinDCF(targets, nonTargets []float64, params DCFParams) (dcf, threshold float64) {
+	return minDCFFromCurve(DETCurve(targets, nonTargets), params)
+}
+
+// ActualDCF 将分数视为对数似然比，按贝叶斯最优阈值判决，计算实际的归一化检测代价
+func ActualDCF(targetLLRs, nonTargetLLRs []float64, params DCFParams) float64 {
+	threshold := params.bayesThreshold()
+	var misses, falseAlarms int
+	for _, s := range targetLLRs {
+		if s < threshold {
+			misses++
+		}
+	}
+	for _, s := range nonTargetLLRs {
+		if s >= threshold {
+			falseAlarms++
+		}
+	}
+	return params.cost(
+		float64(misses)/float64(len(targetLLRs)),
+		float64(falseAlarms)/float64(len(nonTargetLLRs)),
+	)
+}
+
+// Cllr 将分数视为对数似然比，计算对数似然比代价（单位为比特）
+// 完美校准且完全可分时为0，始终输出LLR=0的系统为1
+func Cllr(targetLLRs, nonTargetLLRs []float64) float64 {
+	var ct, cn float64
+	for _, s := range targetLLRs {
+		ct += softplus(-s)
+	}
+	for _, s := range nonTargetLLRs {
+		cn += softplus(s)
+	}
+	ct /= float64(len(targetLLRs))
+	cn /= float64(len(nonTargetLLRs))
+	return (ct + cn) / (2 * math.Ln2)
+}
+
+// CurvePoint DET/ROC曲线上的一个点，分数不低于Threshold时判为同一人
+type CurvePoint struct {
+	Threshold float64
+	PMiss     float64 // 漏检率（错误拒绝率）
+	PFA       float64 // 虚警率（错误接受率）
+}
+
+// DETCurve 计算全部阈值下的漏检率和虚警率，阈值从小到大排列
+// 第一个点的漏检率为0、虚警率为1，最后一个点的阈值为+Inf、漏检率为1、虚警率为0
+func DETCurve(targets, nonTargets []float64) []CurvePoint {
+	type trial struct {
+		score  float64
+		target bool
+	}
+	trials := make([]trial, 0, len(targets)+len(nonTargets))
+	for _, s := range targets {
+		trials = append(trials, trial{s, true})
+	}
+	for _, s := range nonTargets {
+		trials = append(trials, trial{s, false})
+	}
+	sort.Slice(trials, func(i, j int) bool { return trials[i].score < trials[j].score })
+
+	nt, nn := float64(len(targets)), float64(len(nonTargets))
+	misses, falseAlarms := 0, len(nonTargets)
+	points := make([]CurvePoint, 0, len(trials)+1)
+	for i := 0; i < len(trials); {
+		s := trials[i].score
+		points = append(points, CurvePoint{s, float64(misses) / nt, float64(falseAlarms) / nn})
+		// 相同分数的试验共用一个阈值
+		for ; i < len(trials) && trials[i].score == s; i++ {
+			if trials[i].target {
+				misses++
+			} else {
+				falseAlarms--
+			}
+		}
+	}
+	points = append(points, CurvePoint{math.Inf(1), 1, 0})
+	return points
+}
+
+// eerFromCurve 在漏检率与虚警率相交处线性插值得到等错误率
+func eerFromCurve(curve []CurvePoint) (float64, float64) {
+	for i := 1; i < len(curve); i++ {
+		cur := curve[i]
+		if cur.PMiss < cur.PFA {
+			continue
+		}
+		prev := curve[i-1]
+		d0 := prev.PFA - prev.PMiss
+		d1 := cur.PFA - cur.PMiss
+		alpha := 0.0
+		if d0 != d1 {
+			alpha = d0 / (d0 - d1)
+		}
+		eer := prev.PMiss + alpha*(cur.PMiss-prev.PMiss)
+		threshold := prev.Threshold
+		if !math.IsInf(cur.Threshold, 1) {
+			threshold += alpha * (cur.Threshold - prev.Threshold)
+		}
+		return eer, threshold
+	}
+	return 0, curve[len(curve)-1].Threshold
+}
+
+// minDCFFromCurve 在曲线上寻找最小归一化检测代价
+func minDCFFromCurve(curve []CurvePoint, params DCFParams) (float64, float64) {
+	best, threshold := math.Inf(1), 0.0
+	for _, p := range curve {
+		if c := params.cost(p.PMiss, p.PFA); c < best {
+			best, threshold = c, p.Threshold
+		}
+	}
+	return best, threshold
+}
+
+// softplus 数值稳定地计算log(1+exp(x))
+func softplus(x float64) float64 {
+	if x > 30 {
+		return x
+	}
+	return math.Log1p(math.Exp(x))
+}
@@ -0,0 +1,193 @@
+package eval
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// TestEER 测试在已知分布上的等错误率
+func TestEER(t *testing.T) {
+	// 完全可分
+	eer, threshold := EER([]float64{0.8, 0.9}, []float64{0.1, 0.2})
+	if eer != 0 || threshold < 0.2 || threshold > 0.8 {
+		t.Fatalf("完全可分时EER应为0: eer=%v, threshold=%v", eer, threshold)
+	}
+
+	// 方差相同的两个正态分布，EER = Phi(-(mt-mn)/(2*sigma))
+	rng := rand.New(rand.NewSource(1))
+	targets := make([]float64, 20000)
+	nonTargets := make([]float64, 20000)
+	for i := range targets {
+		targets[i] = 1 + rng.NormFloat64()
+		nonTargets[i] = -1 + rng.NormFloat64()
+	}
+	want := 0.5 * math.Erfc(1/math.Sqrt2)
+	eer, threshold = EER(targets, nonTargets)
+	if math.Abs(eer-want) > 0.01 || math.Abs(threshold) > 0.05 {
+		t.Fatalf("EER偏离理论值: eer=%.4f(期望%.4f), threshold=%.4f", eer, want, threshold)
+	}
+}
+
+// TestEvaluate 测试DCF和Cllr的基本性质
+func TestEvaluate(t *testing.T) {
+	targets := []float64{2, 3, 4, -1}
+	nonTargets := []float64{-2, -3, -4, 1, -5, -6}
+
+	report, err := Evaluate(targets, nonTargets, DCFParams{PTarget: 0.5, CMiss: 1, CFA: 1})
+	if err != nil {
+		t.Fatalf("评估失败: %v", err)
+	}
+	if report.NumTargets != 4 || report.NumNonTargets != 6 {
+		t.Fatalf("试验数量不正确: %+v", report)
+	}
+	if report.MinDCF > report.ActDCF+1e-12 {
+		t.Fatalf("minDCF不应大于actDCF: %+v", report)
+	}
+	if report.MinDCF <= 0 || report.MinDCF > 1 {
+		t.Fatalf("minDCF超出范围: %+v", report)
+	}
+
+	// 始终输出0的系统Cllr为1
+	if c := Cllr([]float64{0, 0}, []float64{0}); math.Abs(c-1) > 1e-12 {
+		t.Fatalf("无信息系统的Cllr应为1，实际为: %v", c)
+	}
+
+	if _, err := Evaluate(nil, nonTargets, DefaultDCFParams); err == nil {
+		t.Fatal("缺少同一人分数时应返回错误")
+	}
+}
+
+// TestReadTrials 测试试验列表解析
+func TestReadTrials(t *testing.T) {
+	input := "# comment\n1 a.wav b.wav\n\n0 a.wav c.wav\ntarget d.wav e.wav\n"
+	trials, err := ReadTrials(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("解析试验列表失败: %v", err)
+	}
+	if len(trials) != 3 || !trials[0].Target || trials[1].Target || trials[1].Test != "c.wav" || !trials[2].Target {
+		t.Fatalf("解析结果不正确: %+v", trials)
+	}
+
+	if _, err := ReadTrials(strings.NewReader("2 a.wav b.wav\n")); err == nil {
+		t.Fatal("无效标签应返回错误")
+	}
+}
+
+// TestWriteCurveCSV 测试曲线输出
+func TestWriteCurveCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCurveCSV(&buf, DETCurve([]float64{1, 2}, []float64{0})); err != nil {
+		t.Fatalf("写入CSV失败: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("CSV行数应为5，实际为: %d\n%s", len(lines), buf.String())
+	}
+	if !strings.HasPrefix(lines[len(lines)-1], "inf,1,0,0,inf,-inf") {
+		t.Fatalf("最后一行不正确: %s", lines[len(lines)-1])
+	}
+}
+
+// TestRecommendThreshold 测试按目标虚警率推荐阈值及其置信区间
+func TestRecommendThreshold(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	targets := make([]float64, 2000)
+	nonTargets := make([]float64, 10000)
+	for i := range targets {
+		targets[i] = 0.7 + 0.1*rng.NormFloat64()
+	}
+	for i := range nonTargets {
+		nonTargets[i] = 0.2 + 0.1*rng.NormFloat64()
+	}
+
+	opts := DefaultThresholdOptions()
+	opts.Bootstrap = 200
+	result, err := RecommendThreshold(targets, nonTargets, opts)
+	if err != nil {
+		t.Fatalf("推荐阈值失败: %v", err)
+	}
+	if result.FAR > opts.TargetFAR {
+		t.Fatalf("虚警率超过目标值: %.4f > %.4f", result.FAR, opts.TargetFAR)
+	}
+	// 正态分布1%虚警率对应均值以上约2.33个标准差
+	if math.Abs(result.Threshold-(0.2+0.1*2.326)) > 0.02 {
+		t.Fatalf("阈值偏离理论值: %.4f", result.Threshold)
+	}
+	if !(result.Lower <= result.Threshold && result.Threshold <= result.Upper) || result.Upper-result.Lower > 0.05 {
+		t.Fatalf("置信区间不合理: [%.4f, %.4f], 阈值 %.4f", result.Lower, result.Upper, result.Threshold)
+	}
+
+	opts.Criterion = CriterionEER
+	result, err = RecommendThreshold(targets, nonTargets, opts)
+	if err != nil {
+		t.Fatalf("推荐EER阈值失败: %v", err)
+	}
+	if math.Abs(result.Threshold-0.45) > 0.02 {
+		t.Fatalf("EER阈值偏离理论值: %.4f", result.Threshold)
+	}
+}
+
+// TestRecommendMinDCFThreshold 测试全部拒绝的代价最小时，按最小检测代价推荐的阈值仍为有限值
+func TestRecommendMinDCFThreshold(t *testing.T) {
+	targets := []float64{.5, .6, .7, .8, .4}
+	nonTargets := []float64{.1, .2, .3, .45, .55, 0, .9}
+	if _, threshold := MinDCF(targets, nonTargets, DefaultDCFParams); !math.IsInf(threshold, 1) {
+		t.Fatalf("该数据上全部拒绝的代价应最小，实际阈值为%v", threshold)
+	}
+
+	opts := DefaultThresholdOptions()
+	opts.Criterion = CriterionMinDCF
+	opts.Bootstrap = 200
+	result, err := RecommendThreshold(targets, nonTargets, opts)
+	if err != nil {
+		t.Fatalf("推荐阈值失败: %v", err)
+	}
+	for _, v := range []float64{result.Threshold, result.Lower, result.Upper} {
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			t.Fatalf("推荐结果应为有限值: %+v", result)
+		}
+	}
+
+	if _, err := RecommendThreshold([]float64{math.Inf(1)}, []float64{math.Inf(1)}, opts); err == nil {
+		t.Fatal("只有非有限分数时应返回错误")
+	}
+}
+
+// TestThresholdRoundTrip 测试按虚警率推荐的阈值保存为float32后虚警率仍不超过目标值
+func TestThresholdRoundTrip(t *testing.T) {
+	// 分数来自float32打分
+	rng := rand.New(rand.NewSource(1))
+	targets := make([]float64, 100)
+	nonTargets := make([]float64, 100)
+	for i := range targets {
+		targets[i] = float64(float32(0.7 + 0.1*rng.NormFloat64()))
+		nonTargets[i] = float64(float32(0.2 + 0.1*rng.NormFloat64()))
+	}
+	opts := DefaultThresholdOptions()
+	opts.Bootstrap = 0
+	result, err := RecommendThreshold(targets, nonTargets, opts)
+	if err != nil {
+		t.Fatalf("推荐阈值失败: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "threshold.json")
+	if err := (&speaker.ThresholdConfig{Threshold: float32(result.Threshold), FAR: result.FAR}).Save(path); err != nil {
+		t.Fatal(err)
+	}
+	config, err := speaker.LoadThresholdConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if float64(config.Threshold) != result.Threshold {
+		t.Fatalf("保存后阈值发生变化: %v != %v", config.Threshold, result.Threshold)
+	}
+	if far := rateAtOrAbove(nonTargets, float64(config.Threshold)); far > opts.TargetFAR || far != result.FAR {
+		t.Fatalf("保存后的阈值虚警率为%.4f，推荐时为%.4f", far, result.FAR)
+	}
+}
@@ -0,0 +1,151 @@
+package eval
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Trial 一条试验：判断Enroll和Test两段音频是否来自同一说话人
+type Trial struct {
+	Target bool   // 是否为同一人
+	Enroll string // 注册音频路径
+	Test   string // 测试音频路径
+}
+
+// ReadTrials 读取VoxCeleb格式的试验列表，每行为"标签 路径1 路径2"
+// 标签可以是1/0或target/nontarget，空行和以#开头的行会被忽略
+func ReadTrials(r io.Reader) ([]Trial, error) {
+	var trials []Trial
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("第%d行格式错误，应为\"标签 路径1 路径2\": %s", line, text)
+		}
+
+		target, err := parseLabel(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("第%d行%w", line, err)
+		}
+		trials = append(trials, Trial{Target: target, Enroll: fields[1], Test: fields[2]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取试验列表失败: %w", err)
+	}
+	return trials, nil
+}
+
+// ReadTrialsFile 从文件读取试验列表，参见ReadTrials
+func ReadTrialsFile(path string) ([]Trial, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开试验列表失败: %w", err)
+	}
+	defer f.Close()
+	return ReadTrials(f)
+}
+
+// WriteCurveCSV 将DET/ROC曲线写为CSV
+// 列依次为阈值、漏检率、虚警率、真正率（ROC纵轴）以及漏检率和虚警率的probit变换（DET坐标轴）
+func WriteCurveCSV(w io.Writer, curve []CurvePoint) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"threshold", "p_miss", "p_fa", "tpr", "probit_miss", "probit_fa"}); err != nil {
+		return err
+	}
+	for _, p := range curve {
+		record := []string{
+			formatFloat(p.Threshold),
+			formatFloat(p.PMiss),
+			formatFloat(p.PFA),
+			formatFloat(1 - p.PMiss),
+			formatFloat(probit(p.PMiss)),
+			formatFloat(probit(p.PFA)),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// probit 标准正态分布的分位数函数，DET曲线以此为坐标轴
+func probit(p float64) float64 {
+	return math.Sqrt2 * math.Erfinv(2*p-1)
+}
+
+// formatFloat 格式化浮点数，无穷大输出为inf/-inf
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "inf"
+	case math.IsInf(v, -1):
+		return "-inf"
+	}
+	return strconv.FormatFloat(v, 'g', 8, 64)
+}
+
+// ReadScores 读取带标签的分数列表，每行第一列为标签（同ReadTrials），最后一列为分数，
+// 例如spk eval -scores输出的"标签 路径1 路径2 分数"
+func ReadScores(r io.Reader) (targets, nonTargets []float64, err error) {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) < 2 {
+			return nil, nil, fmt.Errorf("第%d行格式错误，应为\"标签 ... 分数\": %s", line, text)
+		}
+		target, err := parseLabel(fields[0])
+		if err != nil {
+			return nil, nil, fmt.Errorf("第%d行%w", line, err)
+		}
+		score, err := strconv.ParseFloat(fields[len(fields)-1], 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("第%d行分数无效: %s", line, fields[len(fields)-1])
+		}
+		if target {
+			targets = append(targets, score)
+		} else {
+			nonTargets = append(nonTargets, score)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("读取分数列表失败: %w", err)
+	}
+	return targets, nonTargets, nil
+}
+
+// ReadScoresFile 从文件读取带标签的分数列表，参见ReadScores
+func ReadScoresFile(path string) (targets, nonTargets []float64, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("打开分数列表失败: %w", err)
+	}
+	defer f.Close()
+	return ReadScores(f)
+}
+
+// parseLabel 解析试验标签
+func parseLabel(label string) (bool, error) {
+	switch strings.ToLower(label) {
+	case "1", "target", "tgt":
+		return true, nil
+	case "0", "nontarget", "non-target", "imp":
+		return false, nil
+	default:
+		return false, fmt.Errorf("标签无效: %s", label)
+	}
+}
@@ -0,0 +1,201 @@
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Criterion 阈值选择准则
+type Criterion int
+
+const (
+	// CriterionFAR 选择虚警率不超过目标值的最低阈值
+	CriterionFAR Criterion = iota
+	// CriterionEER 选择等错误率对应的阈值
+	CriterionEER
+	// CriterionMinDCF 选择最小检测代价对应的阈值
+	CriterionMinDCF
+)
+
+// String 返回准则名称
+func (c Criterion) String() string {
+	switch c {
+	case CriterionFAR:
+		return "far"
+	case CriterionEER:
+		return "eer"
+	case CriterionMinDCF:
+		return "mindcf"
+	default:
+		return fmt.Sprintf("Criterion(%d)", int(c))
+	}
+}
+
+// ParseCriterion 根据名称解析阈值选择准则
+func ParseCriterion(name string) (Criterion, error) {
+	for _, c := range []Criterion{CriterionFAR, CriterionEER, CriterionMinDCF} {
+		if c.String() == name {
+			return c, nil
+		}
+	}
+	return 0, fmt.Errorf("未知的阈值选择准则: %s", name)
+}
+
+// ThresholdOptions 阈值推荐参数
+type ThresholdOptions struct {
+	Criterion  Criterion
+	TargetFAR  float64   // CriterionFAR使用的目标虚警率，如0.01
+	DCF        DCFParams // CriterionMinDCF使用的检测代价参数
+	Bootstrap  int       // 自助法重采样次数，<=0时不计算置信区间
+	Confidence float64   // 置信水平，默认0.95
+	Seed       int64     // 重采样随机数种子
+}
+
+// DefaultThresholdOptions 返回默认参数：虚警率1%，1000次重采样，95%置信区间
+func DefaultThresholdOptions() ThresholdOptions {
+	return ThresholdOptions{
+		Criterion:  CriterionFAR,
+		TargetFAR:  0.01,
+		DCF:        DefaultDCFParams,
+		Bootstrap:  1000,
+		Confidence: 0.95,
+		Seed:       1,
+	}
+}
+
+// ThresholdResult 阈值推荐结果
+type ThresholdResult struct {
+	Criterion  Criterion
+	Threshold  float64 // 推荐阈值，分数不低于该值时判为同一人
+	Lower      float64 // 阈值置信区间下限
+	Upper      float64 // 阈值置信区间上限
+	Confidence float64 // 置信水平
+	FAR        float64 // 推荐阈值在全部数据上的虚警率
+	FRR        float64 // 推荐阈值在全部数据上的漏检率
+}
+
+// RecommendThreshold 根据带标签的分数推荐判决阈值，并用自助法估计阈值的置信区间
+//
+// 参数:
+//   - targets: 同一人试验的分数
+//   - nonTargets: 不同人试验的分数
+//   - opts: 推荐参数
+//
+// 返回:
+//   - 推荐结果和可能的错误
+func RecommendThreshold(targets, nonTargets []float64, opts ThresholdOptions) (ThresholdResult, error) {
+	if len(targets) == 0 || len(nonTargets) == 0 {
+		return ThresholdResult{}, errors.New("推荐阈值需要同一人和不同人的分数")
+	}
+	switch opts.Criterion {
+	case CriterionFAR:
+		if opts.TargetFAR <= 0 || opts.TargetFAR >= 1 {
+			return ThresholdResult{}, fmt.Errorf("目标虚警率必须在(0,1)内，实际为: %v", opts.TargetFAR)
+		}
+	case CriterionMinDCF:
+		if err := opts.DCF.validate(); err != nil {
+			return ThresholdResult{}, err
+		}
+	case CriterionEER:
+	default:
+		return ThresholdResult{}, fmt.Errorf("未知的阈值选择准则: %d", opts.Criterion)
+	}
+	if opts.Confidence <= 0 || opts.Confidence >= 1 {
+		opts.Confidence = 0.95
+	}
+
+	threshold := selectThreshold(targets, nonTargets, opts)
+	if math.IsInf(threshold, 0) || math.IsNaN(threshold) {
+		return ThresholdResult{}, fmt.Errorf("无法推荐有限的阈值，请检查分数中是否有非有限值: %v", threshold)
+	}
+	result := ThresholdResult{
+		Criterion:  opts.Criterion,
+		Threshold:  threshold,
+		Lower:      threshold,
+		Upper:      threshold,
+		Confidence: opts.Confidence,
+		FAR:        rateAtOrAbove(nonTargets, threshold),
+		FRR:        1 - rateAtOrAbove(targets, threshold),
+	}
+
+	if opts.Bootstrap > 0 {
+		rng := rand.New(rand.NewSource(opts.Seed))
+		samples := make([]float64, 0, opts.Bootstrap)
+		t := make([]float64, len(targets))
+		n := make([]float64, len(nonTargets))
+		for range opts.Bootstrap {
+			resample(rng, targets, t)
+			resample(rng, nonTargets, n)
+			// 重采样可能只抽到非有限的分数，这样的样本不参与置信区间
+			if threshold := selectThreshold(t, n, opts); !math.IsInf(threshold, 0) && !math.IsNaN(threshold) {
+				samples = append(samples, threshold)
+			}
+		}
+		if len(samples) > 0 {
+			sort.Float64s(samples)
+			alpha := (1 - opts.Confidence) / 2
+			result.Lower = quantile(samples, alpha)
+			result.Upper = quantile(samples, 1-alpha)
+		}
+	}
+	return result, nil
+}
+
+// selectThreshold 按准则在给定分数上选择阈值
+func selectThreshold(targets, nonTargets []float64, opts ThresholdOptions) float64 {
+	switch opts.Criterion {
+	case CriterionEER:
+		_, threshold := EER(targets, nonTargets)
+		return threshold
+	case CriterionMinDCF:
+		// 全部拒绝对应的+Inf不是可用的判决阈值，只在有限阈值中选择
+		curve := DETCurve(targets, nonTargets)
+		_, threshold := minDCFFromCurve(curve[:len(curve)-1], opts.DCF)
+		return threshold
+	default:
+		return thresholdAtFAR(nonTargets, opts.TargetFAR)
+	}
+}
+
+// thresholdAtFAR 返回使虚警率不超过far的最低阈值
+// 阈值以float32保存和比较，因此取第k+1高分数之上的下一个float32值，保存后不会舍入回该分数
+func thresholdAtFAR(nonTargets []float64, far float64) float64 {
+	sorted := append([]float64(nil), nonTargets...)
+	sort.Sort(sort.Reverse(sort.Float64Slice(sorted)))
+
+	// 最多允许k个不同人试验被接受，阈值取第k+1高分数之上
+	k := int(math.Floor(far * float64(len(sorted))))
+	if k >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return float64(math.Nextafter32(float32(sorted[k]), float32(math.Inf(1))))
+}
+
+// rateAtOrAbove 返回分数不低于阈值的比例
+func rateAtOrAbove(scores []float64, threshold float64) float64 {
+	count := 0
+	for _, s := range scores {
+		if s >= threshold {
+			count++
+		}
+	}
+	return float64(count) / float64(len(scores))
+}
+
+// resample 有放回地重采样
+func resample(rng *rand.Rand, src, dst []float64) {
+	for i := range dst {
+		dst[i] = src[rng.Intn(len(src))]
+	}
+}
+
+// quantile 返回已排序数据的分位数（线性插值）
+func quantile(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	return sorted[lo] + (pos-float64(lo))*(sorted[hi]-sorted[lo])
+}
@@ -0,0 +1,643 @@
+// Package gallery 提供基于文件的说话人嵌入向量库，用于在服务重启后恢复已注册的说话人
+//
+// 文件格式为带校验头的追加写日志：
+//
+//	头部(80字节): 魔数"SPKG" | 版本(u32) | 维度(u32) | 模型指纹(64字节) | CRC32(u32)
+//	记录: 负载长度(u32) | 负载CRC32(u32) | 负载
+//	负载: 操作类型(u8) | ID长度(u16) | ID | 向量数据(维度*float32，仅写入操作)
+//
+// 除注册的说话人外，库中还可以保存用于分数规整的冒认者集合（cohort），二者ID相互独立。
+// 每次写入后都会fsync；打开时若发现尾部记录不完整或校验失败（如写入过程中崩溃），
+// 会将文件截断到最后一条完整记录。无效记录累积到一定比例后自动压缩日志。
+package gallery
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+const (
+	magic         = "SPKG"
+	formatVersion = 2 // 版本2增加了冒认者记录，版本1的文件打开时自动升级
+
+	fingerprintSize = 64
+	headerSize      = 4 + 4 + 4 + fingerprintSize + 4
+	recordHeadSize  = 8
+
+	opPut          = 1
+	opDelete       = 2
+	opPutCohort    = 3
+	opDeleteCohort = 4
+
+	maxIDLen = math.MaxUint16
+)
+
+// 自动压缩的触发条件：无效记录数超过minGarbage且占比超过garbageRatio
+const (
+	minGarbage   = 1024
+	garbageRatio = 0.5
+)
+
+var (
+	// ErrClosed 表示库已关闭
+	ErrClosed = errors.New("说话人库已关闭")
+	// ErrNotFound 表示指定ID不存在
+	ErrNotFound = errors.New("说话人不存在")
+	// ErrFingerprintMismatch 表示库中的模型指纹与当前模型不一致
+	ErrFingerprintMismatch = errors.New("模型指纹不匹配")
+	// ErrDimensionMismatch 表示嵌入向量维度与库不一致
+	ErrDimensionMismatch = errors.New("嵌入向量维度不匹配")
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Header 说话人库的头部信息
+type Header struct {
+	Fingerprint string // 模型指纹，为空表示尚未绑定
+	Dimension   int    // 嵌入向量维度，为0表示尚未确定
+}
+
+// Entry 说话人库中的一条记录
+type Entry struct {
+	ID        string
+	Embedding *speaker.Embedding
+}
+
+// Store 基于追加写日志的说话人库，可安全地并发使用
+type Store struct {
+	mu      sync.RWMutex
+	path    string
+	file    *os.File
+	header  Header
+	entries map[string]*speaker.Embedding
+	cohort  map[string]*speaker.Embedding
+	size    int64 // 当前有效文件长度
+	records int   // 日志中的记录总数
+}
+
+// Open 打开或创建说话人库文件
+//
+// 参数:
+//   - path: 库文件路径，不存在时自动创建
+//
+// 返回:
+//   - 说话人库和可能的错误
+func Open(path string) (*Store, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开说话人库失败: %w", err)
+	}
+
+	s := &Store{
+		path:    path,
+		file:    f,
+		entries: make(map[string]*speaker.Embedding),
+		cohort:  make(map[string]*speaker.Embedding),
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("读取说话人库信息失败: %w", err)
+	}
+
+	if info.Size() == 0 {
+		// 新库：写入空头部
+		if err := s.writeHeader(); err != nil {
+			f.Close()
+			return nil, err
+		}
+		if err := syncDir(path); err != nil {
+			f.Close()
+			return nil, err
+		}
+		s.size = headerSize
+		return s, nil
+	}
+
+	if err := s.load(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// load 读取头部并重放日志，截断不完整的尾部
+func (s *Store) load() error {
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("定位说话人库失败: %w", err)
+	}
+	r := bufio.NewReader(s.file)
+
+	head := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return fmt.Errorf("读取说话人库头部失败: %w", err)
+	}
+	header, version, err := decodeHeader(head)
+	if err != nil {
+		return err
+	}
+	s.header = header
+	if version < formatVersion {
+		// 旧版本的记录在新版本中含义不变，只需更新头部，避免旧程序误读新记录
+		if err := s.writeHeader(); err != nil {
+			return err
+		}
+	}
+
+	offset := int64(headerSize)
+	for {
+		op, id, data, n, err := readRecord(r, s.header.Dimension)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// 尾部记录损坏或不完整，丢弃其后的所有内容
+			fmt.Fprintf(os.Stderr, "警告: 说话人库 %s 在偏移量 %d 处记录无效(%v)，截断尾部\n", s.path, offset, err)
+			if err := s.file.Truncate(offset); err != nil {
+				return fmt.Errorf("截断说话人库失败: %w", err)
+			}
+			if err := s.file.Sync(); err != nil {
+				return fmt.Errorf("同步说话人库失败: %w", err)
+			}
+			break
+		}
+		s.apply(op, id, data)
+		s.records++
+		offset += int64(n)
+	}
+	s.size = offset
+	return nil
+}
+
+// apply 将一条记录应用到内存索引
+func (s *Store) apply(op byte, id string, data []float32) {
+	switch op {
+	case opPut:
+		s.entries[id] = speaker.NewEmbedding(data)
+	case opDelete:
+		delete(s.entries, id)
+	case opPutCohort:
+		s.cohort[id] = speaker.NewEmbedding(data)
+	case opDeleteCohort:
+		delete(s.cohort, id)
+	}
+}
+
+// Header 返回库的头部信息
+func (s *Store) Header() Header {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.header
+}
+
+// Bind 将库绑定到指定的模型指纹和维度
+// 对于尚未绑定的库会写入头部；对于已绑定的库则校验是否一致
+func (s *Store) Bind(fingerprint string, dimension int) error {
+	if len(fingerprint) > fingerprintSize {
+		return fmt.Errorf("模型指纹过长: %d 字节", len(fingerprint))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return ErrClosed
+	}
+
+	if s.header.Fingerprint != "" && s.header.Fingerprint != fingerprint {
+		return fmt.Errorf("%w: 库为 %s，当前模型为 %s", ErrFingerprintMismatch, s.header.Fingerprint, fingerprint)
+	}
+	if s.header.Dimension != 0 && s.header.Dimension != dimension {
+		return fmt.Errorf("%w: 库为 %d，当前模型为 %d", ErrDimensionMismatch, s.header.Dimension, dimension)
+	}
+	if s.header.Fingerprint == fingerprint && s.header.Dimension == dimension {
+		return nil
+	}
+
+	s.header = Header{Fingerprint: fingerprint, Dimension: dimension}
+	return s.writeHeader()
+}
+
+// Put 写入或覆盖一个说话人的嵌入向量
+func (s *Store) Put(id string, emb *speaker.Embedding) error {
+	return s.put(opPut, id, emb)
+}
+
+// PutCohort 写入或覆盖一个冒认者的嵌入向量，冒认者用于分数规整，不参与Iterate和Snapshot
+func (s *Store) PutCohort(id string, emb *speaker.Embedding) error {
+	return s.put(opPutCohort, id, emb)
+}
+
+// put 写入一条嵌入向量记录
+func (s *Store) put(op byte, id string, emb *speaker.Embedding) error {
+	if id == "" {
+		return errors.New("说话人ID为空")
+	}
+	if len(id) > maxIDLen {
+		return fmt.Errorf("说话人ID过长: %d 字节", len(id))
+	}
+	if emb == nil || emb.GetEmbeddingDimension() == 0 {
+		return errors.New("嵌入向量为空")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return ErrClosed
+	}
+
+	dim := emb.GetEmbeddingDimension()
+	if s.header.Dimension == 0 {
+		// 首次写入时确定维度
+		s.header.Dimension = dim
+		if err := s.writeHeader(); err != nil {
+			return err
+		}
+	} else if s.header.Dimension != dim {
+		return fmt.Errorf("%w: 库为 %d，输入为 %d", ErrDimensionMismatch, s.header.Dimension, dim)
+	}
+
+	if err := s.append(encodeRecord(op, id, emb.GetData())); err != nil {
+		return err
+	}
+	s.apply(op, id, emb.GetData())
+	return s.maybeCompact()
+}
+
+// Delete 删除一个说话人，ID不存在时返回ErrNotFound
+func (s *Store) Delete(id string) error {
+	return s.delete(opDelete, id)
+}
+
+// DeleteCohort 删除一个冒认者，ID不存在时返回ErrNotFound
+func (s *Store) DeleteCohort(id string) error {
+	return s.delete(opDeleteCohort, id)
+}
+
+// delete 写入一条删除记录
+func (s *Store) delete(op byte, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return ErrClosed
+	}
+	entries := s.entries
+	if op == opDeleteCohort {
+		entries = s.cohort
+	}
+	if _, ok := entries[id]; !ok {
+		return ErrNotFound
+	}
+
+	if err := s.append(encodeRecord(op, id, nil)); err != nil {
+		return err
+	}
+	s.apply(op, id, nil)
+	return s.maybeCompact()
+}
+
+// Get 获取一个说话人的嵌入向量
+func (s *Store) Get(id string) (*speaker.Embedding, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	emb, ok := s.entries[id]
+	return emb, ok
+}
+
+// Len 返回说话人数量
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.entries)
+}
+
+// Iterate 按ID顺序遍历所有说话人，fn返回false时停止遍历
+// 遍历期间持有读锁，fn中不能调用Put、Delete等写方法
+func (s *Store) Iterate(fn func(id string, emb *speaker.Embedding) bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, id := range s.sortedIDs() {
+		if !fn(id, s.entries[id]) {
+			return
+		}
+	}
+}
+
+// Snapshot 返回当前所有说话人的一致性快照（按ID排序），之后的写入不影响快照内容
+func (s *Store) Snapshot() []Entry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entries := make([]Entry, 0, len(s.entries))
+	for _, id := range s.sortedIDs() {
+		entries = append(entries, Entry{ID: id, Embedding: s.entries[id]})
+	}
+	return entries
+}
+
+// Cohort 返回冒认者集合的嵌入向量（按ID排序），可直接用于speaker.NewScoreNormalizer
+func (s *Store) Cohort() []*speaker.Embedding {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ids := sortedKeys(s.cohort)
+	cohort := make([]*speaker.Embedding, len(ids))
+	for i, id := range ids {
+		cohort[i] = s.cohort[id]
+	}
+	return cohort
+}
+
+// SaveCohort 用规整器的冒认者集合替换库中已有的冒认者
+// 通过重写整个文件完成替换，中途失败时库中仍为原有的冒认者
+func (s *Store) SaveCohort(n *speaker.ScoreNormalizer) error {
+	embs := n.Cohort()
+	cohort := make(map[string]*speaker.Embedding, len(embs))
+	dim := 0
+	for i, emb := range embs {
+		if emb == nil || emb.GetEmbeddingDimension() == 0 {
+			return errors.New("嵌入向量为空")
+		}
+		if i == 0 {
+			dim = emb.GetEmbeddingDimension()
+		} else if emb.GetEmbeddingDimension() != dim {
+			return fmt.Errorf("%w: 冒认者维度不一致", ErrDimensionMismatch)
+		}
+		cohort[fmt.Sprintf("cohort-%06d", i)] = speaker.NewEmbedding(emb.GetData())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return ErrClosed
+	}
+	header := s.header
+	if len(cohort) > 0 {
+		if s.header.Dimension == 0 {
+			s.header.Dimension = dim
+		} else if s.header.Dimension != dim {
+			return fmt.Errorf("%w: 库为 %d，输入为 %d", ErrDimensionMismatch, s.header.Dimension, dim)
+		}
+	}
+
+	old, file := s.cohort, s.file
+	s.cohort = cohort
+	err := s.compact()
+	if err != nil && s.file == file {
+		// 尚未替换文件，恢复原有的冒认者
+		s.cohort, s.header = old, header
+	}
+	return err
+}
+
+// sortedIDs 返回排序后的说话人ID列表，调用方需持有锁
+func (s *Store) sortedIDs() []string {
+	return sortedKeys(s.entries)
+}
+
+// sortedKeys 返回排序后的ID列表
+func sortedKeys(entries map[string]*speaker.Embedding) []string {
+	ids := make([]string, 0, len(entries))
+	for id := range entries {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Compact 将日志重写为仅包含有效记录的新文件
+func (s *Store) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return ErrClosed
+	}
+	return s.compact()
+}
+
+// Close 关闭说话人库
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return ErrClosed
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// maybeCompact 在无效记录过多时压缩日志，调用方需持有写锁
+func (s *Store) maybeCompact() error {
+	garbage := s.records - len(s.entries) - len(s.cohort)
+	if garbage < minGarbage || float64(garbage) < garbageRatio*float64(s.records) {
+		return nil
+	}
+	return s.compact()
+}
+
+// compact 写入临时文件后原子替换，调用方需持有写锁
+func (s *Store) compact() error {
+	tmpPath := s.path + ".compact"
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("创建压缩文件失败: %w", err)
+	}
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmpPath)
+	}
+
+	w := bufio.NewWriter(tmp)
+	if _, err := w.Write(encodeHeader(s.header)); err != nil {
+		cleanup()
+		return fmt.Errorf("写入压缩文件失败: %w", err)
+	}
+	size := int64(headerSize)
+	for _, id := range s.sortedIDs() {
+		rec := encodeRecord(opPut, id, s.entries[id].GetData())
+		if _, err := w.Write(rec); err != nil {
+			cleanup()
+			return fmt.Errorf("写入压缩文件失败: %w", err)
+		}
+		size += int64(len(rec))
+	}
+	for _, id := range sortedKeys(s.cohort) {
+		rec := encodeRecord(opPutCohort, id, s.cohort[id].GetData())
+		if _, err := w.Write(rec); err != nil {
+			cleanup()
+			return fmt.Errorf("写入压缩文件失败: %w", err)
+		}
+		size += int64(len(rec))
+	}
+	if err := w.Flush(); err != nil {
+		cleanup()
+		return fmt.Errorf("写入压缩文件失败: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		cleanup()
+		return fmt.Errorf("同步压缩文件失败: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		cleanup()
+		return fmt.Errorf("替换说话人库失败: %w", err)
+	}
+
+	// 重命名后旧文件已被替换，无论目录同步是否成功都要切换到新文件
+	s.file.Close()
+	s.file = tmp
+	s.size = size
+	s.records = len(s.entries) + len(s.cohort)
+	return syncDir(s.path)
+}
+
+// append 在文件末尾追加一条记录并同步到磁盘，调用方需持有写锁
+func (s *Store) append(rec []byte) error {
+	if _, err := s.file.WriteAt(rec, s.size); err != nil {
+		// 尽量回滚部分写入，失败也无妨，打开时会截断
+		s.file.Truncate(s.size)
+		return fmt.Errorf("写入说话人库失败: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("同步说话人库失败: %w", err)
+	}
+	s.size += int64(len(rec))
+	s.records++
+	return nil
+}
+
+// writeHeader 写入头部并同步到磁盘，调用方需持有写锁
+func (s *Store) writeHeader() error {
+	if _, err := s.file.WriteAt(encodeHeader(s.header), 0); err != nil {
+		return fmt.Errorf("写入说话人库头部失败: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("同步说话人库失败: %w", err)
+	}
+	return nil
+}
+
+// encodeHeader 编码头部
+func encodeHeader(h Header) []byte {
+	buf := make([]byte, headerSize)
+	copy(buf[0:4], magic)
+	binary.LittleEndian.PutUint32(buf[4:8], formatVersion)
+	binary.LittleEndian.PutUint32(buf[8:12], uint32(h.Dimension))
+	copy(buf[12:12+fingerprintSize], h.Fingerprint)
+	binary.LittleEndian.PutUint32(buf[headerSize-4:], crc32.Checksum(buf[:headerSize-4], crcTable))
+	return buf
+}
+
+// decodeHeader 解码并校验头部，同时返回文件格式版本
+func decodeHeader(buf []byte) (Header, uint32, error) {
+	if string(buf[0:4]) != magic {
+		return Header{}, 0, errors.New("不是有效的说话人库文件")
+	}
+	if crc32.Checksum(buf[:headerSize-4], crcTable) != binary.LittleEndian.Uint32(buf[headerSize-4:]) {
+		return Header{}, 0, errors.New("说话人库头部校验失败")
+	}
+	version := binary.LittleEndian.Uint32(buf[4:8])
+	if version == 0 || version > formatVersion {
+		return Header{}, 0, fmt.Errorf("不支持的说话人库版本: %d", version)
+	}
+
+	fp := buf[12 : 12+fingerprintSize]
+	n := 0
+	for n < len(fp) && fp[n] != 0 {
+		n++
+	}
+	return Header{
+		Fingerprint: string(fp[:n]),
+		Dimension:   int(binary.LittleEndian.Uint32(buf[8:12])),
+	}, version, nil
+}
+
+// encodeRecord 编码一条日志记录
+func encodeRecord(op byte, id string, data []float32) []byte {
+	payloadLen := 1 + 2 + len(id) + 4*len(data)
+	buf := make([]byte, recordHeadSize+payloadLen)
+	payload := buf[recordHeadSize:]
+	payload[0] = op
+	binary.LittleEndian.PutUint16(payload[1:3], uint16(len(id)))
+	copy(payload[3:], id)
+	off := 3 + len(id)
+	for _, v := range data {
+		binary.LittleEndian.PutUint32(payload[off:], math.Float32bits(v))
+		off += 4
+	}
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(payloadLen))
+	binary.LittleEndian.PutUint32(buf[4:8], crc32.Checksum(payload, crcTable))
+	return buf
+}
+
+// readRecord 读取并校验一条日志记录，返回记录占用的字节数
+// 文件正好结束时返回io.EOF，记录不完整或损坏时返回其他错误
+func readRecord(r io.Reader, dim int) (op byte, id string, data []float32, n int, err error) {
+	var head [recordHeadSize]byte
+	if _, err = io.ReadFull(r, head[:]); err != nil {
+		if err == io.EOF {
+			return 0, "", nil, 0, io.EOF
+		}
+		return 0, "", nil, 0, fmt.Errorf("记录头不完整: %w", err)
+	}
+	payloadLen := int(binary.LittleEndian.Uint32(head[0:4]))
+	if payloadLen < 3 || payloadLen > 3+maxIDLen+4*dim {
+		return 0, "", nil, 0, fmt.Errorf("记录长度无效: %d", payloadLen)
+	}
+
+	payload := make([]byte, payloadLen)
+	if _, err = io.ReadFull(r, payload); err != nil {
+		return 0, "", nil, 0, fmt.Errorf("记录内容不完整: %w", err)
+	}
+	if crc32.Checksum(payload, crcTable) != binary.LittleEndian.Uint32(head[4:8]) {
+		return 0, "", nil, 0, errors.New("记录校验失败")
+	}
+
+	op = payload[0]
+	idLen := int(binary.LittleEndian.Uint16(payload[1:3]))
+	if 3+idLen > payloadLen {
+		return 0, "", nil, 0, errors.New("记录ID长度无效")
+	}
+	id = string(payload[3 : 3+idLen])
+	rest := payload[3+idLen:]
+
+	switch op {
+	case opPut, opPutCohort:
+		if len(rest) != 4*dim {
+			return 0, "", nil, 0, fmt.Errorf("记录向量长度无效: %d 字节", len(rest))
+		}
+		data = make([]float32, dim)
+		for i := range data {
+			data[i] = math.Float32frombits(binary.LittleEndian.Uint32(rest[4*i:]))
+		}
+	case opDelete, opDeleteCohort:
+		if len(rest) != 0 {
+			return 0, "", nil, 0, errors.New("删除记录包含多余数据")
+		}
+	default:
+		return 0, "", nil, 0, fmt.Errorf("未知的记录类型: %d", op)
+	}
+	return op, id, data, recordHeadSize + payloadLen, nil
+}
+
+// syncDir 同步文件所在目录，确保新建或重命名的文件项持久化
+func syncDir(path string) error {
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return fmt.Errorf("打开目录失败: %w", err)
+	}
+	defer dir.Close()
+	if err := dir.Sync(); err != nil {
+		return fmt.Errorf("同步目录失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package gallery
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// TestStorePersistence 测试写入、删除后重新打开能恢复相同内容
+func TestStorePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.gallery")
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("打开说话人库失败: %v", err)
+	}
+	if err := store.Bind("fingerprint-a", 3); err != nil {
+		t.Fatalf("绑定模型失败: %v", err)
+	}
+	if err := store.Put("alice", speaker.NewEmbedding([]float32{1, 0, 0})); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+	if err := store.Put("bob", speaker.NewEmbedding([]float32{0, 1, 0})); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+	if err := store.Put("alice", speaker.NewEmbedding([]float32{0, 0, 1})); err != nil {
+		t.Fatalf("覆盖写入失败: %v", err)
+	}
+	if err := store.Delete("bob"); err != nil {
+		t.Fatalf("删除失败: %v", err)
+	}
+	if err := store.Delete("bob"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("重复删除应返回ErrNotFound，实际为: %v", err)
+	}
+	store.Close()
+
+	store, err = Open(path)
+	if err != nil {
+		t.Fatalf("重新打开说话人库失败: %v", err)
+	}
+	defer store.Close()
+
+	if h := store.Header(); h.Fingerprint != "fingerprint-a" || h.Dimension != 3 {
+		t.Fatalf("头部信息不正确: %+v", h)
+	}
+	if store.Len() != 1 {
+		t.Fatalf("说话人数量应为1，实际为: %d", store.Len())
+	}
+	emb, ok := store.Get("alice")
+	if !ok || emb.GetData()[2] != 1 {
+		t.Fatalf("alice的嵌入向量不正确: %v", emb)
+	}
+	if err := store.Bind("fingerprint-b", 3); !errors.Is(err, ErrFingerprintMismatch) {
+		t.Fatalf("绑定不同指纹应失败，实际为: %v", err)
+	}
+	if err := store.Put("carol", speaker.NewEmbedding([]float32{1, 2})); !errors.Is(err, ErrDimensionMismatch) {
+		t.Fatalf("写入不同维度应失败，实际为: %v", err)
+	}
+}
+
+// TestStoreTruncatedTail 测试尾部记录不完整时能恢复到最后一条完整记录
+func TestStoreTruncatedTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.gallery")
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("打开说话人库失败: %v", err)
+	}
+	store.Put("alice", speaker.NewEmbedding([]float32{1, 0}))
+	store.Put("bob", speaker.NewEmbedding([]float32{0, 1}))
+	store.Close()
+
+	// 模拟写入最后一条记录时崩溃
+	info, _ := os.Stat(path)
+	if err := os.Truncate(path, info.Size()-3); err != nil {
+		t.Fatalf("截断文件失败: %v", err)
+	}
+
+	store, err = Open(path)
+	if err != nil {
+		t.Fatalf("恢复说话人库失败: %v", err)
+	}
+	if store.Len() != 1 {
+		t.Fatalf("恢复后说话人数量应为1，实际为: %d", store.Len())
+	}
+	if _, ok := store.Get("alice"); !ok {
+		t.Fatal("恢复后应保留alice")
+	}
+
+	// 截断后应能继续追加
+	if err := store.Put("carol", speaker.NewEmbedding([]float32{1, 1})); err != nil {
+		t.Fatalf("恢复后写入失败: %v", err)
+	}
+	store.Close()
+
+	store, err = Open(path)
+	if err != nil {
+		t.Fatalf("重新打开说话人库失败: %v", err)
+	}
+	defer store.Close()
+	if store.Len() != 2 {
+		t.Fatalf("说话人数量应为2，实际为: %d", store.Len())
+	}
+}
+
+// TestStoreCompact 测试压缩后文件变小且内容不变
+func TestStoreCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.gallery")
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("打开说话人库失败: %v", err)
+	}
+	defer store.Close()
+
+	for i := 0; i < 100; i++ {
+		store.Put("alice", speaker.NewEmbedding([]float32{float32(i), 0}))
+	}
+	before, _ := os.Stat(path)
+	if err := store.Compact(); err != nil {
+		t.Fatalf("压缩失败: %v", err)
+	}
+	after, _ := os.Stat(path)
+	if after.Size() >= before.Size() {
+		t.Fatalf("压缩后文件应变小: %d -> %d", before.Size(), after.Size())
+	}
+
+	snapshot := store.Snapshot()
+	if len(snapshot) != 1 || snapshot[0].Embedding.GetData()[0] != 99 {
+		t.Fatalf("压缩后内容不正确: %+v", snapshot)
+	}
+
+	// 压缩后应能继续写入
+	if err := store.Put("bob", speaker.NewEmbedding([]float32{0, 1})); err != nil {
+		t.Fatalf("压缩后写入失败: %v", err)
+	}
+	if store.Len() != 2 {
+		t.Fatalf("说话人数量应为2，实际为: %d", store.Len())
+	}
+}
+
+// TestStoreCohort 测试冒认者集合的持久化
+func TestStoreCohort(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.gallery")
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("打开说话人库失败: %v", err)
+	}
+	store.Put("alice", speaker.NewEmbedding([]float32{1, 0}))
+	cohort := []*speaker.Embedding{
+		speaker.NewEmbedding([]float32{0, 1}),
+		speaker.NewEmbedding([]float32{1, 1}),
+		speaker.NewEmbedding([]float32{-1, 1}),
+	}
+	normalizer, err := speaker.NewScoreNormalizer(cohort, speaker.SNorm, 0)
+	if err != nil {
+		t.Fatalf("创建规整器失败: %v", err)
+	}
+	if err := store.SaveCohort(normalizer); err != nil {
+		t.Fatalf("保存冒认者集合失败: %v", err)
+	}
+	store.Close()
+
+	store, err = Open(path)
+	if err != nil {
+		t.Fatalf("重新打开说话人库失败: %v", err)
+	}
+	defer store.Close()
+
+	if store.Len() != 1 || len(store.Snapshot()) != 1 {
+		t.Fatalf("冒认者不应计入说话人: %d", store.Len())
+	}
+	loaded := store.Cohort()
+	if len(loaded) != len(cohort) {
+		t.Fatalf("冒认者数量不正确: %d", len(loaded))
+	}
+	if _, err := speaker.NewScoreNormalizer(loaded, speaker.SNorm, 0); err != nil {
+		t.Fatalf("使用加载的冒认者创建规整器失败: %v", err)
+	}
+
+	// 维度不一致时保存失败，原有的冒认者保持不变
+	mismatched, err := speaker.NewScoreNormalizer([]*speaker.Embedding{speaker.NewEmbedding([]float32{1, 0, 0}), speaker.NewEmbedding([]float32{0, 1, 0})}, speaker.SNorm, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveCohort(mismatched); !errors.Is(err, ErrDimensionMismatch) || len(store.Cohort()) != len(cohort) {
+		t.Fatalf("维度不一致时应保存失败并保留原有冒认者: %v", err)
+	}
+
+	// 再次保存时整体替换原有的冒认者
+	smaller, err := speaker.NewScoreNormalizer(cohort[:2], speaker.SNorm, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveCohort(smaller); err != nil {
+		t.Fatalf("替换冒认者集合失败: %v", err)
+	}
+	store.Close()
+	store, err = Open(path)
+	if err != nil {
+		t.Fatalf("重新打开说话人库失败: %v", err)
+	}
+	defer store.Close()
+	if len(store.Cohort()) != 2 || store.Len() != 1 {
+		t.Fatalf("冒认者应被替换为2个: %d", len(store.Cohort()))
+	}
+}
@@ -0,0 +1,134 @@
+package mat
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// 特征值相对下限，低于最大特征值该比例的方向视为退化方向
+const eigenFloor = 1e-10
+
+// Whitening 根据协方差矩阵计算白化矩阵W，使W*(x-mean)的协方差为单位矩阵
+// zca为false时为PCA白化（W=Λ^{-1/2}U^T，各维按方差从大到小排列），
+// 为true时为ZCA白化（W=UΛ^{-1/2}U^T，白化后的向量与原向量最接近）
+func Whitening(cov *Dense, zca bool) *Dense {
+	p := InvSqrt(cov, eigenFloor)
+	w := p.T()
+	if zca {
+		_, u := SymEigen(cov)
+		w = Mul(u, w)
+	}
+	return w
+}
+
+// SimultaneousDiag 对两个对称矩阵同时对角化，返回矩阵V和向量psi，
+// 满足V^T*w*V=I且V^T*b*V=diag(psi)，psi按从大到小排列
+// w须为正定矩阵，b为半正定矩阵
+func SimultaneousDiag(b, w *Dense) (*Dense, []float64) {
+	p := InvSqrt(w, eigenFloor)
+	bw := Mul(Mul(p.T(), b), p).Symmetrize()
+	psi, q := SymEigen(bw)
+	for i, v := range psi {
+		if v < 0 {
+			psi[i] = 0
+		}
+	}
+	return Mul(p, q), psi
+}
+
+// ScatterMatrices 根据类别标签计算类内协方差和类间协方差
+// 类内协方差为各样本相对所属类均值的协方差，类间协方差为各类均值相对总体均值的协方差（按类平均）
+func ScatterMatrices(x [][]float64, labels []int) (within, between *Dense, err error) {
+	if len(x) == 0 || len(x) != len(labels) {
+		return nil, nil, fmt.Errorf("样本数 %d 与标签数 %d 不一致", len(x), len(labels))
+	}
+	d := len(x[0])
+
+	numClasses := 0
+	for _, l := range labels {
+		if l < 0 {
+			return nil, nil, fmt.Errorf("类别标签不能为负数: %d", l)
+		}
+		if l+1 > numClasses {
+			numClasses = l + 1
+		}
+	}
+	sums := make([][]float64, numClasses)
+	counts := make([]int, numClasses)
+	for i, v := range x {
+		if len(v) != d {
+			return nil, nil, fmt.Errorf("第%d个样本维度为%d，应为%d", i, len(v), d)
+		}
+		l := labels[i]
+		if sums[l] == nil {
+			sums[l] = make([]float64, d)
+		}
+		for j, e := range v {
+			sums[l][j] += e
+		}
+		counts[l]++
+	}
+
+	var means [][]float64
+	for l, sum := range sums {
+		if counts[l] == 0 {
+			continue
+		}
+		for j := range sum {
+			sum[j] /= float64(counts[l])
+		}
+		means = append(means, sum)
+	}
+	if len(means) < 2 {
+		return nil, nil, errors.New("至少需要2个类别")
+	}
+
+	within = New(d, d)
+	diff := make([]float64, d)
+	for i, v := range x {
+		m := sums[labels[i]]
+		for j := range diff {
+			diff[j] = v[j] - m[j]
+		}
+		within.AddOuter(1, diff, diff)
+	}
+	within.Scale(1 / float64(len(x))).Symmetrize()
+	between = Covariance(means, Mean(means))
+	return within, between, nil
+}
+
+// FitLDA 训练线性判别分析投影矩阵，返回dim×d的矩阵P，y=P*(x-mean)即为降维后的向量
+// 投影方向按类间/类内方差比从大到小排列，类间协方差的秩至多为类别数-1，超出部分的方向不具判别性
+func FitLDA(x [][]float64, labels []int, dim int) (*Dense, error) {
+	within, between, err := ScatterMatrices(x, labels)
+	if err != nil {
+		return nil, err
+	}
+	d := within.Rows
+	if dim <= 0 || dim > d {
+		return nil, fmt.Errorf("LDA维度 %d 超出范围 [1,%d]", dim, d)
+	}
+
+	// 类内协方差加入少量对角正则，避免样本不足时奇异
+	var trace float64
+	for i := 0; i < d; i++ {
+		trace += within.At(i, i)
+	}
+	reg := 1e-6 * trace / float64(d)
+	if reg == 0 || math.IsNaN(reg) {
+		return nil, errors.New("类内协方差为0，无法训练LDA")
+	}
+	for i := 0; i < d; i++ {
+		within.Set(i, i, within.At(i, i)+reg)
+	}
+
+	v, _ := SimultaneousDiag(between, within)
+	p := New(dim, d)
+	for k := 0; k < dim; k++ {
+		for j := 0; j < d; j++ {
+			p.Set(k, j, v.At(j, k))
+		}
+	}
+	return p, nil
+}
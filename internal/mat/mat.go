@@ -0,0 +1,468 @@
+// Package mat 提供后端打分、向量变换和聚类所需的少量稠密矩阵运算
+package mat
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Dense 行优先存储的稠密矩阵
+type Dense struct {
+	Rows int       `json:"rows"`
+	Cols int       `json:"cols"`
+	Data []float64 `json:"data"`
+}
+
+// New 创建r行c列的零矩阵
+func New(r, c int) *Dense {
+	return &Dense{Rows: r, Cols: c, Data: make([]float64, r*c)}
+}
+
+// Identity 创建n阶单位矩阵
+func Identity(n int) *Dense {
+	m := New(n, n)
+	for i := 0; i < n; i++ {
+		m.Data[i*n+i] = 1
+	}
+	return m
+}
+
+// Diag 以向量为对角线创建对角矩阵
+func Diag(v []float64) *Dense {
+	m := New(len(v), len(v))
+	for i, x := range v {
+		m.Data[i*len(v)+i] = x
+	}
+	return m
+}
+
+// At 返回第i行第j列的元素
+func (m *Dense) At(i, j int) float64 {
+	return m.Data[i*m.Cols+j]
+}
+
+// Set 设置第i行第j列的元素
+func (m *Dense) Set(i, j int, v float64) {
+	m.Data[i*m.Cols+j] = v
+}
+
+// Row 返回第i行（共享底层数据）
+func (m *Dense) Row(i int) []float64 {
+	return m.Data[i*m.Cols : (i+1)*m.Cols]
+}
+
+// Col 返回第j列的副本
+func (m *Dense) Col(j int) []float64 {
+	col := make([]float64, m.Rows)
+	for i := range col {
+		col[i] = m.Data[i*m.Cols+j]
+	}
+	return col
+}
+
+// Clone 返回矩阵副本
+func (m *Dense) Clone() *Dense {
+	return &Dense{Rows: m.Rows, Cols: m.Cols, Data: append([]float64(nil), m.Data...)}
+}
+
+// T 返回转置矩阵
+func (m *Dense) T() *Dense {
+	t := New(m.Cols, m.Rows)
+	for i := 0; i < m.Rows; i++ {
+		for j := 0; j < m.Cols; j++ {
+			t.Data[j*m.Rows+i] = m.Data[i*m.Cols+j]
+		}
+	}
+	return t
+}
+
+// Mul 返回矩阵乘积a*b
+func Mul(a, b *Dense) *Dense {
+	if a.Cols != b.Rows {
+		panic(fmt.Sprintf("mat: 矩阵维度不匹配 %dx%d * %dx%d", a.Rows, a.Cols, b.Rows, b.Cols))
+	}
+	c := New(a.Rows, b.Cols)
+	for i := 0; i < a.Rows; i++ {
+		ci := c.Data[i*c.Cols : (i+1)*c.Cols]
+		for k := 0; k < a.Cols; k++ {
+			aik := a.Data[i*a.Cols+k]
+			if aik == 0 {
+				continue
+			}
+			bk := b.Data[k*b.Cols : (k+1)*b.Cols]
+			for j, v := range bk {
+				ci[j] += aik * v
+			}
+		}
+	}
+	return c
+}
+
+// MulVec 返回矩阵与向量的乘积m*x
+func (m *Dense) MulVec(x []float64) []float64 {
+	if len(x) != m.Cols {
+		panic(fmt.Sprintf("mat: 向量维度不匹配 %dx%d * %d", m.Rows, m.Cols, len(x)))
+	}
+	y := make([]float64, m.Rows)
+	for i := range y {
+		var sum float64
+		row := m.Data[i*m.Cols : (i+1)*m.Cols]
+		for j, v := range row {
+			sum += v * x[j]
+		}
+		y[i] = sum
+	}
+	return y
+}
+
+// Add 返回a+b
+func Add(a, b *Dense) *Dense {
+	c := a.Clone()
+	for i, v := range b.Data {
+		c.Data[i] += v
+	}
+	return c
+}
+
+// Scale 将矩阵所有元素乘以s
+func (m *Dense) Scale(s float64) *Dense {
+	for i := range m.Data {
+		m.Data[i] *= s
+	}
+	return m
+}
+
+// Symmetrize 用(m+m^T)/2替换方阵，消除数值误差导致的不对称
+func (m *Dense) Symmetrize() *Dense {
+	for i := 0; i < m.Rows; i++ {
+		for j := i + 1; j < m.Cols; j++ {
+			v := (m.Data[i*m.Cols+j] + m.Data[j*m.Cols+i]) / 2
+			m.Data[i*m.Cols+j] = v
+			m.Data[j*m.Cols+i] = v
+		}
+	}
+	return m
+}
+
+// AddOuter 将s*x*y^T累加到矩阵上
+func (m *Dense) AddOuter(s float64, x, y []float64) {
+	for i, xi := range x {
+		if xi == 0 {
+			continue
+		}
+		row := m.Data[i*m.Cols : (i+1)*m.Cols]
+		for j, yj := range y {
+			row[j] += s * xi * yj
+		}
+	}
+}
+
+// Mean 计算向量集合的均值
+func Mean(x [][]float64) []float64 {
+	mean := make([]float64, len(x[0]))
+	for _, v := range x {
+		for j, e := range v {
+			mean[j] += e
+		}
+	}
+	for j := range mean {
+		mean[j] /= float64(len(x))
+	}
+	return mean
+}
+
+// Covariance 计算向量集合关于给定均值的协方差矩阵（除以样本数）
+func Covariance(x [][]float64, mean []float64) *Dense {
+	d := len(mean)
+	cov := New(d, d)
+	diff := make([]float64, d)
+	for _, v := range x {
+		for j := range diff {
+			diff[j] = v[j] - mean[j]
+		}
+		cov.AddOuter(1, diff, diff)
+	}
+	return cov.Scale(1 / float64(len(x))).Symmetrize()
+}
+
+// SymEigen 计算实对称矩阵的特征分解，特征值按从大到小排列，特征向量为返回矩阵的各列
+// 采用Householder三对角化和隐式QL迭代
+func SymEigen(a *Dense) ([]float64, *Dense) {
+	if a.Rows != a.Cols {
+		panic(fmt.Sprintf("mat: 特征分解需要方阵，实际为 %dx%d", a.Rows, a.Cols))
+	}
+	n := a.Rows
+	v := make([][]float64, n)
+	for i := range v {
+		v[i] = append([]float64(nil), a.Row(i)...)
+	}
+	d := make([]float64, n)
+	e := make([]float64, n)
+	if n > 0 {
+		tred2(v, d, e)
+		tql2(v, d, e)
+	}
+
+	// 按特征值从大到小排序
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return d[order[i]] > d[order[j]] })
+
+	values := make([]float64, n)
+	vectors := New(n, n)
+	for k, idx := range order {
+		values[k] = d[idx]
+		for i := 0; i < n; i++ {
+			vectors.Data[i*n+k] = v[i][idx]
+		}
+	}
+	return values, vectors
+}
+
+// tred2 将对称矩阵Householder约化为三对角形式，v中累积变换矩阵
+func tred2(v [][]float64, d, e []float64) {
+	n := len(d)
+	for j := 0; j < n; j++ {
+		d[j] = v[n-1][j]
+	}
+
+	for i := n - 1; i > 0; i-- {
+		scale, h := 0.0, 0.0
+		for k := 0; k < i; k++ {
+			scale += math.Abs(d[k])
+		}
+		if scale == 0 {
+			e[i] = d[i-1]
+			for j := 0; j < i; j++ {
+				d[j] = v[i-1][j]
+				v[i][j] = 0
+				v[j][i] = 0
+			}
+		} else {
+			for k := 0; k < i; k++ {
+				d[k] /= scale
+				h += d[k] * d[k]
+			}
+			f := d[i-1]
+			g := math.Sqrt(h)
+			if f > 0 {
+				g = -g
+			}
+			e[i] = scale * g
+			h -= f * g
+			d[i-1] = f - g
+			for j := 0; j < i; j++ {
+				e[j] = 0
+			}
+
+			for j := 0; j < i; j++ {
+				f = d[j]
+				v[j][i] = f
+				g = e[j] + v[j][j]*f
+				for k := j + 1; k <= i-1; k++ {
+					g += v[k][j] * d[k]
+					e[k] += v[k][j] * f
+				}
+				e[j] = g
+			}
+			f = 0
+			for j := 0; j < i; j++ {
+				e[j] /= h
+				f += e[j] * d[j]
+			}
+			hh := f / (h + h)
+			for j := 0; j < i; j++ {
+				e[j] -= hh * d[j]
+			}
+			for j := 0; j < i; j++ {
+				f = d[j]
+				g = e[j]
+				for k := j; k <= i-1; k++ {
+					v[k][j] -= f*e[k] + g*d[k]
+				}
+				d[j] = v[i-1][j]
+				v[i][j] = 0
+			}
+		}
+		d[i] = h
+	}
+
+	// 累积变换
+	for i := 0; i < n-1; i++ {
+		v[n-1][i] = v[i][i]
+		v[i][i] = 1
+		h := d[i+1]
+		if h != 0 {
+			for k := 0; k <= i; k++ {
+				d[k] = v[k][i+1] / h
+			}
+			for j := 0; j <= i; j++ {
+				g := 0.0
+				for k := 0; k <= i; k++ {
+					g += v[k][i+1] * v[k][j]
+				}
+				for k := 0; k <= i; k++ {
+					v[k][j] -= g * d[k]
+				}
+			}
+		}
+		for k := 0; k <= i; k++ {
+			v[k][i+1] = 0
+		}
+	}
+	for j := 0; j < n; j++ {
+		d[j] = v[n-1][j]
+		v[n-1][j] = 0
+	}
+	v[n-1][n-1] = 1
+	e[0] = 0
+}
+
+// tql2 对三对角矩阵进行隐式QL迭代，求出特征值和特征向量
+func tql2(v [][]float64, d, e []float64) {
+	n := len(d)
+	for i := 1; i < n; i++ {
+		e[i-1] = e[i]
+	}
+	e[n-1] = 0
+
+	f, tst1 := 0.0, 0.0
+	eps := math.Pow(2, -52)
+	for l := 0; l < n; l++ {
+		// 寻找足够小的次对角元素
+		tst1 = math.Max(tst1, math.Abs(d[l])+math.Abs(e[l]))
+		m := l
+		for m < n {
+			if math.Abs(e[m]) <= eps*tst1 {
+				break
+			}
+			m++
+		}
+		if m == n {
+			m = n - 1
+		}
+
+		if m > l {
+			for iter := 0; iter < 100; iter++ {
+				g := d[l]
+				p := (d[l+1] - g) / (2 * e[l])
+				r := math.Hypot(p, 1)
+				if p < 0 {
+					r = -r
+				}
+				d[l] = e[l] / (p + r)
+				d[l+1] = e[l] * (p + r)
+				dl1 := d[l+1]
+				h := g - d[l]
+				for i := l + 2; i < n; i++ {
+					d[i] -= h
+				}
+				f += h
+
+				p = d[m]
+				c, c2, c3 := 1.0, 1.0, 1.0
+				el1 := e[l+1]
+				s, s2 := 0.0, 0.0
+				for i := m - 1; i >= l; i-- {
+					c3 = c2
+					c2 = c
+					s2 = s
+					g = c * e[i]
+					h = c * p
+					r = math.Hypot(p, e[i])
+					e[i+1] = s * r
+					s = e[i] / r
+					c = p / r
+					p = c*d[i] - s*g
+					d[i+1] = h + s*(c*g+s*d[i])
+					for k := 0; k < n; k++ {
+						h = v[k][i+1]
+						v[k][i+1] = s*v[k][i] + c*h
+						v[k][i] = c*v[k][i] - s*h
+					}
+				}
+				p = -s * s2 * c3 * el1 * e[l] / dl1
+				e[l] = s * p
+				d[l] = c * p
+				if math.Abs(e[l]) <= eps*tst1 {
+					break
+				}
+			}
+		}
+		d[l] += f
+		e[l] = 0
+	}
+}
+
+// InvSqrt 返回对称半正定矩阵的逆平方根矩阵P=U*diag(1/sqrt(λ))（满足P^T*A*P=I），
+// 小于floor*最大特征值的特征值会被截断为该下限，避免数值不稳定
+func InvSqrt(a *Dense, floor float64) *Dense {
+	values, vectors := SymEigen(a)
+	minValue := floor * math.Max(values[0], 0)
+	if minValue <= 0 {
+		minValue = 1e-12
+	}
+	p := vectors.Clone()
+	for j, lambda := range values {
+		s := 1 / math.Sqrt(math.Max(lambda, minValue))
+		for i := 0; i < p.Rows; i++ {
+			p.Data[i*p.Cols+j] *= s
+		}
+	}
+	return p
+}
+
+// Inverse 使用带部分主元的高斯-约当消元法求逆矩阵
+func Inverse(a *Dense) (*Dense, error) {
+	if a.Rows != a.Cols {
+		return nil, fmt.Errorf("mat: 求逆需要方阵，实际为 %dx%d", a.Rows, a.Cols)
+	}
+	n := a.Rows
+	m := a.Clone()
+	inv := Identity(n)
+	for col := 0; col < n; col++ {
+		pivot := col
+		for r := col + 1; r < n; r++ {
+			if math.Abs(m.At(r, col)) > math.Abs(m.At(pivot, col)) {
+				pivot = r
+			}
+		}
+		if math.Abs(m.At(pivot, col)) < 1e-300 {
+			return nil, fmt.Errorf("mat: 矩阵奇异")
+		}
+		if pivot != col {
+			swapRows(m, pivot, col)
+			swapRows(inv, pivot, col)
+		}
+		s := 1 / m.At(col, col)
+		for j := 0; j < n; j++ {
+			m.Data[col*n+j] *= s
+			inv.Data[col*n+j] *= s
+		}
+		for r := 0; r < n; r++ {
+			if r == col {
+				continue
+			}
+			f := m.At(r, col)
+			if f == 0 {
+				continue
+			}
+			for j := 0; j < n; j++ {
+				m.Data[r*n+j] -= f * m.Data[col*n+j]
+				inv.Data[r*n+j] -= f * inv.Data[col*n+j]
+			}
+		}
+	}
+	return inv, nil
+}
+
+// swapRows 交换矩阵的两行
+func swapRows(m *Dense, i, j int) {
+	ri, rj := m.Row(i), m.Row(j)
+	for k := range ri {
+		ri[k], rj[k] = rj[k], ri[k]
+	}
+}
@@ -0,0 +1,88 @@
+package mat
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// randomSPD 生成随机对称正定矩阵
+func randomSPD(rng *rand.Rand, n int) *Dense {
+	a := New(n, n)
+	for i := range a.Data {
+		a.Data[i] = rng.NormFloat64()
+	}
+	return Add(Mul(a, a.T()), Identity(n).Scale(0.1))
+}
+
+// TestSymEigen 测试特征分解能重建原矩阵且特征向量正交
+func TestSymEigen(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range []int{1, 2, 5, 40} {
+		a := randomSPD(rng, n)
+		values, vectors := SymEigen(a)
+		for i := 1; i < n; i++ {
+			if values[i] > values[i-1] {
+				t.Fatalf("n=%d 特征值未按降序排列: %v", n, values)
+			}
+		}
+		recon := Mul(Mul(vectors, Diag(values)), vectors.T())
+		orth := Mul(vectors.T(), vectors)
+		id := Identity(n)
+		for i := range a.Data {
+			if math.Abs(recon.Data[i]-a.Data[i]) > 1e-8*float64(n) {
+				t.Fatalf("n=%d 重建误差过大: %v vs %v", n, recon.Data[i], a.Data[i])
+			}
+			if math.Abs(orth.Data[i]-id.Data[i]) > 1e-10*float64(n) {
+				t.Fatalf("n=%d 特征向量不正交", n)
+			}
+		}
+	}
+}
+
+// TestInverseAndDiag 测试求逆和同时对角化
+func TestInverseAndDiag(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	n := 8
+	a := randomSPD(rng, n)
+	inv, err := Inverse(a)
+	if err != nil {
+		t.Fatalf("求逆失败: %v", err)
+	}
+	prod := Mul(a, inv)
+	id := Identity(n)
+	for i := range prod.Data {
+		if math.Abs(prod.Data[i]-id.Data[i]) > 1e-9 {
+			t.Fatalf("A*A^-1不为单位矩阵: %v", prod.Data[i])
+		}
+	}
+	if _, err := Inverse(New(2, 2)); err == nil {
+		t.Fatal("奇异矩阵求逆应返回错误")
+	}
+
+	b := randomSPD(rng, n)
+	v, psi := SimultaneousDiag(b, a)
+	vwv := Mul(Mul(v.T(), a), v)
+	vbv := Mul(Mul(v.T(), b), v)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			wantW, wantB := 0.0, 0.0
+			if i == j {
+				wantW, wantB = 1, psi[i]
+			}
+			if math.Abs(vwv.At(i, j)-wantW) > 1e-8 || math.Abs(vbv.At(i, j)-wantB) > 1e-8 {
+				t.Fatalf("同时对角化结果不正确: (%d,%d) %v %v", i, j, vwv.At(i, j), vbv.At(i, j))
+			}
+		}
+	}
+
+	for _, zca := range []bool{false, true} {
+		w := Whitening(a, zca)
+		cov := Mul(Mul(w, a), w.T())
+		for i := range cov.Data {
+			if math.Abs(cov.Data[i]-id.Data[i]) > 1e-8 {
+				t.Fatalf("zca=%v 白化后协方差不为单位矩阵", zca)
+			}
+		}
+	}
+}
@@ -0,0 +1,18 @@
+// Package testaudio 生成服务测试使用的合成音频
+package testaudio
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// PCMBytes 生成一段freq赫兹正弦波的16kHz小端int16原始PCM
+func PCMBytes(seconds float64, freq float64) []byte {
+	n := int(seconds * 16000)
+	data := make([]byte, 2*n)
+	for i := 0; i < n; i++ {
+		v := int16(8000 * math.Sin(2*math.Pi*freq*float64(i)/16000))
+		binary.LittleEndian.PutUint16(data[2*i:], uint16(v))
+	}
+	return data
+}
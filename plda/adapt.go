@@ -0,0 +1,89 @@
+package plda
+
+import (
+	"errors"
+	"math"
+
+	"github.com/seastart/3dspeaker-onnx-go/internal/mat"
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// AdaptConfig 无监督域自适应配置
+type AdaptConfig struct {
+	MeanDiffScale float64 // 域内均值偏移计入域内方差的比例
+	WithinScale   float64 // 超出模型的域内方差分配给说话人内协方差的比例
+	BetweenScale  float64 // 超出模型的域内方差分配给说话人间协方差的比例
+}
+
+// DefaultAdaptConfig 返回默认自适应配置（与Kaldi的ivector-adapt-plda一致）
+func DefaultAdaptConfig() AdaptConfig {
+	return AdaptConfig{MeanDiffScale: 1, WithinScale: 0.3, BetweenScale: 0.7}
+}
+
+// Adapt 使用无标签的域内嵌入向量对模型做无监督自适应
+//
+// 模型均值替换为域内均值；在域内数据方差超过模型总方差的方向上，
+// 将多出的方差按比例分配给说话人内和说话人间协方差。
+// 训练数据与部署信道不一致（例如训练用网络视频、部署用电话）时，一般能明显降低错误率
+//
+// 参数:
+//   - embeddings: 域内嵌入向量，无需说话人标签，一般数百条以上
+//   - cfg: 自适应配置
+//
+// 返回:
+//   - 可能的错误
+func (p *PLDA) Adapt(embeddings []*speaker.Embedding, cfg AdaptConfig) error {
+	if len(embeddings) < 2 {
+		return errors.New("域内嵌入向量至少需要2个")
+	}
+	if cfg.MeanDiffScale < 0 || cfg.WithinScale < 0 || cfg.BetweenScale < 0 {
+		return errors.New("自适应比例不能为负数")
+	}
+	y := make([][]float64, len(embeddings))
+	for i, emb := range embeddings {
+		var err error
+		if y[i], err = p.preprocess(emb); err != nil {
+			return err
+		}
+	}
+
+	// 域内均值和方差，均值偏移按比例计入方差
+	mean := mat.Mean(y)
+	variance := mat.Covariance(y, mean)
+	diff := sub(mean, p.mu)
+	variance.AddOuter(cfg.MeanDiffScale, diff, diff)
+	p.mu = mean
+
+	// 变换到对角化空间，此时说话人内协方差为I、说话人间协方差为diag(psi)，
+	// 再按模型总方差I+diag(psi)缩放，特征值大于1的方向即域内方差超出模型的方向
+	k := len(p.psi)
+	projected := mat.Mul(mat.Mul(p.transform, variance), p.transform.T())
+	scale := make([]float64, k)
+	for d, psi := range p.psi {
+		scale[d] = 1 / math.Sqrt(1+psi)
+	}
+	scaled := mat.Mul(mat.Mul(mat.Diag(scale), projected), mat.Diag(scale)).Symmetrize()
+	values, vectors := mat.SymEigen(scaled)
+
+	withinP := mat.Identity(k)
+	betweenP := mat.Diag(p.psi)
+	for i, lambda := range values {
+		if lambda <= 1 {
+			break
+		}
+		excess := lambda - 1
+		v := vectors.Col(i)
+		for d := range v {
+			v[d] /= scale[d]
+		}
+		withinP.AddOuter(cfg.WithinScale*excess, v, v)
+		betweenP.AddOuter(cfg.BetweenScale*excess, v, v)
+	}
+
+	// 变换回原空间
+	a := mat.Mul(p.within, p.transform.T())
+	p.within = mat.Mul(mat.Mul(a, withinP), a.T()).Symmetrize()
+	p.between = mat.Mul(mat.Mul(a, betweenP), a.T()).Symmetrize()
+	p.update()
+	return nil
+}
@@ -0,0 +1,99 @@
+package plda
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/seastart/3dspeaker-onnx-go/internal/mat"
+)
+
+// 模型文件格式版本
+const formatVersion = 1
+
+// modelFile PLDA模型的JSON文件格式
+type modelFile struct {
+	Version     int        `json:"version"`
+	Fingerprint string     `json:"fingerprint,omitempty"`
+	Mean        []float64  `json:"mean"`
+	Projection  *mat.Dense `json:"projection"`
+	PLDAMean    []float64  `json:"plda_mean"`
+	Between     *mat.Dense `json:"between"`
+	Within      *mat.Dense `json:"within"`
+}
+
+// Save 将模型保存为JSON文件
+func (p *PLDA) Save(path string) error {
+	f := modelFile{
+		Version:     formatVersion,
+		Fingerprint: p.fingerprint,
+		Mean:        p.mean,
+		Projection:  p.proj,
+		PLDAMean:    p.mu,
+		Between:     p.between,
+		Within:      p.within,
+	}
+	data, err := json.Marshal(&f)
+	if err != nil {
+		return fmt.Errorf("序列化PLDA模型失败: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("写入PLDA模型失败: %w", err)
+	}
+	return nil
+}
+
+// Load 从JSON文件加载模型
+func Load(path string) (*PLDA, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取PLDA模型失败: %w", err)
+	}
+	var f modelFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("解析PLDA模型失败: %w", err)
+	}
+	if f.Version != formatVersion {
+		return nil, fmt.Errorf("不支持的PLDA模型版本: %d", f.Version)
+	}
+	if err := f.validate(); err != nil {
+		return nil, fmt.Errorf("PLDA模型无效: %w", err)
+	}
+
+	p := &PLDA{
+		dim:         len(f.Mean),
+		mean:        f.Mean,
+		proj:        f.Projection,
+		mu:          f.PLDAMean,
+		between:     f.Between,
+		within:      f.Within,
+		fingerprint: f.Fingerprint,
+	}
+	p.update()
+	return p, nil
+}
+
+// validate 校验模型各部分的维度是否一致
+func (f *modelFile) validate() error {
+	if len(f.Mean) == 0 || f.Projection == nil || f.Between == nil || f.Within == nil {
+		return errors.New("缺少模型参数")
+	}
+	k := len(f.PLDAMean)
+	if k == 0 || k > len(f.Mean) {
+		return fmt.Errorf("潜在空间维度 %d 必须在1到输入维度 %d 之间", k, len(f.Mean))
+	}
+	if f.Projection.Cols != len(f.Mean) || f.Projection.Rows != k {
+		return fmt.Errorf("投影矩阵维度 %dx%d 不正确", f.Projection.Rows, f.Projection.Cols)
+	}
+	for _, m := range []*mat.Dense{f.Projection, f.Between, f.Within} {
+		if m.Rows <= 0 || m.Cols <= 0 || len(m.Data) != m.Rows*m.Cols {
+			return errors.New("矩阵数据长度不正确")
+		}
+	}
+
+	if f.Between.Rows != k || f.Between.Cols != k || f.Within.Rows != k || f.Within.Cols != k {
+		return errors.New("协方差矩阵维度不正确")
+	}
+	return nil
+}
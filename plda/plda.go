@@ -0,0 +1,323 @@
+// Package plda 实现说话人嵌入向量的双协方差PLDA（概率线性判别分析）后端
+//
+// 嵌入向量先经过中心化、可选的LDA降维、白化和长度规整，再用双协方差模型建模：
+// 说话人变量y~N(μ,B)，同一说话人的各条嵌入x=y+ε，ε~N(0,W)。
+// 打分输出同一人假设与不同人假设的对数似然比，实现了speaker.Scorer接口，
+// 可通过Speaker.SetScorer替代余弦打分。跨信道场景下可用Adapt以无标签的域内数据自适应
+package plda
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/seastart/3dspeaker-onnx-go/internal/mat"
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// Config PLDA训练配置
+type Config struct {
+	LDADim     int // LDA降维后的维度，0表示不做LDA；不能超过说话人数-1
+	Iterations int // EM迭代次数
+}
+
+// DefaultConfig 返回默认训练配置：不做LDA，EM迭代10次
+func DefaultConfig() Config {
+	return Config{Iterations: 10}
+}
+
+// PLDA 训练好的PLDA模型
+type PLDA struct {
+	dim  int        // 输入嵌入向量维度
+	mean []float64  // 输入空间均值
+	proj *mat.Dense // 预处理投影（LDA+白化），k×dim
+
+	mu      []float64  // PLDA空间均值
+	between *mat.Dense // 说话人间协方差B
+	within  *mat.Dense // 说话人内协方差W
+
+	// 由B和W同时对角化得到：transform*W*transform^T=I，transform*B*transform^T=diag(psi)
+	transform *mat.Dense
+	psi       []float64
+
+	fingerprint string
+}
+
+// Train 使用带说话人标签的嵌入向量训练PLDA模型
+//
+// 参数:
+//   - embeddings: 训练嵌入向量，应与实际使用时来自同一模型
+//   - labels: 与embeddings一一对应的说话人标签
+//   - cfg: 训练配置
+//
+// 返回:
+//   - PLDA模型和可能的错误
+func Train(embeddings []*speaker.Embedding, labels []string, cfg Config) (*PLDA, error) {
+	if len(embeddings) != len(labels) {
+		return nil, fmt.Errorf("嵌入向量数 %d 与标签数 %d 不一致", len(embeddings), len(labels))
+	}
+	x, err := toFloat64(embeddings)
+	if err != nil {
+		return nil, err
+	}
+	dim := len(x[0])
+
+	// 将说话人标签映射为从0开始的编号
+	ids := make([]int, len(labels))
+	index := make(map[string]int)
+	for i, label := range labels {
+		id, ok := index[label]
+		if !ok {
+			id = len(index)
+			index[label] = id
+		}
+		ids[i] = id
+	}
+	numSpeakers := len(index)
+	if numSpeakers < 2 {
+		return nil, errors.New("至少需要2个说话人")
+	}
+	if len(x) <= numSpeakers {
+		return nil, errors.New("至少需要一个说话人有2条以上的嵌入向量，才能估计说话人内协方差")
+	}
+	if cfg.LDADim < 0 || cfg.LDADim > dim {
+		return nil, fmt.Errorf("LDA维度 %d 超出范围 [0,%d]", cfg.LDADim, dim)
+	}
+	if cfg.LDADim >= numSpeakers {
+		return nil, fmt.Errorf("LDA维度 %d 不能超过说话人数-1(%d)", cfg.LDADim, numSpeakers-1)
+	}
+
+	p := &PLDA{dim: dim, mean: mat.Mean(x)}
+	centered := make([][]float64, len(x))
+	for i, v := range x {
+		centered[i] = sub(v, p.mean)
+	}
+
+	// 预处理投影：LDA降维后再白化
+	proj := mat.Identity(dim)
+	if cfg.LDADim > 0 {
+		proj, err = mat.FitLDA(centered, ids, cfg.LDADim)
+		if err != nil {
+			return nil, fmt.Errorf("训练LDA失败: %w", err)
+		}
+	}
+	y := make([][]float64, len(x))
+	for i, v := range centered {
+		y[i] = proj.MulVec(v)
+	}
+	p.proj = mat.Mul(mat.Whitening(mat.Covariance(y, mat.Mean(y)), false), proj)
+	for i, v := range centered {
+		y[i] = lengthNorm(p.proj.MulVec(v))
+	}
+
+	// 以矩估计初始化，再用EM迭代
+	p.mu = mat.Mean(y)
+	p.within, p.between, err = mat.ScatterMatrices(y, ids)
+	if err != nil {
+		return nil, err
+	}
+	p.update()
+
+	groups := make([][]int, numSpeakers)
+	for i, id := range ids {
+		groups[id] = append(groups[id], i)
+	}
+	for iter := 0; iter < cfg.Iterations; iter++ {
+		p.emStep(y, groups)
+	}
+	return p, nil
+}
+
+// emStep 执行一次EM迭代
+// 在同时对角化的空间中，说话人变量的后验协方差为对角阵，E步无需矩阵求逆
+func (p *PLDA) emStep(y [][]float64, groups [][]int) {
+	k := len(p.mu)
+	sumEy := make([]float64, k)
+	sumEyy := mat.New(k, k)
+	withinAcc := mat.New(k, k)
+	numGroups, numSamples := 0, 0
+
+	for _, group := range groups {
+		if len(group) == 0 {
+			continue
+		}
+		n := float64(len(group))
+		u := make([][]float64, len(group))
+		f := make([]float64, k)
+		for i, idx := range group {
+			u[i] = p.transform.MulVec(sub(y[idx], p.mu))
+			for d, v := range u[i] {
+				f[d] += v
+			}
+		}
+
+		// 说话人变量的后验均值和（对角）协方差
+		ey := make([]float64, k)
+		variance := make([]float64, k)
+		for d, psi := range p.psi {
+			variance[d] = psi / (1 + n*psi)
+			ey[d] = variance[d] * f[d]
+		}
+
+		for d := range ey {
+			sumEy[d] += ey[d]
+		}
+		sumEyy.AddOuter(1, ey, ey)
+		for _, ui := range u {
+			diff := sub(ui, ey)
+			withinAcc.AddOuter(1, diff, diff)
+		}
+		for d, v := range variance {
+			sumEyy.Set(d, d, sumEyy.At(d, d)+v)
+			withinAcc.Set(d, d, withinAcc.At(d, d)+n*v)
+		}
+		numGroups++
+		numSamples += len(group)
+	}
+
+	// M步：在对角化空间中更新参数
+	muP := make([]float64, k)
+	for d, v := range sumEy {
+		muP[d] = v / float64(numGroups)
+	}
+	betweenP := sumEyy.Scale(1 / float64(numGroups))
+	betweenP.AddOuter(-1, muP, muP)
+	withinP := withinAcc.Scale(1 / float64(numSamples))
+
+	// 变换回原空间，transform的逆为W*transform^T
+	a := mat.Mul(p.within, p.transform.T())
+	shift := a.MulVec(muP)
+	for d := range p.mu {
+		p.mu[d] += shift[d]
+	}
+	p.between = mat.Mul(mat.Mul(a, betweenP), a.T()).Symmetrize()
+	p.within = mat.Mul(mat.Mul(a, withinP), a.T()).Symmetrize()
+	p.update()
+}
+
+// update 根据当前的B和W重新计算同时对角化变换
+func (p *PLDA) update() {
+	v, psi := mat.SimultaneousDiag(p.between, p.within)
+	p.transform = v.T()
+	p.psi = psi
+}
+
+// Dim 返回输入嵌入向量维度
+func (p *PLDA) Dim() int {
+	return p.dim
+}
+
+// OutputDim 返回预处理（LDA降维）后的维度
+func (p *PLDA) OutputDim() int {
+	return p.proj.Rows
+}
+
+// Fingerprint 返回训练数据所用模型的指纹，未设置时为空
+func (p *PLDA) Fingerprint() string {
+	return p.fingerprint
+}
+
+// SetFingerprint 记录训练数据所用模型的指纹，随模型一同保存，
+// 加载时可据此校验PLDA是否与当前嵌入模型匹配
+func (p *PLDA) SetFingerprint(fingerprint string) {
+	p.fingerprint = fingerprint
+}
+
+// Score 计算注册向量与测试向量的对数似然比，实现speaker.Scorer接口
+// 分数大于0表示同一人假设更可能，以自然对数为单位
+func (p *PLDA) Score(enroll, test *speaker.Embedding) (float32, error) {
+	u1, err := p.latent(enroll)
+	if err != nil {
+		return 0, err
+	}
+	u2, err := p.latent(test)
+	if err != nil {
+		return 0, err
+	}
+
+	// 对角化空间中各维相互独立，说话人内方差为1、说话人间方差为psi
+	var llr float64
+	for d, psi := range p.psi {
+		a := psi + 1
+		det := 2*psi + 1
+		sq := u1[d]*u1[d] + u2[d]*u2[d]
+		llr += -0.5*math.Log(det) + math.Log(a) -
+			0.5*(a*sq-2*psi*u1[d]*u2[d])/det + 0.5*sq/a
+	}
+	return float32(llr), nil
+}
+
+// latent 将嵌入向量变换到同时对角化的PLDA空间
+func (p *PLDA) latent(emb *speaker.Embedding) ([]float64, error) {
+	y, err := p.preprocess(emb)
+	if err != nil {
+		return nil, err
+	}
+	return p.transform.MulVec(sub(y, p.mu)), nil
+}
+
+// preprocess 对嵌入向量做中心化、LDA、白化和长度规整
+func (p *PLDA) preprocess(emb *speaker.Embedding) ([]float64, error) {
+	if emb == nil {
+		return nil, errors.New("嵌入向量为空")
+	}
+	data := emb.GetData()
+	if len(data) != p.dim {
+		return nil, fmt.Errorf("嵌入向量维度 %d 与PLDA模型维度 %d 不一致", len(data), p.dim)
+	}
+	x := make([]float64, p.dim)
+	for i, v := range data {
+		x[i] = float64(v) - p.mean[i]
+	}
+	return lengthNorm(p.proj.MulVec(x)), nil
+}
+
+// toFloat64 将嵌入向量集合转换为float64切片并校验维度一致
+func toFloat64(embeddings []*speaker.Embedding) ([][]float64, error) {
+	if len(embeddings) == 0 {
+		return nil, errors.New("嵌入向量集合为空")
+	}
+	x := make([][]float64, len(embeddings))
+	for i, emb := range embeddings {
+		if emb == nil {
+			return nil, fmt.Errorf("第%d个嵌入向量为空", i)
+		}
+		data := emb.GetData()
+		if i > 0 && len(data) != len(x[0]) {
+			return nil, fmt.Errorf("第%d个嵌入向量维度为%d，应为%d", i, len(data), len(x[0]))
+		}
+		if len(data) == 0 {
+			return nil, fmt.Errorf("第%d个嵌入向量数据为空", i)
+		}
+		x[i] = make([]float64, len(data))
+		for j, v := range data {
+			x[i][j] = float64(v)
+		}
+	}
+	return x, nil
+}
+
+// lengthNorm 将白化后的向量缩放到长度sqrt(维度)，使其与高斯假设更吻合
+func lengthNorm(v []float64) []float64 {
+	var sum float64
+	for _, e := range v {
+		sum += e * e
+	}
+	if sum == 0 {
+		return v
+	}
+	scale := math.Sqrt(float64(len(v)) / sum)
+	for i := range v {
+		v[i] *= scale
+	}
+	return v
+}
+
+// sub 返回a-b
+func sub(a, b []float64) []float64 {
+	c := make([]float64, len(a))
+	for i := range a {
+		c[i] = a[i] - b[i]
+	}
+	return c
+}
@@ -0,0 +1,143 @@
+package plda
+
+import (
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/seastart/3dspeaker-onnx-go/eval"
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// synthData 生成模拟数据：说话人均值在前半部分维度上分散，说话人内噪声在后半部分维度上较大（模拟信道干扰），
+// offset和extra模拟另一信道的均值偏移和额外方差
+func synthData(rng *rand.Rand, speakers, perSpeaker, dim int, offset, extra float64) ([]*speaker.Embedding, []string) {
+	var embeddings []*speaker.Embedding
+	var labels []string
+	for s := 0; s < speakers; s++ {
+		center := make([]float64, dim)
+		for d := 0; d < dim/2; d++ {
+			center[d] = rng.NormFloat64()
+		}
+		for i := 0; i < perSpeaker; i++ {
+			data := make([]float32, dim)
+			channel := extra * rng.NormFloat64()
+			for d := range data {
+				noise := 0.3
+				if d >= dim/2 {
+					noise = 1.5
+				}
+				data[d] = float32(center[d] + noise*rng.NormFloat64() + offset)
+			}
+			data[dim-1] += float32(channel)
+			embeddings = append(embeddings, speaker.NewEmbedding(data))
+			labels = append(labels, string(rune('A'+s%26))+string(rune('a'+s/26)))
+		}
+	}
+	return embeddings, labels
+}
+
+// trialEER 对所有两两组合打分并计算EER
+func trialEER(t *testing.T, scorer speaker.Scorer, embeddings []*speaker.Embedding, labels []string) float64 {
+	var targets, nonTargets []float64
+	for i := range embeddings {
+		for j := i + 1; j < len(embeddings); j++ {
+			score, err := scorer.Score(embeddings[i], embeddings[j])
+			if err != nil {
+				t.Fatalf("打分失败: %v", err)
+			}
+			if labels[i] == labels[j] {
+				targets = append(targets, float64(score))
+			} else {
+				nonTargets = append(nonTargets, float64(score))
+			}
+		}
+	}
+	eer, _ := eval.EER(targets, nonTargets)
+	return eer
+}
+
+// TestTrainAndScore 测试PLDA在信道干扰下优于余弦打分，且保存加载后分数不变
+func TestTrainAndScore(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	dim := 24
+	train, trainLabels := synthData(rng, 200, 8, dim, 0, 0)
+	test, testLabels := synthData(rng, 30, 4, dim, 0, 0)
+
+	cosineEER := trialEER(t, speaker.CosineScorer{}, test, testLabels)
+	for _, ldaDim := range []int{0, 16} {
+		cfg := DefaultConfig()
+		cfg.LDADim = ldaDim
+		model, err := Train(train, trainLabels, cfg)
+		if err != nil {
+			t.Fatalf("训练失败: %v", err)
+		}
+		pldaEER := trialEER(t, model, test, testLabels)
+		if pldaEER >= cosineEER {
+			t.Fatalf("LDA维度%d: PLDA的EER %.4f 应低于余弦的 %.4f", ldaDim, pldaEER, cosineEER)
+		}
+
+		path := filepath.Join(t.TempDir(), "plda.json")
+		model.SetFingerprint("fp")
+		if err := model.Save(path); err != nil {
+			t.Fatalf("保存失败: %v", err)
+		}
+		loaded, err := Load(path)
+		if err != nil {
+			t.Fatalf("加载失败: %v", err)
+		}
+		if loaded.Fingerprint() != "fp" || loaded.OutputDim() != model.OutputDim() {
+			t.Fatalf("加载的模型信息不一致")
+		}
+		s1, _ := model.Score(test[0], test[1])
+		s2, _ := loaded.Score(test[0], test[1])
+		if math.Abs(float64(s1-s2)) > 1e-4 {
+			t.Fatalf("保存加载后分数不一致: %v vs %v", s1, s2)
+		}
+	}
+
+	if _, err := Train(train, trainLabels, Config{LDADim: 200}); err == nil {
+		t.Fatal("LDA维度超过说话人数-1时应返回错误")
+	}
+	if _, err := Train(train[:2], []string{"a", "b"}, DefaultConfig()); err == nil {
+		t.Fatal("每个说话人只有一条数据时应返回错误")
+	}
+
+	// 潜在空间维度为0或矩阵维度为负时加载返回错误，而不是在对角化时panic
+	for name, content := range map[string]string{
+		"潜在空间维度为0": `{"version":1,"mean":[0,0],"projection":{"rows":0,"cols":2,"data":[]},"plda_mean":[],"between":{"rows":0,"cols":0,"data":[]},"within":{"rows":0,"cols":0,"data":[]}}`,
+		"矩阵维度为负":   `{"version":1,"mean":[0],"projection":{"rows":1,"cols":1,"data":[1]},"plda_mean":[0],"between":{"rows":-1,"cols":-1,"data":[1]},"within":{"rows":1,"cols":1,"data":[1]}}`,
+	} {
+		path := filepath.Join(t.TempDir(), "plda.json")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Fatalf("%s时加载应返回错误", name)
+		}
+	}
+}
+
+// TestAdapt 测试域内自适应能降低跨信道数据上的错误率
+func TestAdapt(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	dim := 24
+	train, trainLabels := synthData(rng, 200, 8, dim, 0, 0)
+	inDomain, _ := synthData(rng, 200, 4, dim, 0.5, 6)
+	test, testLabels := synthData(rng, 30, 4, dim, 0.5, 6)
+
+	model, err := Train(train, trainLabels, DefaultConfig())
+	if err != nil {
+		t.Fatalf("训练失败: %v", err)
+	}
+	before := trialEER(t, model, test, testLabels)
+	if err := model.Adapt(inDomain, DefaultAdaptConfig()); err != nil {
+		t.Fatalf("自适应失败: %v", err)
+	}
+	after := trialEER(t, model, test, testLabels)
+	if after >= before {
+		t.Fatalf("自适应后EER %.4f 应低于自适应前 %.4f", after, before)
+	}
+}
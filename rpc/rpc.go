@@ -0,0 +1,430 @@
+// Package rpc 提供说话人识别的gRPC服务（接口定义见speakerpb/speaker.proto），基于Speaker会话池和说话人库
+//
+// Embed、Verify、Identify每次调用借用一个会话；Stream在整个流的生命周期内占用一个会话，
+// 同时进行的流和请求数超过会话池大小时排队等待
+package rpc
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/seastart/3dspeaker-onnx-go/audio"
+	"github.com/seastart/3dspeaker-onnx-go/diarize"
+	"github.com/seastart/3dspeaker-onnx-go/gallery"
+	"github.com/seastart/3dspeaker-onnx-go/rpc/speakerpb"
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// Config 服务配置
+type Config struct {
+	VerifyThreshold   *float32 // 验证阈值，nil时使用Speaker配置的阈值（LoadThreshold或默认值）
+	IdentifyThreshold *float32 // 辨认时接受候选说话人的阈值，nil时与验证阈值相同
+	TopK              int      // 辨认默认返回的候选说话人数
+	MaxAudioSeconds   float64  // 每段音频解码后的时长上限（秒），超过时返回InvalidArgument
+
+	Stream          speaker.StreamConfig // 流式验证配置，请求中的窗口长度和更新间隔可覆盖
+	MaxStreamWindow float64              // 请求可覆盖的窗口长度上限（秒），更新间隔不超过窗口长度
+	Online          diarize.OnlineConfig // 在线说话人日志配置
+}
+
+// DefaultConfig 返回默认的服务配置
+func DefaultConfig() Config {
+	return Config{
+		TopK:            5,
+		MaxAudioSeconds: 300,
+		Stream:          speaker.DefaultStreamConfig(),
+		MaxStreamWindow: 30,
+		Online:          diarize.DefaultOnlineConfig(),
+	}
+}
+
+// Service 说话人识别gRPC服务
+type Service struct {
+	speakerpb.UnimplementedSpeakerServiceServer
+
+	pool  *speaker.Pool
+	store *gallery.Store
+	cfg   Config
+}
+
+// New 创建服务
+//
+// 参数:
+//   - pool: Speaker会话池
+//   - store: 说话人库，已绑定时需与会话池的模型指纹一致
+//   - cfg: 服务配置
+func New(pool *speaker.Pool, store *gallery.Store, cfg Config) (*Service, error) {
+	if pool == nil || store == nil {
+		return nil, errors.New("会话池和说话人库不能为空")
+	}
+	if h := store.Header(); h.Fingerprint != "" && h.Fingerprint != pool.Fingerprint() {
+		return nil, fmt.Errorf("%w: 库为 %s，当前模型为 %s", gallery.ErrFingerprintMismatch, h.Fingerprint, pool.Fingerprint())
+	}
+	if cfg.TopK <= 0 {
+		cfg.TopK = DefaultConfig().TopK
+	}
+	if cfg.MaxAudioSeconds <= 0 {
+		cfg.MaxAudioSeconds = DefaultConfig().MaxAudioSeconds
+	}
+	if cfg.MaxStreamWindow <= 0 {
+		cfg.MaxStreamWindow = DefaultConfig().MaxStreamWindow
+	}
+	return &Service{pool: pool, store: store, cfg: cfg}, nil
+}
+
+// Register 将服务注册到gRPC服务器
+func (s *Service) Register(g *grpc.Server) {
+	speakerpb.RegisterSpeakerServiceServer(g, s)
+}
+
+// errInvalidArgument 请求参数错误
+var errInvalidArgument = errors.New("参数错误")
+
+// invalidArgument 返回包装了errInvalidArgument的错误
+func invalidArgument(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", errInvalidArgument, fmt.Sprintf(format, args...))
+}
+
+// toStatus 将错误转换为gRPC状态
+func toStatus(err error) error {
+	var code codes.Code
+	switch {
+	case errors.Is(err, errInvalidArgument), errors.Is(err, speaker.ErrInvalidStreamConfig):
+		code = codes.InvalidArgument
+	case errors.Is(err, gallery.ErrNotFound):
+		code = codes.NotFound
+	case errors.Is(err, speaker.ErrLowQuality), errors.Is(err, speaker.ErrZeroNorm):
+		code = codes.FailedPrecondition
+	case errors.Is(err, speaker.ErrPoolClosed), errors.Is(err, gallery.ErrClosed):
+		code = codes.Unavailable
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		code = codes.Internal
+	}
+	return status.Error(code, err.Error())
+}
+
+// decode 解码请求中的音频，采样率超出支持范围或时长超过上限时返回参数错误
+func (s *Service) decode(field string, data []byte) ([]int16, error) {
+	if len(data) == 0 {
+		return nil, invalidArgument("缺少音频字段%s", field)
+	}
+	pcm, err := audio.Decode(data)
+	if err != nil {
+		return nil, invalidArgument("解码音频字段%s失败: %v", field, err)
+	}
+	if len(pcm) == 0 {
+		return nil, invalidArgument("音频字段%s为空", field)
+	}
+	if seconds := float64(len(pcm)) / audio.SampleRate; seconds > s.cfg.MaxAudioSeconds {
+		return nil, invalidArgument("音频字段%s时长%.1f秒，超过上限%g秒", field, seconds, s.cfg.MaxAudioSeconds)
+	}
+	return pcm, nil
+}
+
+// Embed 提取嵌入向量
+func (s *Service) Embed(ctx context.Context, req *speakerpb.EmbedRequest) (*speakerpb.EmbedResponse, error) {
+	pcm, err := s.decode("audio", req.GetAudio())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	var emb *speaker.Embedding
+	err = s.pool.Do(ctx, func(spk *speaker.Speaker) error {
+		emb, err = spk.ExtractEmbedding(pcm)
+		return err
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &speakerpb.EmbedResponse{Embedding: emb.GetData(), Norm: emb.Norm()}, nil
+}
+
+// Verify 验证音频与注册音频或库中说话人是否为同一说话人
+func (s *Service) Verify(ctx context.Context, req *speakerpb.VerifyRequest) (*speakerpb.VerifyResponse, error) {
+	pcm, err := s.decode("audio", req.GetAudio())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	var enroll *speaker.Embedding
+	var enrollPCM []int16
+	switch e := req.GetEnroll().(type) {
+	case *speakerpb.VerifyRequest_SpeakerId:
+		var ok bool
+		if enroll, ok = s.store.Get(e.SpeakerId); !ok {
+			return nil, toStatus(fmt.Errorf("%w: %s", gallery.ErrNotFound, e.SpeakerId))
+		}
+	case *speakerpb.VerifyRequest_EnrollAudio:
+		if enrollPCM, err = s.decode("enroll_audio", e.EnrollAudio); err != nil {
+			return nil, toStatus(err)
+		}
+	default:
+		return nil, toStatus(invalidArgument("需要enroll_audio或speaker_id"))
+	}
+
+	resp := &speakerpb.VerifyResponse{}
+	err = s.pool.Do(ctx, func(spk *speaker.Speaker) error {
+		resp.Threshold = spk.ResolveThreshold(req.Threshold, s.cfg.VerifyThreshold)
+		if enroll != nil {
+			resp.Accepted, resp.Score, err = spk.VerifyWithThreshold(enroll, pcm, resp.Threshold)
+		} else {
+			resp.Accepted, resp.Score, err = spk.IsSameSpeakerWithThreshold(enrollPCM, pcm, resp.Threshold)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return resp, nil
+}
+
+// Identify 在说话人库中辨认音频的说话人
+func (s *Service) Identify(ctx context.Context, req *speakerpb.IdentifyRequest) (*speakerpb.IdentifyResponse, error) {
+	pcm, err := s.decode("audio", req.GetAudio())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	top := int(req.GetTop())
+	if top <= 0 {
+		top = s.cfg.TopK
+	}
+	entries := s.store.Snapshot()
+
+	resp := &speakerpb.IdentifyResponse{}
+	err = s.pool.Do(ctx, func(spk *speaker.Speaker) error {
+		resp.Threshold = spk.ResolveThreshold(req.Threshold, s.cfg.IdentifyThreshold, s.cfg.VerifyThreshold)
+		probe, err := spk.ExtractEmbedding(pcm)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			score, err := spk.CompareEmbeddings(e.Embedding, probe)
+			if err != nil {
+				return fmt.Errorf("与说话人 %s 打分失败: %w", e.ID, err)
+			}
+			resp.Candidates = append(resp.Candidates, &speakerpb.Candidate{Id: e.ID, Score: score, Accepted: score >= resp.Threshold})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	sort.SliceStable(resp.Candidates, func(i, j int) bool { return resp.Candidates[i].Score > resp.Candidates[j].Score })
+	if len(resp.Candidates) > top {
+		resp.Candidates = resp.Candidates[:top]
+	}
+	if len(resp.Candidates) > 0 && resp.Candidates[0].Accepted {
+		resp.Id = resp.Candidates[0].Id
+	}
+	return resp, nil
+}
+
+// Stream 流式验证和在线说话人日志
+func (s *Service) Stream(stream grpc.BidiStreamingServer[speakerpb.StreamRequest, speakerpb.StreamResponse]) error {
+	ctx := stream.Context()
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	cfg := first.GetConfig()
+	if cfg == nil {
+		return toStatus(invalidArgument("第一条消息必须为配置"))
+	}
+	streamCfg, err := s.streamConfig(cfg.GetWindowSeconds(), cfg.GetIntervalSeconds())
+	if err != nil {
+		return toStatus(err)
+	}
+
+	spk, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return toStatus(err)
+	}
+	defer s.pool.Release(spk)
+	sess, err := s.newSession(spk, cfg, streamCfg)
+	if err != nil {
+		return toStatus(err)
+	}
+	defer sess.close()
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if req.GetConfig() != nil {
+			return toStatus(invalidArgument("配置只能在第一条消息中发送"))
+		}
+		events, err := sess.write(req.GetAudio())
+		if err != nil {
+			return toStatus(err)
+		}
+		for _, ev := range events {
+			if err := stream.Send(ev); err != nil {
+				return err
+			}
+		}
+	}
+	for _, ev := range sess.finish() {
+		if err := stream.Send(ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// session 一个流的处理状态
+type session struct {
+	stream    *speaker.Stream
+	diarizer  *diarize.OnlineDiarizer
+	target    bool
+	threshold float32
+
+	odd     []byte // 不足一个样本的剩余字节
+	samples int
+	events  []*speakerpb.StreamResponse
+}
+
+// streamConfig 返回应用请求覆盖值后的流式验证配置，按MaxStreamWindow限制（见StreamConfig.Clamp），
+// 不足一个样本时返回错误
+func (s *Service) streamConfig(window, interval float64) (speaker.StreamConfig, error) {
+	cfg := s.cfg.Stream
+	if window > 0 {
+		cfg.WindowSeconds = window
+	}
+	if interval > 0 {
+		cfg.IntervalSeconds = interval
+	}
+	cfg = cfg.Clamp(s.cfg.MaxStreamWindow)
+	return cfg, cfg.Validate()
+}
+
+// newSession 按流配置创建流式验证和在线说话人日志
+func (s *Service) newSession(spk *speaker.Speaker, cfg *speakerpb.StreamConfig, streamCfg speaker.StreamConfig) (*session, error) {
+	var target *speaker.Embedding
+	switch t := cfg.GetTarget().(type) {
+	case *speakerpb.StreamConfig_SpeakerId:
+		var ok bool
+		if target, ok = s.store.Get(t.SpeakerId); !ok {
+			return nil, fmt.Errorf("%w: %s", gallery.ErrNotFound, t.SpeakerId)
+		}
+	case *speakerpb.StreamConfig_EnrollAudio:
+		pcm, err := s.decode("enroll_audio", t.EnrollAudio)
+		if err != nil {
+			return nil, err
+		}
+		if target, err = spk.Enroll(pcm); err != nil {
+			return nil, err
+		}
+	}
+
+	st, err := spk.NewStream(target, streamCfg)
+	if err != nil {
+		return nil, err
+	}
+	sess := &session{stream: st, target: target != nil, threshold: spk.ResolveThreshold(cfg.Threshold, s.cfg.VerifyThreshold)}
+	st.OnUpdate(sess.onUpdate)
+
+	if cfg.GetDiarize() {
+		if sess.diarizer, err = diarize.NewOnlineDiarizer(spk, s.cfg.Online); err != nil {
+			st.Close()
+			return nil, err
+		}
+	}
+	return sess, nil
+}
+
+// onUpdate 将流式验证的更新转换为分数事件
+func (sess *session) onUpdate(u speaker.StreamUpdate) {
+	if !sess.target {
+		return
+	}
+	sess.events = append(sess.events, &speakerpb.StreamResponse{Event: &speakerpb.StreamResponse_Score{Score: &speakerpb.ScoreUpdate{
+		Time:         u.Time,
+		Score:        u.Score,
+		RunningScore: u.RunningScore,
+		Accepted:     u.Score >= sess.threshold,
+	}}})
+}
+
+// write 输入一块小端int16 PCM，返回产生的事件
+func (sess *session) write(chunk []byte) ([]*speakerpb.StreamResponse, error) {
+	if len(sess.odd) > 0 {
+		chunk = append(sess.odd, chunk...)
+		sess.odd = nil
+	}
+	if len(chunk)%2 == 1 {
+		sess.odd = []byte{chunk[len(chunk)-1]}
+		chunk = chunk[:len(chunk)-1]
+	}
+	pcm := make([]int16, len(chunk)/2)
+	for i := range pcm {
+		pcm[i] = int16(binary.LittleEndian.Uint16(chunk[2*i:]))
+	}
+	sess.samples += len(pcm)
+
+	if err := sess.stream.WriteSamples(pcm); err != nil {
+		return nil, err
+	}
+	if sess.diarizer != nil {
+		labels, err := sess.diarizer.Process(pcm)
+		if err != nil {
+			return nil, err
+		}
+		sess.addLabels(labels)
+	}
+	events := sess.events
+	sess.events = nil
+	return events, nil
+}
+
+// addLabels 将说话人日志标签转换为事件
+func (sess *session) addLabels(labels []diarize.Label) {
+	for _, l := range labels {
+		sess.events = append(sess.events, &speakerpb.StreamResponse{Event: &speakerpb.StreamResponse_Diarization{Diarization: &speakerpb.DiarizationEvent{
+			Start:   l.Start,
+			End:     l.End,
+			Speaker: l.Speaker,
+			Revised: l.Revised,
+			Final:   l.Final,
+		}}})
+	}
+}
+
+// finish 结束输入，返回剩余的说话人日志标签和最终结果
+func (sess *session) finish() []*speakerpb.StreamResponse {
+	result := &speakerpb.StreamResult{Duration: float64(sess.samples) / audio.SampleRate}
+	if sess.diarizer != nil {
+		sess.addLabels(sess.diarizer.Flush())
+		result.NumSpeakers = int32(sess.diarizer.NumSpeakers())
+	}
+	if u, ok := sess.stream.Latest(); ok && sess.target {
+		result.RunningScore = u.RunningScore
+		result.Accepted = u.RunningScore >= sess.threshold
+	}
+	events := append(sess.events, &speakerpb.StreamResponse{Event: &speakerpb.StreamResponse_Result{Result: result}})
+	sess.events = nil
+	return events
+}
+
+// close 释放流式特征提取器
+func (sess *session) close() {
+	sess.stream.Close()
+	if sess.diarizer != nil {
+		sess.diarizer.Close()
+	}
+}
@@ -0,0 +1,192 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"math"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/seastart/3dspeaker-onnx-go/gallery"
+	"github.com/seastart/3dspeaker-onnx-go/internal/testaudio"
+	"github.com/seastart/3dspeaker-onnx-go/rpc/speakerpb"
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// newTestClient 在进程内的bufconn上启动服务并返回客户端
+func newTestClient(t *testing.T, pool *speaker.Pool) (speakerpb.SpeakerServiceClient, *gallery.Store) {
+	t.Helper()
+	store, err := gallery.Open(filepath.Join(t.TempDir(), "test.gallery"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	s, err := New(pool, store, DefaultConfig())
+	if err != nil {
+		t.Fatalf("创建服务失败: %v", err)
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	g := grpc.NewServer()
+	s.Register(g)
+	go g.Serve(lis)
+	t.Cleanup(g.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return speakerpb.NewSpeakerServiceClient(conn), store
+}
+
+// TestServiceErrors 测试不需要模型的请求和错误状态码
+func TestServiceErrors(t *testing.T) {
+	// 未加载模型的实例提取嵌入向量时必然失败
+	pool, err := speaker.NewPool(1, func() (*speaker.Speaker, error) { return &speaker.Speaker{}, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	client, store := newTestClient(t, pool)
+	store.Put("alice", speaker.NewEmbedding([]float32{1, 0, 0}))
+	ctx := context.Background()
+	audio := testaudio.PCMBytes(1, 200)
+
+	tests := []struct {
+		name string
+		call func() error
+		code codes.Code
+	}{
+		{"缺少音频", func() error { _, err := client.Embed(ctx, &speakerpb.EmbedRequest{}); return err }, codes.InvalidArgument},
+		{"提取失败", func() error { _, err := client.Embed(ctx, &speakerpb.EmbedRequest{Audio: audio}); return err }, codes.Internal},
+		{"验证缺少注册", func() error { _, err := client.Verify(ctx, &speakerpb.VerifyRequest{Audio: audio}); return err }, codes.InvalidArgument},
+		{"验证不存在的说话人", func() error {
+			_, err := client.Verify(ctx, &speakerpb.VerifyRequest{Enroll: &speakerpb.VerifyRequest_SpeakerId{SpeakerId: "bob"}, Audio: audio})
+			return err
+		}, codes.NotFound},
+		{"流缺少配置", func() error {
+			stream, err := client.Stream(ctx)
+			if err != nil {
+				return err
+			}
+			stream.Send(&speakerpb.StreamRequest{Request: &speakerpb.StreamRequest_Audio{Audio: audio}})
+			_, err = stream.Recv()
+			return err
+		}, codes.InvalidArgument},
+		{"更新间隔不足一个样本", func() error {
+			stream, err := client.Stream(ctx)
+			if err != nil {
+				return err
+			}
+			stream.Send(&speakerpb.StreamRequest{Request: &speakerpb.StreamRequest_Config{Config: &speakerpb.StreamConfig{IntervalSeconds: 1e-5}}})
+			_, err = stream.Recv()
+			return err
+		}, codes.InvalidArgument},
+		{"流的目标说话人不存在", func() error {
+			stream, err := client.Stream(ctx)
+			if err != nil {
+				return err
+			}
+			stream.Send(&speakerpb.StreamRequest{Request: &speakerpb.StreamRequest_Config{Config: &speakerpb.StreamConfig{Target: &speakerpb.StreamConfig_SpeakerId{SpeakerId: "bob"}}}})
+			_, err = stream.Recv()
+			return err
+		}, codes.NotFound},
+	}
+	for _, tt := range tests {
+		if code := status.Code(tt.call()); code != tt.code {
+			t.Errorf("%s: 状态码应为%v，实际为%v", tt.name, tt.code, code)
+		}
+	}
+
+	// 解码后超过时长上限的音频返回InvalidArgument
+	s := &Service{cfg: DefaultConfig()}
+	s.cfg.MaxAudioSeconds = 0.5
+	if _, err := s.decode("audio", audio); status.Code(toStatus(err)) != codes.InvalidArgument {
+		t.Fatalf("超过时长上限应返回InvalidArgument: %v", err)
+	}
+}
+
+// TestServiceFlow 测试注册后验证、辨认和流式验证的完整流程
+func TestServiceFlow(t *testing.T) {
+	modelPath := "../../onnxruntime/model.onnx"
+	configPath := "../../onnxruntime/assets/fbank_config.json"
+	pool, err := speaker.NewPoolFromModel(modelPath, configPath, 2)
+	if err != nil {
+		t.Skipf("跳过测试：无法加载模型: %v", err)
+	}
+	defer pool.Close()
+	client, store := newTestClient(t, pool)
+	ctx := context.Background()
+
+	alice, bob := testaudio.PCMBytes(3, 220), testaudio.PCMBytes(3, 330)
+	for id, data := range map[string][]byte{"alice": alice, "bob": bob} {
+		resp, err := client.Embed(ctx, &speakerpb.EmbedRequest{Audio: data})
+		if err != nil || len(resp.Embedding) == 0 {
+			t.Fatalf("提取嵌入向量失败: %v", err)
+		}
+		store.Put(id, speaker.NewEmbedding(resp.Embedding))
+	}
+
+	verify, err := client.Verify(ctx, &speakerpb.VerifyRequest{Enroll: &speakerpb.VerifyRequest_SpeakerId{SpeakerId: "alice"}, Audio: alice, Threshold: proto.Float32(0.5)})
+	if err != nil || !verify.Accepted || verify.Threshold != 0.5 || verify.Score < 0.99 {
+		t.Fatalf("相同音频应验证通过: %v %v", err, verify)
+	}
+	identify, err := client.Identify(ctx, &speakerpb.IdentifyRequest{Audio: bob, Top: 1})
+	if err != nil || len(identify.Candidates) != 1 || identify.Candidates[0].Id != "bob" {
+		t.Fatalf("应辨认为bob: %v %v", err, identify)
+	}
+
+	stream, err := client.Stream(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &speakerpb.StreamConfig{Target: &speakerpb.StreamConfig_SpeakerId{SpeakerId: "alice"}, Diarize: true}
+	if err := stream.Send(&speakerpb.StreamRequest{Request: &speakerpb.StreamRequest_Config{Config: config}}); err != nil {
+		t.Fatal(err)
+	}
+	// 按100ms分块发送，块长度为奇数字节时跨块拼接样本
+	go func() {
+		data := append(append([]byte{}, alice...), bob...)
+		for i := 0; i < len(data); i += 3201 {
+			stream.Send(&speakerpb.StreamRequest{Request: &speakerpb.StreamRequest_Audio{Audio: data[i:min(i+3201, len(data))]}})
+		}
+		stream.CloseSend()
+	}()
+
+	var scores, labels int
+	var result *speakerpb.StreamResult
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("接收流式结果失败: %v", err)
+		}
+		switch ev := resp.Event.(type) {
+		case *speakerpb.StreamResponse_Score:
+			scores++
+		case *speakerpb.StreamResponse_Diarization:
+			labels++
+		case *speakerpb.StreamResponse_Result:
+			result = ev.Result
+		}
+	}
+	if scores == 0 || labels == 0 || result == nil {
+		t.Fatalf("应收到分数更新、说话人日志事件和最终结果: %d %d %v", scores, labels, result)
+	}
+	if math.Abs(result.Duration-6) > 0.01 || result.NumSpeakers == 0 {
+		t.Fatalf("最终结果错误: %v", result)
+	}
+}
@@ -0,0 +1,4 @@
+// Package speakerpb 说话人识别gRPC接口的protobuf定义和生成代码
+package speakerpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative speaker.proto
@@ -0,0 +1,1165 @@
+// 说话人识别gRPC接口
+//
+// 音频字段均为WAV文件内容或16kHz单声道小端int16原始PCM，
+// 流式接口中的音频块只能是原始PCM
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: speaker.proto
+
+package speakerpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type EmbedRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Audio         []byte                 `protobuf:"bytes,1,opt,name=audio,proto3" json:"audio,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EmbedRequest) Reset() {
+	*x = EmbedRequest{}
+	mi := &file_speaker_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EmbedRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmbedRequest) ProtoMessage() {}
+
+func (x *EmbedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_speaker_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmbedRequest.ProtoReflect.Descriptor instead.
+func (*EmbedRequest) Descriptor() ([]byte, []int) {
+	return file_speaker_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *EmbedRequest) GetAudio() []byte {
+	if x != nil {
+		return x.Audio
+	}
+	return nil
+}
+
+type EmbedResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Embedding []float32              `protobuf:"fixed32,1,rep,packed,name=embedding,proto3" json:"embedding,omitempty"`
+	// 模型原始输出的L2范数
+	Norm          float32 `protobuf:"fixed32,2,opt,name=norm,proto3" json:"norm,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EmbedResponse) Reset() {
+	*x = EmbedResponse{}
+	mi := &file_speaker_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EmbedResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmbedResponse) ProtoMessage() {}
+
+func (x *EmbedResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_speaker_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmbedResponse.ProtoReflect.Descriptor instead.
+func (*EmbedResponse) Descriptor() ([]byte, []int) {
+	return file_speaker_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *EmbedResponse) GetEmbedding() []float32 {
+	if x != nil {
+		return x.Embedding
+	}
+	return nil
+}
+
+func (x *EmbedResponse) GetNorm() float32 {
+	if x != nil {
+		return x.Norm
+	}
+	return 0
+}
+
+type VerifyRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Enroll:
+	//
+	//	*VerifyRequest_EnrollAudio
+	//	*VerifyRequest_SpeakerId
+	Enroll isVerifyRequest_Enroll `protobuf_oneof:"enroll"`
+	// 测试音频
+	Audio []byte `protobuf:"bytes,3,opt,name=audio,proto3" json:"audio,omitempty"`
+	// 判决阈值，未设置时使用服务配置的阈值；可以为0或负数（例如对数似然比）
+	Threshold     *float32 `protobuf:"fixed32,4,opt,name=threshold,proto3,oneof" json:"threshold,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyRequest) Reset() {
+	*x = VerifyRequest{}
+	mi := &file_speaker_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyRequest) ProtoMessage() {}
+
+func (x *VerifyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_speaker_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyRequest.ProtoReflect.Descriptor instead.
+func (*VerifyRequest) Descriptor() ([]byte, []int) {
+	return file_speaker_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *VerifyRequest) GetEnroll() isVerifyRequest_Enroll {
+	if x != nil {
+		return x.Enroll
+	}
+	return nil
+}
+
+func (x *VerifyRequest) GetEnrollAudio() []byte {
+	if x != nil {
+		if x, ok := x.Enroll.(*VerifyRequest_EnrollAudio); ok {
+			return x.EnrollAudio
+		}
+	}
+	return nil
+}
+
+func (x *VerifyRequest) GetSpeakerId() string {
+	if x != nil {
+		if x, ok := x.Enroll.(*VerifyRequest_SpeakerId); ok {
+			return x.SpeakerId
+		}
+	}
+	return ""
+}
+
+func (x *VerifyRequest) GetAudio() []byte {
+	if x != nil {
+		return x.Audio
+	}
+	return nil
+}
+
+func (x *VerifyRequest) GetThreshold() float32 {
+	if x != nil && x.Threshold != nil {
+		return *x.Threshold
+	}
+	return 0
+}
+
+type isVerifyRequest_Enroll interface {
+	isVerifyRequest_Enroll()
+}
+
+type VerifyRequest_EnrollAudio struct {
+	// 注册音频
+	EnrollAudio []byte `protobuf:"bytes,1,opt,name=enroll_audio,json=enrollAudio,proto3,oneof"`
+}
+
+type VerifyRequest_SpeakerId struct {
+	// 说话人库中的说话人ID
+	SpeakerId string `protobuf:"bytes,2,opt,name=speaker_id,json=speakerId,proto3,oneof"`
+}
+
+func (*VerifyRequest_EnrollAudio) isVerifyRequest_Enroll() {}
+
+func (*VerifyRequest_SpeakerId) isVerifyRequest_Enroll() {}
+
+type VerifyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Score         float32                `protobuf:"fixed32,1,opt,name=score,proto3" json:"score,omitempty"`
+	Threshold     float32                `protobuf:"fixed32,2,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	Accepted      bool                   `protobuf:"varint,3,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyResponse) Reset() {
+	*x = VerifyResponse{}
+	mi := &file_speaker_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyResponse) ProtoMessage() {}
+
+func (x *VerifyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_speaker_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyResponse.ProtoReflect.Descriptor instead.
+func (*VerifyResponse) Descriptor() ([]byte, []int) {
+	return file_speaker_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *VerifyResponse) GetScore() float32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *VerifyResponse) GetThreshold() float32 {
+	if x != nil {
+		return x.Threshold
+	}
+	return 0
+}
+
+func (x *VerifyResponse) GetAccepted() bool {
+	if x != nil {
+		return x.Accepted
+	}
+	return false
+}
+
+type IdentifyRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Audio []byte                 `protobuf:"bytes,1,opt,name=audio,proto3" json:"audio,omitempty"`
+	// 返回的候选说话人数，<=0时使用服务配置
+	Top int32 `protobuf:"varint,2,opt,name=top,proto3" json:"top,omitempty"`
+	// 接受候选说话人的阈值，未设置时使用服务配置的阈值
+	Threshold     *float32 `protobuf:"fixed32,3,opt,name=threshold,proto3,oneof" json:"threshold,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IdentifyRequest) Reset() {
+	*x = IdentifyRequest{}
+	mi := &file_speaker_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IdentifyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IdentifyRequest) ProtoMessage() {}
+
+func (x *IdentifyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_speaker_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IdentifyRequest.ProtoReflect.Descriptor instead.
+func (*IdentifyRequest) Descriptor() ([]byte, []int) {
+	return file_speaker_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *IdentifyRequest) GetAudio() []byte {
+	if x != nil {
+		return x.Audio
+	}
+	return nil
+}
+
+func (x *IdentifyRequest) GetTop() int32 {
+	if x != nil {
+		return x.Top
+	}
+	return 0
+}
+
+func (x *IdentifyRequest) GetThreshold() float32 {
+	if x != nil && x.Threshold != nil {
+		return *x.Threshold
+	}
+	return 0
+}
+
+type Candidate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Score         float32                `protobuf:"fixed32,2,opt,name=score,proto3" json:"score,omitempty"`
+	Accepted      bool                   `protobuf:"varint,3,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Candidate) Reset() {
+	*x = Candidate{}
+	mi := &file_speaker_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Candidate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Candidate) ProtoMessage() {}
+
+func (x *Candidate) ProtoReflect() protoreflect.Message {
+	mi := &file_speaker_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Candidate.ProtoReflect.Descriptor instead.
+func (*Candidate) Descriptor() ([]byte, []int) {
+	return file_speaker_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Candidate) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Candidate) GetScore() float32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *Candidate) GetAccepted() bool {
+	if x != nil {
+		return x.Accepted
+	}
+	return false
+}
+
+type IdentifyResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 分数最高且达到阈值的说话人，没有时为空
+	Id        string  `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Threshold float32 `protobuf:"fixed32,2,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	// 按分数从高到低排序
+	Candidates    []*Candidate `protobuf:"bytes,3,rep,name=candidates,proto3" json:"candidates,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IdentifyResponse) Reset() {
+	*x = IdentifyResponse{}
+	mi := &file_speaker_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IdentifyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IdentifyResponse) ProtoMessage() {}
+
+func (x *IdentifyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_speaker_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IdentifyResponse.ProtoReflect.Descriptor instead.
+func (*IdentifyResponse) Descriptor() ([]byte, []int) {
+	return file_speaker_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *IdentifyResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *IdentifyResponse) GetThreshold() float32 {
+	if x != nil {
+		return x.Threshold
+	}
+	return 0
+}
+
+func (x *IdentifyResponse) GetCandidates() []*Candidate {
+	if x != nil {
+		return x.Candidates
+	}
+	return nil
+}
+
+type StreamConfig struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 目标说话人，为空时不做验证
+	//
+	// Types that are valid to be assigned to Target:
+	//
+	//	*StreamConfig_SpeakerId
+	//	*StreamConfig_EnrollAudio
+	Target isStreamConfig_Target `protobuf_oneof:"target"`
+	// 判决阈值，未设置时使用服务配置的阈值
+	Threshold *float32 `protobuf:"fixed32,3,opt,name=threshold,proto3,oneof" json:"threshold,omitempty"`
+	// 是否输出在线说话人日志事件
+	Diarize bool `protobuf:"varint,4,opt,name=diarize,proto3" json:"diarize,omitempty"`
+	// 验证窗口长度和更新间隔（秒），<=0时使用服务配置；窗口长度不超过服务的上限，更新间隔不超过窗口长度
+	WindowSeconds   float64 `protobuf:"fixed64,5,opt,name=window_seconds,json=windowSeconds,proto3" json:"window_seconds,omitempty"`
+	IntervalSeconds float64 `protobuf:"fixed64,6,opt,name=interval_seconds,json=intervalSeconds,proto3" json:"interval_seconds,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *StreamConfig) Reset() {
+	*x = StreamConfig{}
+	mi := &file_speaker_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamConfig) ProtoMessage() {}
+
+func (x *StreamConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_speaker_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamConfig.ProtoReflect.Descriptor instead.
+func (*StreamConfig) Descriptor() ([]byte, []int) {
+	return file_speaker_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *StreamConfig) GetTarget() isStreamConfig_Target {
+	if x != nil {
+		return x.Target
+	}
+	return nil
+}
+
+func (x *StreamConfig) GetSpeakerId() string {
+	if x != nil {
+		if x, ok := x.Target.(*StreamConfig_SpeakerId); ok {
+			return x.SpeakerId
+		}
+	}
+	return ""
+}
+
+func (x *StreamConfig) GetEnrollAudio() []byte {
+	if x != nil {
+		if x, ok := x.Target.(*StreamConfig_EnrollAudio); ok {
+			return x.EnrollAudio
+		}
+	}
+	return nil
+}
+
+func (x *StreamConfig) GetThreshold() float32 {
+	if x != nil && x.Threshold != nil {
+		return *x.Threshold
+	}
+	return 0
+}
+
+func (x *StreamConfig) GetDiarize() bool {
+	if x != nil {
+		return x.Diarize
+	}
+	return false
+}
+
+func (x *StreamConfig) GetWindowSeconds() float64 {
+	if x != nil {
+		return x.WindowSeconds
+	}
+	return 0
+}
+
+func (x *StreamConfig) GetIntervalSeconds() float64 {
+	if x != nil {
+		return x.IntervalSeconds
+	}
+	return 0
+}
+
+type isStreamConfig_Target interface {
+	isStreamConfig_Target()
+}
+
+type StreamConfig_SpeakerId struct {
+	SpeakerId string `protobuf:"bytes,1,opt,name=speaker_id,json=speakerId,proto3,oneof"`
+}
+
+type StreamConfig_EnrollAudio struct {
+	EnrollAudio []byte `protobuf:"bytes,2,opt,name=enroll_audio,json=enrollAudio,proto3,oneof"`
+}
+
+func (*StreamConfig_SpeakerId) isStreamConfig_Target() {}
+
+func (*StreamConfig_EnrollAudio) isStreamConfig_Target() {}
+
+type StreamRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Request:
+	//
+	//	*StreamRequest_Config
+	//	*StreamRequest_Audio
+	Request       isStreamRequest_Request `protobuf_oneof:"request"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamRequest) Reset() {
+	*x = StreamRequest{}
+	mi := &file_speaker_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamRequest) ProtoMessage() {}
+
+func (x *StreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_speaker_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamRequest.ProtoReflect.Descriptor instead.
+func (*StreamRequest) Descriptor() ([]byte, []int) {
+	return file_speaker_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *StreamRequest) GetRequest() isStreamRequest_Request {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetConfig() *StreamConfig {
+	if x != nil {
+		if x, ok := x.Request.(*StreamRequest_Config); ok {
+			return x.Config
+		}
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetAudio() []byte {
+	if x != nil {
+		if x, ok := x.Request.(*StreamRequest_Audio); ok {
+			return x.Audio
+		}
+	}
+	return nil
+}
+
+type isStreamRequest_Request interface {
+	isStreamRequest_Request()
+}
+
+type StreamRequest_Config struct {
+	// 第一条消息必须为配置
+	Config *StreamConfig `protobuf:"bytes,1,opt,name=config,proto3,oneof"`
+}
+
+type StreamRequest_Audio struct {
+	// 16kHz单声道小端int16原始PCM，长度任意
+	Audio []byte `protobuf:"bytes,2,opt,name=audio,proto3,oneof"`
+}
+
+func (*StreamRequest_Config) isStreamRequest_Request() {}
+
+func (*StreamRequest_Audio) isStreamRequest_Request() {}
+
+// 滚动验证分数
+type ScoreUpdate struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 到目前为止输入的音频时长（秒）
+	Time float64 `protobuf:"fixed64,1,opt,name=time,proto3" json:"time,omitempty"`
+	// 最近窗口的分数
+	Score float32 `protobuf:"fixed32,2,opt,name=score,proto3" json:"score,omitempty"`
+	// 累积嵌入向量的分数
+	RunningScore  float32 `protobuf:"fixed32,3,opt,name=running_score,json=runningScore,proto3" json:"running_score,omitempty"`
+	Accepted      bool    `protobuf:"varint,4,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScoreUpdate) Reset() {
+	*x = ScoreUpdate{}
+	mi := &file_speaker_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScoreUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScoreUpdate) ProtoMessage() {}
+
+func (x *ScoreUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_speaker_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScoreUpdate.ProtoReflect.Descriptor instead.
+func (*ScoreUpdate) Descriptor() ([]byte, []int) {
+	return file_speaker_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ScoreUpdate) GetTime() float64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *ScoreUpdate) GetScore() float32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *ScoreUpdate) GetRunningScore() float32 {
+	if x != nil {
+		return x.RunningScore
+	}
+	return 0
+}
+
+func (x *ScoreUpdate) GetAccepted() bool {
+	if x != nil {
+		return x.Accepted
+	}
+	return false
+}
+
+// 在线说话人日志的一个标签
+type DiarizationEvent struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Start   float64                `protobuf:"fixed64,1,opt,name=start,proto3" json:"start,omitempty"`
+	End     float64                `protobuf:"fixed64,2,opt,name=end,proto3" json:"end,omitempty"`
+	Speaker string                 `protobuf:"bytes,3,opt,name=speaker,proto3" json:"speaker,omitempty"`
+	// 之前输出过该区间的标签，本次为修订后的结果
+	Revised bool `protobuf:"varint,4,opt,name=revised,proto3" json:"revised,omitempty"`
+	// 该标签不会再被修订
+	Final         bool `protobuf:"varint,5,opt,name=final,proto3" json:"final,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DiarizationEvent) Reset() {
+	*x = DiarizationEvent{}
+	mi := &file_speaker_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiarizationEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiarizationEvent) ProtoMessage() {}
+
+func (x *DiarizationEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_speaker_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiarizationEvent.ProtoReflect.Descriptor instead.
+func (*DiarizationEvent) Descriptor() ([]byte, []int) {
+	return file_speaker_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DiarizationEvent) GetStart() float64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *DiarizationEvent) GetEnd() float64 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+func (x *DiarizationEvent) GetSpeaker() string {
+	if x != nil {
+		return x.Speaker
+	}
+	return ""
+}
+
+func (x *DiarizationEvent) GetRevised() bool {
+	if x != nil {
+		return x.Revised
+	}
+	return false
+}
+
+func (x *DiarizationEvent) GetFinal() bool {
+	if x != nil {
+		return x.Final
+	}
+	return false
+}
+
+// 流结束时的最终结果
+type StreamResult struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Duration float64                `protobuf:"fixed64,1,opt,name=duration,proto3" json:"duration,omitempty"`
+	// 整个流的累积分数，未设置目标或没有更新时为0
+	RunningScore  float32 `protobuf:"fixed32,2,opt,name=running_score,json=runningScore,proto3" json:"running_score,omitempty"`
+	Accepted      bool    `protobuf:"varint,3,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	NumSpeakers   int32   `protobuf:"varint,4,opt,name=num_speakers,json=numSpeakers,proto3" json:"num_speakers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamResult) Reset() {
+	*x = StreamResult{}
+	mi := &file_speaker_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamResult) ProtoMessage() {}
+
+func (x *StreamResult) ProtoReflect() protoreflect.Message {
+	mi := &file_speaker_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamResult.ProtoReflect.Descriptor instead.
+func (*StreamResult) Descriptor() ([]byte, []int) {
+	return file_speaker_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *StreamResult) GetDuration() float64 {
+	if x != nil {
+		return x.Duration
+	}
+	return 0
+}
+
+func (x *StreamResult) GetRunningScore() float32 {
+	if x != nil {
+		return x.RunningScore
+	}
+	return 0
+}
+
+func (x *StreamResult) GetAccepted() bool {
+	if x != nil {
+		return x.Accepted
+	}
+	return false
+}
+
+func (x *StreamResult) GetNumSpeakers() int32 {
+	if x != nil {
+		return x.NumSpeakers
+	}
+	return 0
+}
+
+type StreamResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*StreamResponse_Score
+	//	*StreamResponse_Diarization
+	//	*StreamResponse_Result
+	Event         isStreamResponse_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamResponse) Reset() {
+	*x = StreamResponse{}
+	mi := &file_speaker_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamResponse) ProtoMessage() {}
+
+func (x *StreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_speaker_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamResponse.ProtoReflect.Descriptor instead.
+func (*StreamResponse) Descriptor() ([]byte, []int) {
+	return file_speaker_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *StreamResponse) GetEvent() isStreamResponse_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *StreamResponse) GetScore() *ScoreUpdate {
+	if x != nil {
+		if x, ok := x.Event.(*StreamResponse_Score); ok {
+			return x.Score
+		}
+	}
+	return nil
+}
+
+func (x *StreamResponse) GetDiarization() *DiarizationEvent {
+	if x != nil {
+		if x, ok := x.Event.(*StreamResponse_Diarization); ok {
+			return x.Diarization
+		}
+	}
+	return nil
+}
+
+func (x *StreamResponse) GetResult() *StreamResult {
+	if x != nil {
+		if x, ok := x.Event.(*StreamResponse_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+type isStreamResponse_Event interface {
+	isStreamResponse_Event()
+}
+
+type StreamResponse_Score struct {
+	Score *ScoreUpdate `protobuf:"bytes,1,opt,name=score,proto3,oneof"`
+}
+
+type StreamResponse_Diarization struct {
+	Diarization *DiarizationEvent `protobuf:"bytes,2,opt,name=diarization,proto3,oneof"`
+}
+
+type StreamResponse_Result struct {
+	Result *StreamResult `protobuf:"bytes,3,opt,name=result,proto3,oneof"`
+}
+
+func (*StreamResponse_Score) isStreamResponse_Event() {}
+
+func (*StreamResponse_Diarization) isStreamResponse_Event() {}
+
+func (*StreamResponse_Result) isStreamResponse_Event() {}
+
+var File_speaker_proto protoreflect.FileDescriptor
+
+const file_speaker_proto_rawDesc = "" +
+	"\n" +
+	"\rspeaker.proto\x12\n" +
+	"speaker.v1\"$\n" +
+	"\fEmbedRequest\x12\x14\n" +
+	"\x05audio\x18\x01 \x01(\fR\x05audio\"A\n" +
+	"\rEmbedResponse\x12\x1c\n" +
+	"\tembedding\x18\x01 \x03(\x02R\tembedding\x12\x12\n" +
+	"\x04norm\x18\x02 \x01(\x02R\x04norm\"\xa6\x01\n" +
+	"\rVerifyRequest\x12#\n" +
+	"\fenroll_audio\x18\x01 \x01(\fH\x00R\venrollAudio\x12\x1f\n" +
+	"\n" +
+	"speaker_id\x18\x02 \x01(\tH\x00R\tspeakerId\x12\x14\n" +
+	"\x05audio\x18\x03 \x01(\fR\x05audio\x12!\n" +
+	"\tthreshold\x18\x04 \x01(\x02H\x01R\tthreshold\x88\x01\x01B\b\n" +
+	"\x06enrollB\f\n" +
+	"\n" +
+	"_threshold\"`\n" +
+	"\x0eVerifyResponse\x12\x14\n" +
+	"\x05score\x18\x01 \x01(\x02R\x05score\x12\x1c\n" +
+	"\tthreshold\x18\x02 \x01(\x02R\tthreshold\x12\x1a\n" +
+	"\baccepted\x18\x03 \x01(\bR\baccepted\"j\n" +
+	"\x0fIdentifyRequest\x12\x14\n" +
+	"\x05audio\x18\x01 \x01(\fR\x05audio\x12\x10\n" +
+	"\x03top\x18\x02 \x01(\x05R\x03top\x12!\n" +
+	"\tthreshold\x18\x03 \x01(\x02H\x00R\tthreshold\x88\x01\x01B\f\n" +
+	"\n" +
+	"_threshold\"M\n" +
+	"\tCandidate\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05score\x18\x02 \x01(\x02R\x05score\x12\x1a\n" +
+	"\baccepted\x18\x03 \x01(\bR\baccepted\"w\n" +
+	"\x10IdentifyResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1c\n" +
+	"\tthreshold\x18\x02 \x01(\x02R\tthreshold\x125\n" +
+	"\n" +
+	"candidates\x18\x03 \x03(\v2\x15.speaker.v1.CandidateR\n" +
+	"candidates\"\xfb\x01\n" +
+	"\fStreamConfig\x12\x1f\n" +
+	"\n" +
+	"speaker_id\x18\x01 \x01(\tH\x00R\tspeakerId\x12#\n" +
+	"\fenroll_audio\x18\x02 \x01(\fH\x00R\venrollAudio\x12!\n" +
+	"\tthreshold\x18\x03 \x01(\x02H\x01R\tthreshold\x88\x01\x01\x12\x18\n" +
+	"\adiarize\x18\x04 \x01(\bR\adiarize\x12%\n" +
+	"\x0ewindow_seconds\x18\x05 \x01(\x01R\rwindowSeconds\x12)\n" +
+	"\x10interval_seconds\x18\x06 \x01(\x01R\x0fintervalSecondsB\b\n" +
+	"\x06targetB\f\n" +
+	"\n" +
+	"_threshold\"f\n" +
+	"\rStreamRequest\x122\n" +
+	"\x06config\x18\x01 \x01(\v2\x18.speaker.v1.StreamConfigH\x00R\x06config\x12\x16\n" +
+	"\x05audio\x18\x02 \x01(\fH\x00R\x05audioB\t\n" +
+	"\arequest\"x\n" +
+	"\vScoreUpdate\x12\x12\n" +
+	"\x04time\x18\x01 \x01(\x01R\x04time\x12\x14\n" +
+	"\x05score\x18\x02 \x01(\x02R\x05score\x12#\n" +
+	"\rrunning_score\x18\x03 \x01(\x02R\frunningScore\x12\x1a\n" +
+	"\baccepted\x18\x04 \x01(\bR\baccepted\"\x84\x01\n" +
+	"\x10DiarizationEvent\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\x01R\x05start\x12\x10\n" +
+	"\x03end\x18\x02 \x01(\x01R\x03end\x12\x18\n" +
+	"\aspeaker\x18\x03 \x01(\tR\aspeaker\x12\x18\n" +
+	"\arevised\x18\x04 \x01(\bR\arevised\x12\x14\n" +
+	"\x05final\x18\x05 \x01(\bR\x05final\"\x8e\x01\n" +
+	"\fStreamResult\x12\x1a\n" +
+	"\bduration\x18\x01 \x01(\x01R\bduration\x12#\n" +
+	"\rrunning_score\x18\x02 \x01(\x02R\frunningScore\x12\x1a\n" +
+	"\baccepted\x18\x03 \x01(\bR\baccepted\x12!\n" +
+	"\fnum_speakers\x18\x04 \x01(\x05R\vnumSpeakers\"\xc0\x01\n" +
+	"\x0eStreamResponse\x12/\n" +
+	"\x05score\x18\x01 \x01(\v2\x17.speaker.v1.ScoreUpdateH\x00R\x05score\x12@\n" +
+	"\vdiarization\x18\x02 \x01(\v2\x1c.speaker.v1.DiarizationEventH\x00R\vdiarization\x122\n" +
+	"\x06result\x18\x03 \x01(\v2\x18.speaker.v1.StreamResultH\x00R\x06resultB\a\n" +
+	"\x05event2\x9b\x02\n" +
+	"\x0eSpeakerService\x12<\n" +
+	"\x05Embed\x12\x18.speaker.v1.EmbedRequest\x1a\x19.speaker.v1.EmbedResponse\x12?\n" +
+	"\x06Verify\x12\x19.speaker.v1.VerifyRequest\x1a\x1a.speaker.v1.VerifyResponse\x12E\n" +
+	"\bIdentify\x12\x1b.speaker.v1.IdentifyRequest\x1a\x1c.speaker.v1.IdentifyResponse\x12C\n" +
+	"\x06Stream\x12\x19.speaker.v1.StreamRequest\x1a\x1a.speaker.v1.StreamResponse(\x010\x01B5Z3github.com/seastart/3dspeaker-onnx-go/rpc/speakerpbb\x06proto3"
+
+var (
+	file_speaker_proto_rawDescOnce sync.Once
+	file_speaker_proto_rawDescData []byte
+)
+
+func file_speaker_proto_rawDescGZIP() []byte {
+	file_speaker_proto_rawDescOnce.Do(func() {
+		file_speaker_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_speaker_proto_rawDesc), len(file_speaker_proto_rawDesc)))
+	})
+	return file_speaker_proto_rawDescData
+}
+
+var file_speaker_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_speaker_proto_goTypes = []any{
+	(*EmbedRequest)(nil),     // 0: speaker.v1.EmbedRequest
+	(*EmbedResponse)(nil),    // 1: speaker.v1.EmbedResponse
+	(*VerifyRequest)(nil),    // 2: speaker.v1.VerifyRequest
+	(*VerifyResponse)(nil),   // 3: speaker.v1.VerifyResponse
+	(*IdentifyRequest)(nil),  // 4: speaker.v1.IdentifyRequest
+	(*Candidate)(nil),        // 5: speaker.v1.Candidate
+	(*IdentifyResponse)(nil), // 6: speaker.v1.IdentifyResponse
+	(*StreamConfig)(nil),     // 7: speaker.v1.StreamConfig
+	(*StreamRequest)(nil),    // 8: speaker.v1.StreamRequest
+	(*ScoreUpdate)(nil),      // 9: speaker.v1.ScoreUpdate
+	(*DiarizationEvent)(nil), // 10: speaker.v1.DiarizationEvent
+	(*StreamResult)(nil),     // 11: speaker.v1.StreamResult
+	(*StreamResponse)(nil),   // 12: speaker.v1.StreamResponse
+}
+var file_speaker_proto_depIdxs = []int32{
+	5,  // 0: speaker.v1.IdentifyResponse.candidates:type_name -> speaker.v1.Candidate
+	7,  // 1: speaker.v1.StreamRequest.config:type_name -> speaker.v1.StreamConfig
+	9,  // 2: speaker.v1.StreamResponse.score:type_name -> speaker.v1.ScoreUpdate
+	10, // 3: speaker.v1.StreamResponse.diarization:type_name -> speaker.v1.DiarizationEvent
+	11, // 4: speaker.v1.StreamResponse.result:type_name -> speaker.v1.StreamResult
+	0,  // 5: speaker.v1.SpeakerService.Embed:input_type -> speaker.v1.EmbedRequest
+	2,  // 6: speaker.v1.SpeakerService.Verify:input_type -> speaker.v1.VerifyRequest
+	4,  // 7: speaker.v1.SpeakerService.Identify:input_type -> speaker.v1.IdentifyRequest
+	8,  // 8: speaker.v1.SpeakerService.Stream:input_type -> speaker.v1.StreamRequest
+	1,  // 9: speaker.v1.SpeakerService.Embed:output_type -> speaker.v1.EmbedResponse
+	3,  // 10: speaker.v1.SpeakerService.Verify:output_type -> speaker.v1.VerifyResponse
+	6,  // 11: speaker.v1.SpeakerService.Identify:output_type -> speaker.v1.IdentifyResponse
+	12, // 12: speaker.v1.SpeakerService.Stream:output_type -> speaker.v1.StreamResponse
+	9,  // [9:13] is the sub-list for method output_type
+	5,  // [5:9] is the sub-list for method input_type
+	5,  // [5:5] is the sub-list for extension type_name
+	5,  // [5:5] is the sub-list for extension extendee
+	0,  // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_speaker_proto_init() }
+func file_speaker_proto_init() {
+	if File_speaker_proto != nil {
+		return
+	}
+	file_speaker_proto_msgTypes[2].OneofWrappers = []any{
+		(*VerifyRequest_EnrollAudio)(nil),
+		(*VerifyRequest_SpeakerId)(nil),
+	}
+	file_speaker_proto_msgTypes[4].OneofWrappers = []any{}
+	file_speaker_proto_msgTypes[7].OneofWrappers = []any{
+		(*StreamConfig_SpeakerId)(nil),
+		(*StreamConfig_EnrollAudio)(nil),
+	}
+	file_speaker_proto_msgTypes[8].OneofWrappers = []any{
+		(*StreamRequest_Config)(nil),
+		(*StreamRequest_Audio)(nil),
+	}
+	file_speaker_proto_msgTypes[12].OneofWrappers = []any{
+		(*StreamResponse_Score)(nil),
+		(*StreamResponse_Diarization)(nil),
+		(*StreamResponse_Result)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_speaker_proto_rawDesc), len(file_speaker_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_speaker_proto_goTypes,
+		DependencyIndexes: file_speaker_proto_depIdxs,
+		MessageInfos:      file_speaker_proto_msgTypes,
+	}.Build()
+	File_speaker_proto = out.File
+	file_speaker_proto_goTypes = nil
+	file_speaker_proto_depIdxs = nil
+}
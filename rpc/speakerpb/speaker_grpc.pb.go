@@ -0,0 +1,245 @@
+// 说话人识别gRPC接口
+//
+// 音频字段均为WAV文件内容或16kHz单声道小端int16原始PCM，
+// 流式接口中的音频块只能是原始PCM
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: speaker.proto
+
+package speakerpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	SpeakerService_Embed_FullMethodName    = "/speaker.v1.SpeakerService/Embed"
+	SpeakerService_Verify_FullMethodName   = "/speaker.v1.SpeakerService/Verify"
+	SpeakerService_Identify_FullMethodName = "/speaker.v1.SpeakerService/Identify"
+	SpeakerService_Stream_FullMethodName   = "/speaker.v1.SpeakerService/Stream"
+)
+
+// SpeakerServiceClient is the client API for SpeakerService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SpeakerServiceClient interface {
+	// Embed 提取嵌入向量
+	Embed(ctx context.Context, in *EmbedRequest, opts ...grpc.CallOption) (*EmbedResponse, error)
+	// Verify 验证音频与注册音频或库中说话人是否为同一说话人
+	Verify(ctx context.Context, in *VerifyRequest, opts ...grpc.CallOption) (*VerifyResponse, error)
+	// Identify 在说话人库中辨认音频的说话人
+	Identify(ctx context.Context, in *IdentifyRequest, opts ...grpc.CallOption) (*IdentifyResponse, error)
+	// Stream 流式验证和在线说话人日志：第一条消息为配置，之后为音频块，
+	// 服务端随输入返回滚动的验证分数和说话人日志事件，客户端结束发送后返回最终结果
+	Stream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[StreamRequest, StreamResponse], error)
+}
+
+type speakerServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSpeakerServiceClient(cc grpc.ClientConnInterface) SpeakerServiceClient {
+	return &speakerServiceClient{cc}
+}
+
+func (c *speakerServiceClient) Embed(ctx context.Context, in *EmbedRequest, opts ...grpc.CallOption) (*EmbedResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EmbedResponse)
+	err := c.cc.Invoke(ctx, SpeakerService_Embed_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *speakerServiceClient) Verify(ctx context.Context, in *VerifyRequest, opts ...grpc.CallOption) (*VerifyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VerifyResponse)
+	err := c.cc.Invoke(ctx, SpeakerService_Verify_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *speakerServiceClient) Identify(ctx context.Context, in *IdentifyRequest, opts ...grpc.CallOption) (*IdentifyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IdentifyResponse)
+	err := c.cc.Invoke(ctx, SpeakerService_Identify_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *speakerServiceClient) Stream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[StreamRequest, StreamResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &SpeakerService_ServiceDesc.Streams[0], SpeakerService_Stream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamRequest, StreamResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SpeakerService_StreamClient = grpc.BidiStreamingClient[StreamRequest, StreamResponse]
+
+// SpeakerServiceServer is the server API for SpeakerService service.
+// All implementations must embed UnimplementedSpeakerServiceServer
+// for forward compatibility.
+type SpeakerServiceServer interface {
+	// Embed 提取嵌入向量
+	Embed(context.Context, *EmbedRequest) (*EmbedResponse, error)
+	// Verify 验证音频与注册音频或库中说话人是否为同一说话人
+	Verify(context.Context, *VerifyRequest) (*VerifyResponse, error)
+	// Identify 在说话人库中辨认音频的说话人
+	Identify(context.Context, *IdentifyRequest) (*IdentifyResponse, error)
+	// Stream 流式验证和在线说话人日志：第一条消息为配置，之后为音频块，
+	// 服务端随输入返回滚动的验证分数和说话人日志事件，客户端结束发送后返回最终结果
+	Stream(grpc.BidiStreamingServer[StreamRequest, StreamResponse]) error
+	mustEmbedUnimplementedSpeakerServiceServer()
+}
+
+// UnimplementedSpeakerServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSpeakerServiceServer struct{}
+
+func (UnimplementedSpeakerServiceServer) Embed(context.Context, *EmbedRequest) (*EmbedResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Embed not implemented")
+}
+func (UnimplementedSpeakerServiceServer) Verify(context.Context, *VerifyRequest) (*VerifyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Verify not implemented")
+}
+func (UnimplementedSpeakerServiceServer) Identify(context.Context, *IdentifyRequest) (*IdentifyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Identify not implemented")
+}
+func (UnimplementedSpeakerServiceServer) Stream(grpc.BidiStreamingServer[StreamRequest, StreamResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Stream not implemented")
+}
+func (UnimplementedSpeakerServiceServer) mustEmbedUnimplementedSpeakerServiceServer() {}
+func (UnimplementedSpeakerServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeSpeakerServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SpeakerServiceServer will
+// result in compilation errors.
+type UnsafeSpeakerServiceServer interface {
+	mustEmbedUnimplementedSpeakerServiceServer()
+}
+
+func RegisterSpeakerServiceServer(s grpc.ServiceRegistrar, srv SpeakerServiceServer) {
+	// If the following call pancis, it indicates UnimplementedSpeakerServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&SpeakerService_ServiceDesc, srv)
+}
+
+func _SpeakerService_Embed_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmbedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SpeakerServiceServer).Embed(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SpeakerService_Embed_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SpeakerServiceServer).Embed(ctx, req.(*EmbedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SpeakerService_Verify_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SpeakerServiceServer).Verify(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SpeakerService_Verify_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SpeakerServiceServer).Verify(ctx, req.(*VerifyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SpeakerService_Identify_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdentifyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SpeakerServiceServer).Identify(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SpeakerService_Identify_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SpeakerServiceServer).Identify(ctx, req.(*IdentifyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SpeakerService_Stream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(SpeakerServiceServer).Stream(&grpc.GenericServerStream[StreamRequest, StreamResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SpeakerService_StreamServer = grpc.BidiStreamingServer[StreamRequest, StreamResponse]
+
+// SpeakerService_ServiceDesc is the grpc.ServiceDesc for SpeakerService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SpeakerService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "speaker.v1.SpeakerService",
+	HandlerType: (*SpeakerServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Embed",
+			Handler:    _SpeakerService_Embed_Handler,
+		},
+		{
+			MethodName: "Verify",
+			Handler:    _SpeakerService_Verify_Handler,
+		},
+		{
+			MethodName: "Identify",
+			Handler:    _SpeakerService_Identify_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Stream",
+			Handler:       _SpeakerService_Stream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "speaker.proto",
+}
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/seastart/3dspeaker-onnx-go/audio"
+)
+
+// errBadRequest 请求格式错误，返回400
+var errBadRequest = errors.New("请求错误")
+
+// badRequest 返回包装了errBadRequest的错误
+func badRequest(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", errBadRequest, fmt.Sprintf(format, args...))
+}
+
+// request 解析后的请求：音频按字段名分组，同一字段可以有多段音频（例如注册）
+type request struct {
+	audios     map[string][][]byte
+	id         string
+	threshold  *float32 // 未指定时为nil
+	top        int
+	maxSeconds float64 // 每段音频解码后的时长上限，为0时不限制
+}
+
+// jsonRequest JSON请求体，音频为base64编码的WAV或16kHz单声道int16原始PCM
+type jsonRequest struct {
+	Audio     string   `json:"audio"`
+	Audio1    string   `json:"audio1"`
+	Audio2    string   `json:"audio2"`
+	Audios    []string `json:"audios"` // 多段注册音频，与audio合并
+	ID        string   `json:"id"`
+	Threshold *float32 `json:"threshold"`
+	Top       int      `json:"top"`
+}
+
+// parseRequest 解析multipart/form-data上传或JSON请求体
+func parseRequest(r *http.Request, maxBytes int64) (*request, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, badRequest("无法解析Content-Type: %v", err)
+	}
+	switch mediaType {
+	case "multipart/form-data":
+		return parseMultipart(r, maxBytes)
+	case "application/json":
+		return parseJSON(r)
+	default:
+		return nil, badRequest("不支持的Content-Type: %s，请使用multipart/form-data或application/json", mediaType)
+	}
+}
+
+// parseMultipart 解析multipart上传，文件字段为音频，普通字段为id、threshold、top
+func parseMultipart(r *http.Request, maxBytes int64) (*request, error) {
+	if err := r.ParseMultipartForm(maxBytes); err != nil {
+		return nil, badRequest("解析multipart请求失败: %v", err)
+	}
+	req := &request{audios: make(map[string][][]byte), id: r.FormValue("id")}
+	for field, files := range r.MultipartForm.File {
+		for _, fh := range files {
+			f, err := fh.Open()
+			if err != nil {
+				return nil, badRequest("读取上传文件 %s 失败: %v", fh.Filename, err)
+			}
+			data, err := io.ReadAll(f)
+			f.Close()
+			if err != nil {
+				return nil, badRequest("读取上传文件 %s 失败: %v", fh.Filename, err)
+			}
+			req.audios[field] = append(req.audios[field], data)
+		}
+	}
+	if v := r.FormValue("threshold"); v != "" {
+		t, err := strconv.ParseFloat(v, 32)
+		if err != nil {
+			return nil, badRequest("无效的阈值: %s", v)
+		}
+		threshold := float32(t)
+		req.threshold = &threshold
+	}
+	if v := r.FormValue("top"); v != "" {
+		top, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, badRequest("无效的候选数: %s", v)
+		}
+		req.top = top
+	}
+	return req, nil
+}
+
+// parseJSON 解析JSON请求体
+func parseJSON(r *http.Request) (*request, error) {
+	var body jsonRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return nil, badRequest("解析JSON请求失败: %v", err)
+	}
+	req := &request{audios: make(map[string][][]byte), id: body.ID, threshold: body.Threshold, top: body.Top}
+	add := func(field, encoded string) error {
+		if encoded == "" {
+			return nil
+		}
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return badRequest("字段%s不是有效的base64: %v", field, err)
+		}
+		req.audios[field] = append(req.audios[field], data)
+		return nil
+	}
+	for field, encoded := range map[string]string{"audio": body.Audio, "audio1": body.Audio1, "audio2": body.Audio2} {
+		if err := add(field, encoded); err != nil {
+			return nil, err
+		}
+	}
+	for _, encoded := range body.Audios {
+		if err := add("audio", encoded); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
+
+// has 判断请求是否包含指定字段的音频
+func (r *request) has(field string) bool {
+	return len(r.audios[field]) > 0
+}
+
+// pcm 解码指定字段的第一段音频
+func (r *request) pcm(field string) ([]int16, error) {
+	all, err := r.pcms(field)
+	if err != nil {
+		return nil, err
+	}
+	return all[0], nil
+}
+
+// pcms 解码指定字段的全部音频，任一段超过时长上限时返回错误
+func (r *request) pcms(field string) ([][]int16, error) {
+	if !r.has(field) {
+		return nil, badRequest("缺少音频字段%s", field)
+	}
+	out := make([][]int16, len(r.audios[field]))
+	for i, data := range r.audios[field] {
+		pcm, err := audio.Decode(data)
+		if err != nil {
+			return nil, badRequest("解码音频字段%s失败: %v", field, err)
+		}
+		if len(pcm) == 0 {
+			return nil, badRequest("音频字段%s为空", field)
+		}
+		if seconds := float64(len(pcm)) / audio.SampleRate; r.maxSeconds > 0 && seconds > r.maxSeconds {
+			return nil, badRequest("音频字段%s时长%.1f秒，超过上限%g秒", field, seconds, r.maxSeconds)
+		}
+		out[i] = pcm
+	}
+	return out, nil
+}
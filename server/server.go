@@ -0,0 +1,362 @@
+// Package server 提供说话人识别的HTTP REST服务，基于Speaker会话池和说话人库：
+//
+//	POST   /embed          提取嵌入向量
+//	POST   /verify         验证两段音频（audio1、audio2）或音频与库中说话人（audio、id）是否为同一说话人
+//	POST   /enroll         从一段或多段音频注册说话人（id、audio）
+//	POST   /identify       在说话人库中辨认音频的说话人（audio、可选的top）
+//	DELETE /speakers/{id}  从说话人库中删除说话人
+//	GET    /stream         WebSocket流式验证，持续发送音频，服务端推送滚动分数和最终判决
+//
+// 请求可以是multipart/form-data上传（音频为文件字段，其余为普通字段），
+// 也可以是JSON（音频为base64编码）；音频格式为WAV或16kHz单声道int16原始PCM。
+// 响应均为JSON，出错时为{"error": "..."}。
+//
+// /stream的第一条消息为JSON控制消息（StreamControl），声明目标说话人和音频格式（例如浏览器采集的48kHz float32），
+// 之后的二进制消息为该格式的小端交织PCM，服务端转换为16kHz单声道后做流式验证，
+// 每次更新推送StreamScore，客户端发送{"type": "stop"}后推送StreamResult并关闭连接
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/seastart/3dspeaker-onnx-go/gallery"
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// Config 服务配置
+type Config struct {
+	VerifyThreshold   *float32 // 验证阈值，nil时使用Speaker配置的阈值（LoadThreshold或默认值）
+	IdentifyThreshold *float32 // 辨认时接受候选说话人的阈值，nil时与验证阈值相同
+	TopK              int      // 辨认默认返回的候选说话人数
+	MaxBodyBytes      int64    // 请求体大小上限（字节），也是WebSocket单条消息的大小上限
+	MaxAudioSeconds   float64  // 每段音频解码后的时长上限（秒），超过时返回400
+
+	Stream            speaker.StreamConfig     // WebSocket流式验证配置，控制消息中的窗口长度和更新间隔可覆盖
+	MaxStreamWindow   float64                  // 控制消息可覆盖的窗口长度上限（秒），更新间隔不超过窗口长度
+	StreamIdleTimeout time.Duration            // WebSocket连接超过这么长时间没有消息或不读取推送的消息时断开，释放占用的会话
+	CheckOrigin       func(*http.Request) bool // WebSocket握手时检查Origin，nil时只允许同源请求
+}
+
+// DefaultConfig 返回默认的服务配置
+func DefaultConfig() Config {
+	return Config{
+		TopK:              5,
+		MaxBodyBytes:      32 << 20,
+		MaxAudioSeconds:   300,
+		Stream:            speaker.DefaultStreamConfig(),
+		MaxStreamWindow:   30,
+		StreamIdleTimeout: 30 * time.Second,
+	}
+}
+
+// Server 说话人识别HTTP服务，实现http.Handler
+type Server struct {
+	pool     *speaker.Pool
+	store    *gallery.Store
+	cfg      Config
+	mux      *http.ServeMux
+	upgrader websocket.Upgrader
+}
+
+// New 创建服务
+//
+// 参数:
+//   - pool: Speaker会话池，并发请求数超过池大小时排队等待
+//   - store: 说话人库，已绑定时需与会话池的模型指纹一致
+//   - cfg: 服务配置
+func New(pool *speaker.Pool, store *gallery.Store, cfg Config) (*Server, error) {
+	if pool == nil || store == nil {
+		return nil, errors.New("会话池和说话人库不能为空")
+	}
+	if h := store.Header(); h.Fingerprint != "" && h.Fingerprint != pool.Fingerprint() {
+		return nil, fmt.Errorf("%w: 库为 %s，当前模型为 %s", gallery.ErrFingerprintMismatch, h.Fingerprint, pool.Fingerprint())
+	}
+	if cfg.TopK <= 0 {
+		cfg.TopK = DefaultConfig().TopK
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = DefaultConfig().MaxBodyBytes
+	}
+	if cfg.MaxAudioSeconds <= 0 {
+		cfg.MaxAudioSeconds = DefaultConfig().MaxAudioSeconds
+	}
+	if cfg.Stream.WindowSeconds <= 0 || cfg.Stream.IntervalSeconds <= 0 {
+		cfg.Stream = DefaultConfig().Stream
+	}
+	if cfg.MaxStreamWindow <= 0 {
+		cfg.MaxStreamWindow = DefaultConfig().MaxStreamWindow
+	}
+	if cfg.StreamIdleTimeout <= 0 {
+		cfg.StreamIdleTimeout = DefaultConfig().StreamIdleTimeout
+	}
+
+	s := &Server{pool: pool, store: store, cfg: cfg, mux: http.NewServeMux(), upgrader: websocket.Upgrader{CheckOrigin: cfg.CheckOrigin}}
+	s.mux.HandleFunc("POST /embed", s.handle(s.embed))
+	s.mux.HandleFunc("POST /verify", s.handle(s.verify))
+	s.mux.HandleFunc("POST /enroll", s.handle(s.enroll))
+	s.mux.HandleFunc("POST /identify", s.handle(s.identify))
+	s.mux.HandleFunc("DELETE /speakers/{id}", s.deleteSpeaker)
+	s.mux.HandleFunc("GET /stream", s.stream)
+	return s, nil
+}
+
+// ServeHTTP 实现http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handle 将解析请求体、调用处理函数和写出响应组合为http.HandlerFunc
+func (s *Server) handle(fn func(ctx context.Context, req *request) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, s.cfg.MaxBodyBytes)
+		req, err := parseRequest(r, s.cfg.MaxBodyBytes)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		req.maxSeconds = s.cfg.MaxAudioSeconds
+		resp, err := fn(r.Context(), req)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// writeJSON 写出JSON响应
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("写出响应失败: %v", err)
+	}
+}
+
+// writeError 按错误类型选择状态码并写出{"error": "..."}
+func writeError(w http.ResponseWriter, err error) {
+	writeJSON(w, statusOf(err), map[string]string{"error": err.Error()})
+}
+
+// statusOf 返回错误对应的HTTP状态码
+func statusOf(err error) int {
+	var maxErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxErr):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, errBadRequest), errors.Is(err, speaker.ErrInvalidStreamConfig):
+		return http.StatusBadRequest
+	case errors.Is(err, gallery.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, speaker.ErrLowQuality), errors.Is(err, speaker.ErrZeroNorm):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, gallery.ErrFingerprintMismatch), errors.Is(err, gallery.ErrDimensionMismatch):
+		return http.StatusConflict
+	case errors.Is(err, speaker.ErrPoolClosed), errors.Is(err, gallery.ErrClosed):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// EmbedResponse /embed的响应
+type EmbedResponse struct {
+	Dimension int       `json:"dimension"`
+	Norm      float32   `json:"norm"` // 模型原始输出的L2范数
+	Embedding []float32 `json:"embedding"`
+}
+
+// embed 提取嵌入向量
+func (s *Server) embed(ctx context.Context, req *request) (any, error) {
+	pcm, err := req.pcm("audio")
+	if err != nil {
+		return nil, err
+	}
+	var emb *speaker.Embedding
+	err = s.pool.Do(ctx, func(spk *speaker.Speaker) error {
+		emb, err = spk.ExtractEmbedding(pcm)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &EmbedResponse{Dimension: emb.GetEmbeddingDimension(), Norm: emb.Norm(), Embedding: emb.GetData()}, nil
+}
+
+// VerifyResponse /verify的响应
+type VerifyResponse struct {
+	ID        string  `json:"id,omitempty"` // 按说话人ID验证时的ID
+	Score     float32 `json:"score"`
+	Threshold float32 `json:"threshold"`
+	Accepted  bool    `json:"accepted"` // 是否判为同一说话人
+}
+
+// verify 验证两段音频，或音频与库中说话人是否为同一说话人
+func (s *Server) verify(ctx context.Context, req *request) (any, error) {
+	resp := &VerifyResponse{ID: req.id}
+	if req.has("audio1") || req.has("audio2") {
+		pcm1, err := req.pcm("audio1")
+		if err != nil {
+			return nil, err
+		}
+		pcm2, err := req.pcm("audio2")
+		if err != nil {
+			return nil, err
+		}
+		err = s.pool.Do(ctx, func(spk *speaker.Speaker) error {
+			resp.Threshold = spk.ResolveThreshold(req.threshold, s.cfg.VerifyThreshold)
+			resp.Accepted, resp.Score, err = spk.IsSameSpeakerWithThreshold(pcm1, pcm2, resp.Threshold)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return resp, nil
+	}
+
+	if req.id == "" {
+		return nil, badRequest("需要audio1和audio2，或者audio和id")
+	}
+	pcm, err := req.pcm("audio")
+	if err != nil {
+		return nil, err
+	}
+	enroll, ok := s.store.Get(req.id)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", gallery.ErrNotFound, req.id)
+	}
+	err = s.pool.Do(ctx, func(spk *speaker.Speaker) error {
+		resp.Threshold = spk.ResolveThreshold(req.threshold, s.cfg.VerifyThreshold)
+		resp.Accepted, resp.Score, err = spk.VerifyWithThreshold(enroll, pcm, resp.Threshold)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// EnrollResponse /enroll的响应
+type EnrollResponse struct {
+	ID       string  `json:"id"`
+	Audios   int     `json:"audios"`   // 注册音频段数
+	Quality  float64 `json:"quality"`  // 各段音频质量分数的最小值
+	Speakers int     `json:"speakers"` // 注册后库中的说话人数
+}
+
+// enroll 从一段或多段音频注册说话人，多段音频的嵌入向量取归一化平均，已存在的ID会被覆盖
+func (s *Server) enroll(ctx context.Context, req *request) (any, error) {
+	if req.id == "" {
+		return nil, badRequest("缺少说话人ID")
+	}
+	pcms, err := req.pcms("audio")
+	if err != nil {
+		return nil, err
+	}
+	resp := &EnrollResponse{ID: req.id, Audios: len(pcms), Quality: 1}
+	embs := make([]*speaker.Embedding, len(pcms))
+	err = s.pool.Do(ctx, func(spk *speaker.Speaker) error {
+		for i, pcm := range pcms {
+			if embs[i], err = spk.Enroll(pcm); err != nil {
+				return fmt.Errorf("第%d段注册音频: %w", i+1, err)
+			}
+			resp.Quality = min(resp.Quality, embs[i].Quality().Score)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	emb, err := speaker.AverageEmbeddings(embs)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.Bind(s.pool.Fingerprint(), emb.GetEmbeddingDimension()); err != nil {
+		return nil, err
+	}
+	if err := s.store.Put(req.id, emb); err != nil {
+		return nil, err
+	}
+	resp.Speakers = s.store.Len()
+	return resp, nil
+}
+
+// Candidate 辨认结果中的一个候选说话人
+type Candidate struct {
+	ID       string  `json:"id"`
+	Score    float32 `json:"score"`
+	Accepted bool    `json:"accepted"` // 分数是否达到阈值
+}
+
+// IdentifyResponse /identify的响应
+type IdentifyResponse struct {
+	ID         string      `json:"id"` // 分数最高且达到阈值的说话人，没有时为空
+	Threshold  float32     `json:"threshold"`
+	Candidates []Candidate `json:"candidates"` // 按分数从高到低排序
+}
+
+// identify 在说话人库中辨认音频的说话人
+func (s *Server) identify(ctx context.Context, req *request) (any, error) {
+	pcm, err := req.pcm("audio")
+	if err != nil {
+		return nil, err
+	}
+	top := req.top
+	if top <= 0 {
+		top = s.cfg.TopK
+	}
+	entries := s.store.Snapshot()
+
+	resp := &IdentifyResponse{Candidates: []Candidate{}}
+	err = s.pool.Do(ctx, func(spk *speaker.Speaker) error {
+		resp.Threshold = spk.ResolveThreshold(req.threshold, s.cfg.IdentifyThreshold, s.cfg.VerifyThreshold)
+		probe, err := spk.ExtractEmbedding(pcm)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			score, err := spk.CompareEmbeddings(e.Embedding, probe)
+			if err != nil {
+				return fmt.Errorf("与说话人 %s 打分失败: %w", e.ID, err)
+			}
+			resp.Candidates = append(resp.Candidates, Candidate{ID: e.ID, Score: score, Accepted: score >= resp.Threshold})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(resp.Candidates, func(i, j int) bool { return resp.Candidates[i].Score > resp.Candidates[j].Score })
+	if len(resp.Candidates) > top {
+		resp.Candidates = resp.Candidates[:top]
+	}
+	if len(resp.Candidates) > 0 && resp.Candidates[0].Accepted {
+		resp.ID = resp.Candidates[0].ID
+	}
+	return resp, nil
+}
+
+// deleteSpeaker 从说话人库中删除说话人，成功时返回204
+func (s *Server) deleteSpeaker(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := s.store.Delete(id); err != nil {
+		if errors.Is(err, gallery.ErrNotFound) {
+			err = fmt.Errorf("%w: %s", err, id)
+		}
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
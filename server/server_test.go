@@ -0,0 +1,222 @@
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/seastart/3dspeaker-onnx-go/gallery"
+	"github.com/seastart/3dspeaker-onnx-go/internal/testaudio"
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// jsonBody 构造JSON请求
+func jsonBody(t *testing.T, method, path string, body any) *http.Request {
+	t.Helper()
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+// multipartBody 构造multipart上传请求
+func multipartBody(t *testing.T, path string, files map[string][][]byte, fields map[string]string) *http.Request {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for field, list := range files {
+		for i, data := range list {
+			fw, err := mw.CreateFormFile(field, field+string(rune('a'+i))+".pcm")
+			if err != nil {
+				t.Fatal(err)
+			}
+			fw.Write(data)
+		}
+	}
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, path, &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+// serve 执行请求并解析JSON响应
+func serve(t *testing.T, h http.Handler, req *http.Request, out any) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if out != nil && rec.Header().Get("Content-Type") == "application/json" {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("解析响应失败: %v: %s", err, rec.Body.String())
+		}
+	}
+	return rec.Code
+}
+
+// newTestServer 创建使用给定会话池和临时说话人库的服务
+func newTestServer(t *testing.T, pool *speaker.Pool) (*Server, *gallery.Store) {
+	t.Helper()
+	store, err := gallery.Open(filepath.Join(t.TempDir(), "test.gallery"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	s, err := New(pool, store, DefaultConfig())
+	if err != nil {
+		t.Fatalf("创建服务失败: %v", err)
+	}
+	return s, store
+}
+
+// TestParseRequest 测试multipart和JSON请求解析为相同的内容
+func TestParseRequest(t *testing.T) {
+	a, b := testaudio.PCMBytes(0.1, 200), testaudio.PCMBytes(0.1, 300)
+
+	req, err := parseRequest(multipartBody(t, "/enroll", map[string][][]byte{"audio": {a, b}}, map[string]string{"id": "alice", "threshold": "0.6", "top": "3"}), 1<<20)
+	if err != nil {
+		t.Fatalf("解析multipart失败: %v", err)
+	}
+	if req.id != "alice" || req.threshold == nil || *req.threshold != 0.6 || req.top != 3 || len(req.audios["audio"]) != 2 || !bytes.Equal(req.audios["audio"][1], b) {
+		t.Fatalf("multipart解析结果错误: %+v", req)
+	}
+
+	body := map[string]any{
+		"id":     "alice",
+		"audio":  base64.StdEncoding.EncodeToString(a),
+		"audios": []string{base64.StdEncoding.EncodeToString(b)},
+	}
+	req, err = parseRequest(jsonBody(t, http.MethodPost, "/enroll", body), 1<<20)
+	if err != nil {
+		t.Fatalf("解析JSON失败: %v", err)
+	}
+	if req.id != "alice" || req.threshold != nil || len(req.audios["audio"]) != 2 || !bytes.Equal(req.audios["audio"][0], a) {
+		t.Fatalf("JSON解析结果错误: %+v", req)
+	}
+	pcms, err := req.pcms("audio")
+	if err != nil || len(pcms) != 2 || len(pcms[0]) != 1600 {
+		t.Fatalf("解码音频失败: %v", err)
+	}
+
+	if _, err := parseRequest(jsonBody(t, http.MethodPost, "/embed", map[string]string{"audio": "不是base64"}), 1<<20); err == nil {
+		t.Fatal("无效的base64应返回错误")
+	}
+}
+
+// TestThresholds 测试请求中的0和负数阈值不被当作未指定
+func TestThresholds(t *testing.T) {
+	for _, threshold := range []float32{0, -1.5} {
+		req, err := parseRequest(jsonBody(t, http.MethodPost, "/identify", map[string]any{"threshold": threshold}), 1<<20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := (&speaker.Speaker{}).ResolveThreshold(req.threshold, nil); got != threshold {
+			t.Fatalf("请求中的阈值%v应优先，实际为%v", threshold, got)
+		}
+	}
+}
+
+// TestServerErrors 测试不需要模型的请求和错误状态码
+func TestServerErrors(t *testing.T) {
+	// 未加载模型的实例提取嵌入向量时必然失败
+	pool, err := speaker.NewPool(1, func() (*speaker.Speaker, error) { return &speaker.Speaker{}, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	s, store := newTestServer(t, pool)
+	s.cfg.MaxAudioSeconds = 2
+	store.Put("alice", speaker.NewEmbedding([]float32{1, 0, 0}))
+
+	var errResp map[string]string
+	tests := []struct {
+		name   string
+		req    *http.Request
+		status int
+	}{
+		{"不支持的Content-Type", httptest.NewRequest(http.MethodPost, "/embed", bytes.NewReader(testaudio.PCMBytes(1, 200))), http.StatusBadRequest},
+		{"缺少音频", jsonBody(t, http.MethodPost, "/embed", map[string]string{}), http.StatusBadRequest},
+		{"验证缺少ID", jsonBody(t, http.MethodPost, "/verify", map[string]string{"audio": base64.StdEncoding.EncodeToString(testaudio.PCMBytes(1, 200))}), http.StatusBadRequest},
+		{"验证缺少audio2", jsonBody(t, http.MethodPost, "/verify", map[string]string{"audio1": base64.StdEncoding.EncodeToString(testaudio.PCMBytes(1, 200))}), http.StatusBadRequest},
+		{"验证不存在的说话人", jsonBody(t, http.MethodPost, "/verify", map[string]string{"id": "bob", "audio": base64.StdEncoding.EncodeToString(testaudio.PCMBytes(1, 200))}), http.StatusNotFound},
+		{"音频超过时长上限", jsonBody(t, http.MethodPost, "/embed", map[string]string{"audio": base64.StdEncoding.EncodeToString(testaudio.PCMBytes(3, 200))}), http.StatusBadRequest},
+		{"注册缺少ID", jsonBody(t, http.MethodPost, "/enroll", map[string]string{"audio": base64.StdEncoding.EncodeToString(testaudio.PCMBytes(1, 200))}), http.StatusBadRequest},
+		{"提取失败", jsonBody(t, http.MethodPost, "/embed", map[string]string{"audio": base64.StdEncoding.EncodeToString(testaudio.PCMBytes(1, 200))}), http.StatusInternalServerError},
+		{"方法不匹配", httptest.NewRequest(http.MethodGet, "/embed", nil), http.StatusMethodNotAllowed},
+		{"删除不存在的说话人", httptest.NewRequest(http.MethodDelete, "/speakers/bob", nil), http.StatusNotFound},
+		{"删除说话人", httptest.NewRequest(http.MethodDelete, "/speakers/alice", nil), http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		errResp = nil
+		if status := serve(t, s, tt.req, &errResp); status != tt.status {
+			t.Errorf("%s: 状态码应为%d，实际为%d: %v", tt.name, tt.status, status, errResp)
+		}
+		if tt.status >= 400 && tt.status != http.StatusMethodNotAllowed && errResp["error"] == "" {
+			t.Errorf("%s: 错误响应应包含error字段", tt.name)
+		}
+	}
+	if store.Len() != 0 {
+		t.Fatalf("删除后库应为空，实际有%d个说话人", store.Len())
+	}
+
+	// 说话人库与模型指纹不一致时拒绝创建服务
+	other, _ := gallery.Open(filepath.Join(t.TempDir(), "other.gallery"))
+	defer other.Close()
+	other.Bind("another-model", 3)
+	if _, err := New(pool, other, DefaultConfig()); err == nil {
+		t.Fatal("模型指纹不一致时应返回错误")
+	}
+}
+
+// TestServerFlow 测试注册、验证、辨认的完整流程
+func TestServerFlow(t *testing.T) {
+	modelPath := "../../onnxruntime/model.onnx"
+	configPath := "../../onnxruntime/assets/fbank_config.json"
+	pool, err := speaker.NewPoolFromModel(modelPath, configPath, 2)
+	if err != nil {
+		t.Skipf("跳过测试：无法加载模型: %v", err)
+	}
+	defer pool.Close()
+	s, _ := newTestServer(t, pool)
+
+	alice, bob := testaudio.PCMBytes(3, 220), testaudio.PCMBytes(3, 330)
+	var enroll EnrollResponse
+	if status := serve(t, s, multipartBody(t, "/enroll", map[string][][]byte{"audio": {alice, alice}}, map[string]string{"id": "alice"}), &enroll); status != http.StatusOK {
+		t.Fatalf("注册失败: %d", status)
+	}
+	if enroll.ID != "alice" || enroll.Audios != 2 || enroll.Speakers != 1 {
+		t.Fatalf("注册响应错误: %+v", enroll)
+	}
+	serve(t, s, jsonBody(t, http.MethodPost, "/enroll", map[string]string{"id": "bob", "audio": base64.StdEncoding.EncodeToString(bob)}), nil)
+
+	var verify VerifyResponse
+	status := serve(t, s, jsonBody(t, http.MethodPost, "/verify", map[string]any{"id": "alice", "audio": base64.StdEncoding.EncodeToString(alice), "threshold": 0.5}), &verify)
+	if status != http.StatusOK || !verify.Accepted || verify.Threshold != 0.5 || verify.Score < 0.99 {
+		t.Fatalf("相同音频应验证通过: %d %+v", status, verify)
+	}
+	verify = VerifyResponse{}
+	status = serve(t, s, multipartBody(t, "/verify", map[string][][]byte{"audio1": {alice}, "audio2": {alice}}, nil), &verify)
+	if status != http.StatusOK || !verify.Accepted || verify.ID != "" {
+		t.Fatalf("两段相同音频应验证通过: %d %+v", status, verify)
+	}
+
+	var embed EmbedResponse
+	if status := serve(t, s, jsonBody(t, http.MethodPost, "/embed", map[string]string{"audio": base64.StdEncoding.EncodeToString(alice)}), &embed); status != http.StatusOK || len(embed.Embedding) != embed.Dimension {
+		t.Fatalf("提取嵌入向量失败: %d %+v", status, embed)
+	}
+
+	var identify IdentifyResponse
+	status = serve(t, s, jsonBody(t, http.MethodPost, "/identify", map[string]any{"audio": base64.StdEncoding.EncodeToString(bob), "top": 1}), &identify)
+	if status != http.StatusOK || len(identify.Candidates) != 1 || identify.Candidates[0].ID != "bob" {
+		t.Fatalf("应辨认为bob: %d %+v", status, identify)
+	}
+}
@@ -0,0 +1,257 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/seastart/3dspeaker-onnx-go/audio"
+	"github.com/seastart/3dspeaker-onnx-go/gallery"
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// StreamControl WebSocket流式验证中客户端发送的JSON控制消息
+//
+// 第一条消息必须为start，声明目标说话人和之后二进制消息中音频的格式；
+// 发送stop表示音频结束，服务端返回最终判决后关闭连接
+type StreamControl struct {
+	Type            string   `json:"type"`             // start或stop
+	ID              string   `json:"id"`               // 库中的目标说话人
+	SampleRate      int      `json:"sample_rate"`      // 输入采样率，例如浏览器麦克风的48000
+	Channels        int      `json:"channels"`         // 声道数，多声道时样本交织，默认1
+	Encoding        string   `json:"encoding"`         // 小端样本格式：float32（默认，[-1,1]范围）或int16
+	Threshold       *float32 `json:"threshold"`        // 覆盖服务配置的验证阈值，可以为0或负数
+	WindowSeconds   float64  `json:"window_seconds"`   // 覆盖服务配置的窗口长度，不超过Config.MaxStreamWindow
+	IntervalSeconds float64  `json:"interval_seconds"` // 覆盖服务配置的更新间隔，不超过窗口长度
+}
+
+// StreamReady start之后服务端返回的确认消息
+type StreamReady struct {
+	Type      string  `json:"type"` // ready
+	ID        string  `json:"id"`
+	Threshold float32 `json:"threshold"`
+}
+
+// StreamScore 一次滚动验证的分数
+type StreamScore struct {
+	Type         string  `json:"type"` // score
+	Time         float64 `json:"time"` // 到目前为止输入的音频时长（秒）
+	Score        float32 `json:"score"`
+	RunningScore float32 `json:"running_score"`
+	Accepted     bool    `json:"accepted"` // 最近窗口的分数是否达到阈值
+}
+
+// StreamResult 音频结束后的最终判决
+type StreamResult struct {
+	Type         string  `json:"type"` // result
+	Duration     float64 `json:"duration"`
+	Updates      int     `json:"updates"` // 分数更新次数，为0时音频太短，不予接受
+	RunningScore float32 `json:"running_score"`
+	Accepted     bool    `json:"accepted"` // 累积分数是否达到阈值
+}
+
+// StreamError 出错时服务端发送的消息，之后关闭连接
+type StreamError struct {
+	Type   string `json:"type"` // error
+	Status int    `json:"status"`
+	Error  string `json:"error"`
+}
+
+// format 返回控制消息声明的音频格式
+func (c *StreamControl) format() (audio.Format, error) {
+	format := audio.Format{SampleRate: c.SampleRate, NumChannels: max(c.Channels, 1)}
+	switch c.Encoding {
+	case "", "float32":
+		format.BitsPerSample, format.Float = 32, true
+	case "int16":
+		format.BitsPerSample = 16
+	default:
+		return format, badRequest("不支持的样本格式: %s，请使用float32或int16", c.Encoding)
+	}
+	if c.SampleRate <= 0 {
+		return format, badRequest("缺少采样率")
+	}
+	if err := format.Validate(); err != nil {
+		return format, badRequest("%v", err)
+	}
+	return format, nil
+}
+
+// streamConfig 返回应用客户端覆盖值后的流式验证配置，按MaxStreamWindow限制（见StreamConfig.Clamp），
+// 不足一个样本时返回错误
+func (s *Server) streamConfig(window, interval float64) (speaker.StreamConfig, error) {
+	cfg := s.cfg.Stream
+	if window > 0 {
+		cfg.WindowSeconds = window
+	}
+	if interval > 0 {
+		cfg.IntervalSeconds = interval
+	}
+	cfg = cfg.Clamp(s.cfg.MaxStreamWindow)
+	return cfg, cfg.Validate()
+}
+
+// stream WebSocket流式验证：客户端持续发送音频，服务端在每次更新时推送分数，结束后推送最终判决
+func (s *Server) stream(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade已经写出了HTTP错误响应
+		return
+	}
+	defer conn.Close()
+	conn.SetReadLimit(s.cfg.MaxBodyBytes)
+	closeStream(conn, s.runStream(r.Context(), conn))
+}
+
+// readMessage 读取一条消息，超过空闲超时没有消息或消息超过大小上限时返回错误
+func (s *Server) readMessage(conn *websocket.Conn) (int, []byte, error) {
+	conn.SetReadDeadline(time.Now().Add(s.cfg.StreamIdleTimeout))
+	mt, data, err := conn.ReadMessage()
+	var netErr net.Error
+	switch {
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return 0, nil, badRequest("超过%v没有收到消息", s.cfg.StreamIdleTimeout)
+	case errors.Is(err, websocket.ErrReadLimit):
+		return 0, nil, &http.MaxBytesError{Limit: s.cfg.MaxBodyBytes}
+	}
+	return mt, data, err
+}
+
+// writeMessage 写出一条JSON消息，客户端超过空闲超时不读取时返回错误，避免长期占用会话
+func (s *Server) writeMessage(conn *websocket.Conn, v any) error {
+	conn.SetWriteDeadline(time.Now().Add(s.cfg.StreamIdleTimeout))
+	return conn.WriteJSON(v)
+}
+
+// runStream 处理一个WebSocket连接，正常结束时返回nil
+func (s *Server) runStream(ctx context.Context, conn *websocket.Conn) error {
+	mt, data, err := s.readMessage(conn)
+	if err != nil {
+		return err
+	}
+	var start StreamControl
+	if mt != websocket.TextMessage || json.Unmarshal(data, &start) != nil || start.Type != "start" {
+		return badRequest("第一条消息必须为JSON控制消息start")
+	}
+	format, err := start.format()
+	if err != nil {
+		return err
+	}
+	conv, err := audio.NewConverter(format)
+	if err != nil {
+		return badRequest("%v", err)
+	}
+	if start.ID == "" {
+		return badRequest("缺少说话人ID")
+	}
+	target, ok := s.store.Get(start.ID)
+	if !ok {
+		return fmt.Errorf("%w: %s", gallery.ErrNotFound, start.ID)
+	}
+	cfg, err := s.streamConfig(start.WindowSeconds, start.IntervalSeconds)
+	if err != nil {
+		return err
+	}
+
+	// 整个连接期间占用一个会话
+	spk, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.pool.Release(spk)
+	threshold := spk.ResolveThreshold(start.Threshold, s.cfg.VerifyThreshold)
+	st, err := spk.NewStream(target, cfg)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	var pending []StreamScore
+	updates := 0
+	st.OnUpdate(func(u speaker.StreamUpdate) {
+		updates++
+		pending = append(pending, StreamScore{Type: "score", Time: u.Time, Score: u.Score, RunningScore: u.RunningScore, Accepted: u.Score >= threshold})
+	})
+	// write 输入转换后的样本，并推送产生的分数更新
+	write := func(pcm []int16) error {
+		if err := st.WriteSamples(pcm); err != nil {
+			return err
+		}
+		for _, msg := range pending {
+			if err := s.writeMessage(conn, msg); err != nil {
+				return err
+			}
+		}
+		pending = pending[:0]
+		return nil
+	}
+
+	if err := s.writeMessage(conn, StreamReady{Type: "ready", ID: start.ID, Threshold: threshold}); err != nil {
+		return err
+	}
+	samples := 0
+	for {
+		mt, data, err := s.readMessage(conn)
+		if err != nil {
+			return err
+		}
+		if mt == websocket.BinaryMessage {
+			pcm, err := conv.Write(data)
+			if err != nil {
+				return badRequest("%v", err)
+			}
+			samples += len(pcm)
+			if err := write(pcm); err != nil {
+				return err
+			}
+			continue
+		}
+		var ctrl StreamControl
+		if err := json.Unmarshal(data, &ctrl); err != nil {
+			return badRequest("解析控制消息失败: %v", err)
+		}
+		if ctrl.Type != "stop" {
+			return badRequest("不支持的控制消息: %s", ctrl.Type)
+		}
+		break
+	}
+
+	pcm := conv.Flush()
+	samples += len(pcm)
+	if err := write(pcm); err != nil {
+		return err
+	}
+	result := StreamResult{Type: "result", Duration: float64(samples) / audio.SampleRate, Updates: updates}
+	if u, ok := st.Latest(); ok {
+		result.RunningScore = u.RunningScore
+		result.Accepted = u.RunningScore >= threshold
+	}
+	return s.writeMessage(conn, result)
+}
+
+// closeStream 正常结束时发送关闭帧，出错时先发送StreamError；客户端已关闭连接时不再写出
+func closeStream(conn *websocket.Conn, err error) {
+	deadline := time.Now().Add(time.Second)
+	if err == nil {
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), deadline)
+		return
+	}
+	var closeErr *websocket.CloseError
+	if errors.As(err, &closeErr) {
+		return
+	}
+	status := statusOf(err)
+	conn.SetWriteDeadline(deadline)
+	conn.WriteJSON(StreamError{Type: "error", Status: status, Error: err.Error()})
+	code := websocket.ClosePolicyViolation
+	if status >= http.StatusInternalServerError {
+		code = websocket.CloseInternalServerErr
+	}
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, http.StatusText(status)), deadline)
+}
@@ -0,0 +1,181 @@
+package server
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/seastart/3dspeaker-onnx-go/audio"
+	"github.com/seastart/3dspeaker-onnx-go/internal/testaudio"
+	"github.com/seastart/3dspeaker-onnx-go/speaker"
+)
+
+// float32Bytes 生成一段小端float32单声道PCM，模拟浏览器采集的音频
+func float32Bytes(seconds float64, rate int, freq float64) []byte {
+	n := int(seconds * float64(rate))
+	data := make([]byte, 4*n)
+	for i := 0; i < n; i++ {
+		v := float32(0.25 * math.Sin(2*math.Pi*freq*float64(i)/float64(rate)))
+		binary.LittleEndian.PutUint32(data[4*i:], math.Float32bits(v))
+	}
+	return data
+}
+
+// dialStream 连接/stream并发送控制消息
+func dialStream(t *testing.T, ts *httptest.Server, start any) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/stream", nil)
+	if err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if err := conn.WriteJSON(start); err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+// readStream 读取一条服务端消息，返回消息类型和原始内容
+func readStream(t *testing.T, conn *websocket.Conn) (string, []byte) {
+	t.Helper()
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("读取消息失败: %v", err)
+	}
+	var msg struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		t.Fatalf("解析消息失败: %v: %s", err, data)
+	}
+	return msg.Type, data
+}
+
+// TestStreamErrors 测试WebSocket流式验证的错误消息和Origin检查
+func TestStreamErrors(t *testing.T) {
+	// 未加载模型的实例创建流式验证时必然失败
+	pool, err := speaker.NewPool(1, func() (*speaker.Speaker, error) { return &speaker.Speaker{}, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	s, store := newTestServer(t, pool)
+	store.Put("alice", speaker.NewEmbedding([]float32{1, 0, 0}))
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	tests := []struct {
+		name   string
+		start  any
+		status int
+	}{
+		{"第一条消息不是start", StreamControl{Type: "stop"}, http.StatusBadRequest},
+		{"缺少采样率", StreamControl{Type: "start", ID: "alice"}, http.StatusBadRequest},
+		{"不支持的样本格式", StreamControl{Type: "start", ID: "alice", SampleRate: 48000, Encoding: "mp3"}, http.StatusBadRequest},
+		{"采样率超出范围", StreamControl{Type: "start", ID: "alice", SampleRate: 1}, http.StatusBadRequest},
+		{"声道数超出范围", StreamControl{Type: "start", ID: "alice", SampleRate: 48000, Channels: 1 << 20}, http.StatusBadRequest},
+		{"说话人不存在", StreamControl{Type: "start", ID: "bob", SampleRate: 48000}, http.StatusNotFound},
+		{"更新间隔不足一个样本", StreamControl{Type: "start", ID: "alice", SampleRate: 48000, IntervalSeconds: 1e-5}, http.StatusBadRequest},
+		{"创建流式验证失败", StreamControl{Type: "start", ID: "alice", SampleRate: 48000}, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		conn := dialStream(t, ts, tt.start)
+		typ, data := readStream(t, conn)
+		var msg StreamError
+		json.Unmarshal(data, &msg)
+		if typ != "error" || msg.Status != tt.status || msg.Error == "" {
+			t.Errorf("%s: 应返回状态%d的错误消息，实际为%s", tt.name, tt.status, data)
+		}
+		if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.ClosePolicyViolation, websocket.CloseInternalServerErr) {
+			t.Errorf("%s: 错误后应关闭连接: %v", tt.name, err)
+		}
+	}
+
+	// 客户端覆盖的窗口长度和更新间隔被限制在上限内
+	cfg, err := s.streamConfig(1e12, 1e12)
+	if err != nil || cfg.WindowSeconds != s.cfg.MaxStreamWindow || cfg.IntervalSeconds != cfg.WindowSeconds {
+		t.Fatalf("窗口长度应被限制为%v: %+v %v", s.cfg.MaxStreamWindow, cfg, err)
+	}
+
+	// 默认只允许同源请求
+	header := http.Header{"Origin": {"http://example.com"}}
+	if _, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/stream", header); err == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("跨域请求应被拒绝: %v", err)
+	}
+}
+
+// TestStreamFlow 测试48kHz float32音频的流式验证
+func TestStreamFlow(t *testing.T) {
+	modelPath := "../../onnxruntime/model.onnx"
+	configPath := "../../onnxruntime/assets/fbank_config.json"
+	pool, err := speaker.NewPoolFromModel(modelPath, configPath, 1)
+	if err != nil {
+		t.Skipf("跳过测试：无法加载模型: %v", err)
+	}
+	defer pool.Close()
+	s, store := newTestServer(t, pool)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	enroll, err := audio.Decode(testaudio.PCMBytes(3, 220))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = pool.Do(t.Context(), func(spk *speaker.Speaker) error {
+		emb, err := spk.ExtractEmbedding(enroll)
+		if err != nil {
+			return err
+		}
+		return store.Put("alice", emb)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	threshold := float32(0.5)
+	conn := dialStream(t, ts, StreamControl{Type: "start", ID: "alice", SampleRate: 48000, Threshold: &threshold})
+	if typ, data := readStream(t, conn); typ != "ready" {
+		t.Fatalf("应返回ready: %s", data)
+	}
+	// 按浏览器常见的20ms分块发送，边发送边接收分数更新
+	data := float32Bytes(3, 48000, 220)
+	done := make(chan error, 1)
+	go func() {
+		for i := 0; i < len(data); i += 3840 {
+			if err := conn.WriteMessage(websocket.BinaryMessage, data[i:min(i+3840, len(data))]); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- conn.WriteJSON(StreamControl{Type: "stop"})
+	}()
+
+	scores := 0
+	var result StreamResult
+	for result.Type == "" {
+		typ, msg := readStream(t, conn)
+		switch typ {
+		case "score":
+			scores++
+		case "result":
+			json.Unmarshal(msg, &result)
+		default:
+			t.Fatalf("意外的消息: %s", msg)
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if scores == 0 || result.Updates != scores || math.Abs(result.Duration-3) > 0.01 {
+		t.Fatalf("最终结果错误: %d次分数更新 %+v", scores, result)
+	}
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Fatalf("结束后应正常关闭连接: %v", err)
+	}
+}
@@ -35,13 +35,13 @@ func init() {
 	}
 
 	// 如果库不存在，尝试构建
-	fmt.Printf("正在为 %s/%s 构建C++库...\n", osType, archType)
+	fmt.Fprintf(os.Stderr, "正在为 %s/%s 构建C++库...\n", osType, archType)
 	if err := buildLib(); err != nil {
-		fmt.Printf("库构建失败: %v\n", err)
-		fmt.Println("请查看 https://github.com/seastart/3dspeaker-onnx-go 获取预编译库或手动构建说明")
+		fmt.Fprintf(os.Stderr, "库构建失败: %v\n", err)
+		fmt.Fprintln(os.Stderr, "请查看 https://github.com/seastart/3dspeaker-onnx-go 获取预编译库或手动构建说明")
 		// 不直接退出，让用户决定如何处理
 	} else {
-		fmt.Println("C++库构建成功")
+		fmt.Fprintln(os.Stderr, "C++库构建成功")
 	}
 }
 
@@ -84,7 +84,7 @@ func buildLib() error {
 	
 	// 执行make命令
 	cmd := exec.Command("make", "-C", filepath.Join(rootDir))
-	cmd.Stdout = os.Stdout
+	cmd.Stdout = os.Stderr
 	cmd.Stderr = os.Stderr
 	
 	if err := cmd.Run(); err != nil {
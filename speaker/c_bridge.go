@@ -22,10 +22,12 @@ package speaker
 */
 import "C"
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"math"
+	"io"
 	"os"
 	"runtime"
 	"unsafe"
@@ -33,7 +35,10 @@ import (
 
 // ModelHandle 封装了C语言的模型句柄
 type ModelHandle struct {
-	handle C.SpeakerModelHandle
+	handle      C.SpeakerModelHandle
+	fingerprint string
+	dim         int
+	fbank       FbankInfo
 }
 
 // FrameExtractionOptions 帧提取选项
@@ -100,7 +105,7 @@ func LoadModel(onnxModelPath, fbankConfigPath string) (m *ModelHandle, err error
 		config, err = loadFbankConfig(fbankConfigPath)
 		if err != nil {
 			// 配置文件加载失败，使用默认参数
-			fmt.Printf("警告: 无法加载配置文件 %s: %v, 将使用默认参数\n", fbankConfigPath, err)
+			fmt.Fprintf(os.Stderr, "警告: 无法加载配置文件 %s: %v, 将使用默认参数\n", fbankConfigPath, err)
 		}
 	}
 
@@ -112,6 +117,11 @@ func LoadModel(onnxModelPath, fbankConfigPath string) (m *ModelHandle, err error
 // onnxModelPath: ONNX模型文件路径
 // config: FBANK特征提取配置
 func LoadModelWithParams(onnxModelPath string, config FbankConfig) (*ModelHandle, error) {
+	fingerprint, err := ModelFingerprint(onnxModelPath, config)
+	if err != nil {
+		return nil, fmt.Errorf("计算模型指纹失败: %w", err)
+	}
+
 	cOnnxPath := C.CString(onnxModelPath)
 	defer C.free(unsafe.Pointer(cOnnxPath))
 
@@ -130,8 +140,8 @@ func LoadModelWithParams(onnxModelPath string, config FbankConfig) (*ModelHandle
 		usePower = 0
 	}
 
-	// 打印使用的关键参数
-	fmt.Printf("使用传入的参数创建FbankComputer [采样率=%v, 帧移=%vms, 帧长=%vms, 滤波器数=%v, 使用对数=%v, 抖动=%v, 使用功率谱=%v]\n",
+	// 打印使用的关键参数，诊断信息输出到标准错误，不影响调用方的标准输出
+	fmt.Fprintf(os.Stderr, "使用传入的参数创建FbankComputer [采样率=%v, 帧移=%vms, 帧长=%vms, 滤波器数=%v, 使用对数=%v, 抖动=%v, 使用功率谱=%v]\n",
 		config.FrameExtractionOptions.SampleFreq,
 		config.FrameExtractionOptions.FrameShiftMs,
 		config.FrameExtractionOptions.FrameLengthMs,
@@ -156,13 +166,57 @@ func LoadModelWithParams(onnxModelPath string, config FbankConfig) (*ModelHandle
 		return nil, errors.New("加载模型失败")
 	}
 
-	m := &ModelHandle{handle: handle}
+	dim := int(C.GetEmbeddingDimension(handle))
+	if dim <= 0 {
+		C.FreeSpeakerModel(handle)
+		return nil, errors.New("获取嵌入向量维度失败")
+	}
+
+	var numBins, frameLength, frameShift C.int
+	if C.GetFbankInfo(handle, &numBins, &frameLength, &frameShift) != 1 {
+		C.FreeSpeakerModel(handle)
+		return nil, errors.New("获取FBANK特征参数失败")
+	}
+
+	m := &ModelHandle{
+		handle:      handle,
+		fingerprint: fingerprint,
+		dim:         dim,
+		fbank:       FbankInfo{NumBins: int(numBins), FrameLength: int(frameLength), FrameShift: int(frameShift)},
+	}
 	// 注册模型释放函数
 	runtime.SetFinalizer(m, freeModel)
 
 	return m, nil
 }
 
+// ModelFingerprint 计算模型指纹
+// 指纹由ONNX模型文件内容和FBANK特征配置共同决定，任一变化都会产生不同的嵌入空间，
+// 因此持久化的嵌入向量需要记录指纹，以免与不兼容的模型混用
+func ModelFingerprint(onnxModelPath string, config FbankConfig) (string, error) {
+	f, err := os.Open(onnxModelPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	configData, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	h.Write(configData)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Fingerprint 返回模型指纹，参见ModelFingerprint
+func (m *ModelHandle) Fingerprint() string {
+	return m.fingerprint
+}
+
 // loadFbankConfig 从JSON文件加载FBANK配置
 func loadFbankConfig(configPath string) (FbankConfig, error) {
 	// 读取配置文件
@@ -174,7 +228,7 @@ func loadFbankConfig(configPath string) (FbankConfig, error) {
 	// 直接解析JSON到结构体
 	var config FbankConfig
 	if err := json.Unmarshal(data, &config); err != nil {
-		fmt.Printf("解析JSON到结构体失败: %v，尝试兼容模式\n", err)
+		fmt.Fprintf(os.Stderr, "解析JSON到结构体失败: %v，尝试兼容模式\n", err)
 
 		// 尝试兼容模式解析
 		var jsonData map[string]interface{}
@@ -245,7 +299,7 @@ func loadFbankConfig(configPath string) (FbankConfig, error) {
 	}
 
 	// 打印加载的关键参数
-	fmt.Printf("成功加载配置: 采样率=%v, 帧移=%v, 帧长=%v, 抖动=%v, 滤波器数=%v, 使用对数=%v\n",
+	fmt.Fprintf(os.Stderr, "成功加载配置: 采样率=%v, 帧移=%v, 帧长=%v, 抖动=%v, 滤波器数=%v, 使用对数=%v\n",
 		config.FrameExtractionOptions.SampleFreq,
 		config.FrameExtractionOptions.FrameShiftMs,
 		config.FrameExtractionOptions.FrameLengthMs,
@@ -273,142 +327,111 @@ func (m *ModelHandle) Close() {
 	}
 }
 
+// ErrZeroNorm 模型输出的嵌入向量范数为0，无法归一化，也无法用于比较（例如输入为纯静音）
+var ErrZeroNorm = errors.New("嵌入向量范数为0")
+
 // Embedding 表示说话人嵌入向量
 type Embedding struct {
-	data []float32
+	data    []float32
+	norm    float32
+	quality *Quality
 }
 
-// ExtractEmbedding 从PCM数据中提取说话人嵌入向量[必须是16khz单声道音频]
-// pcmData: PCM数据（int16格式）
-func (m *ModelHandle) ExtractEmbedding(pcmData []int16) (*Embedding, error) {
-	if m.handle == nil {
-		return nil, errors.New("模型已关闭或未初始化")
-	}
-
-	if len(pcmData) == 0 {
-		return nil, errors.New("PCM数据为空")
-	}
-
-	// 注意: 采样率转换已在readPCMFile中完成，这里直接使用转换后的数据
-
-	var cEmbedding *C.float
-	var cEmbeddingSize C.int
-
-	// 调用C函数提取嵌入向量
-	ret := C.ExtractEmbedding(
-		m.handle,
-		(*C.short)(unsafe.Pointer(&pcmData[0])),
-		C.int(len(pcmData)),
-		&cEmbedding,
-		&cEmbeddingSize,
-	)
-
-	if ret == 0 || cEmbedding == nil {
-		return nil, errors.New("提取嵌入向量失败")
-	}
-
-	// 创建Go切片并复制数据
-	embeddingSize := int(cEmbeddingSize)
-	goEmbedding := make([]float32, embeddingSize)
-
-	// 从C内存复制到Go内存
-	embeddingPtr := unsafe.Pointer(cEmbedding)
-	for i := 0; i < embeddingSize; i++ {
-		ptr := (*C.float)(unsafe.Pointer(uintptr(embeddingPtr) + uintptr(i)*unsafe.Sizeof(C.float(0))))
-		goEmbedding[i] = float32(*ptr)
-	}
-
-	// 释放C分配的内存
-	C.FreeEmbedding(cEmbedding)
-
-	return &Embedding{data: goEmbedding}, nil
+// NewEmbedding 使用给定的向量数据创建嵌入向量，数据会被复制
+// 其Norm为给定向量自身的L2范数
+func NewEmbedding(data []float32) *Embedding {
+	data = append([]float32(nil), data...)
+	return &Embedding{data: data, norm: norm(data)}
 }
 
-// CosineSimilarity 计算两个嵌入向量的余弦相似度
-func CosineSimilarity(emb1, emb2 *Embedding) (float32, error) {
-	if emb1 == nil || emb2 == nil {
-		return 0, errors.New("嵌入向量为空")
-	}
-
-	if len(emb1.data) == 0 || len(emb2.data) == 0 {
-		return 0, errors.New("嵌入向量数据为空")
-	}
+// NewEmbeddingWithNorm 使用给定的向量数据和原始范数创建嵌入向量，数据会被复制
+// 用于恢复持久化的嵌入向量，使Norm仍返回模型原始输出的范数
+func NewEmbeddingWithNorm(data []float32, norm float32) *Embedding {
+	return &Embedding{data: append([]float32(nil), data...), norm: norm}
+}
 
-	if len(emb1.data) != len(emb2.data) {
-		return 0, fmt.Errorf("嵌入向量维度不匹配: %d vs %d", len(emb1.data), len(emb2.data))
-	}
+// Norm 返回模型原始输出（归一化之前）的L2范数，经过嵌入向量变换后仍保留该值
+// 范数与语音时长、质量相关，可作为嵌入向量可靠程度的参考
+func (e *Embedding) Norm() float32 {
+	return e.norm
+}
 
-	similarity := C.ComputeCosineSimilarity(
-		(*C.float)(unsafe.Pointer(&emb1.data[0])),
-		C.int(len(emb1.data)),
-		(*C.float)(unsafe.Pointer(&emb2.data[0])),
-		C.int(len(emb2.data)),
-	)
+// Dimension 返回模型输出的嵌入向量维度
+func (m *ModelHandle) Dimension() int {
+	return m.dim
+}
 
-	return float32(similarity), nil
+// ExtractEmbedding 从PCM数据中提取L2归一化的说话人嵌入向量[必须是16khz单声道音频]
+// pcmData: PCM数据（int16格式）
+func (m *ModelHandle) ExtractEmbedding(pcmData []int16) (*Embedding, error) {
+	return m.extract(pcmData, true)
 }
 
-// L2Distance 计算两个嵌入向量的L2距离
-func L2Distance(emb1, emb2 *Embedding) (float32, error) {
-	if emb1 == nil || emb2 == nil {
-		return -1, errors.New("嵌入向量为空")
-	}
+// ExtractRawEmbedding 从PCM数据中提取未归一化的说话人嵌入向量（模型原始输出）[必须是16khz单声道音频]
+// pcmData: PCM数据（int16格式）
+func (m *ModelHandle) ExtractRawEmbedding(pcmData []int16) (*Embedding, error) {
+	return m.extract(pcmData, false)
+}
 
-	if len(emb1.data) == 0 || len(emb2.data) == 0 {
-		return -1, errors.New("嵌入向量数据为空")
+// extract 提取嵌入向量，normalize指定是否归一化
+func (m *ModelHandle) extract(pcmData []int16, normalize bool) (*Embedding, error) {
+	data := make([]float32, m.dim)
+	n, err := m.ExtractEmbeddingInto(pcmData, data, normalize)
+	if err != nil {
+		return nil, err
 	}
+	return &Embedding{data: data, norm: n}, nil
+}
 
-	if len(emb1.data) != len(emb2.data) {
-		return -1, fmt.Errorf("嵌入向量维度不匹配: %d vs %d", len(emb1.data), len(emb2.data))
+// ExtractEmbeddingInto 从PCM数据中提取说话人嵌入向量并直接写入dst[必须是16khz单声道音频]
+// dst的长度必须等于Dimension()，C++侧直接写入该缓冲区，不再分配和复制内存，
+// 批量提取时可复用同一缓冲区
+//
+// 参数:
+//   - pcmData: PCM数据（int16格式）
+//   - dst: 输出缓冲区
+//   - normalize: 是否做L2归一化，false时输出模型原始输出
+//
+// 返回:
+//   - 模型原始输出的L2范数
+//   - 可能的错误，范数为0时返回ErrZeroNorm
+func (m *ModelHandle) ExtractEmbeddingInto(pcmData []int16, dst []float32, normalize bool) (float32, error) {
+	if m.handle == nil {
+		return 0, errors.New("模型已关闭或未初始化")
 	}
 
-	// 调用C++函数计算L2距离
-	distance := C.ComputeL2Distance(
-		(*C.float)(unsafe.Pointer(&emb1.data[0])),
-		C.int(len(emb1.data)),
-		(*C.float)(unsafe.Pointer(&emb2.data[0])),
-		C.int(len(emb2.data)),
-	)
-
-	// 检查是否返回错误值（负值）
-	if distance < 0 {
-		return -1, errors.New("L2距离计算失败")
+	if len(pcmData) == 0 {
+		return 0, errors.New("PCM数据为空")
 	}
 
-	return float32(distance), nil
-}
-
-// HybridSimilarity 结合余弦相似度和L2距离的混合评分
-// 权重范围[0,1]，值越大表示越重视余弦相似度，越小表示越重视L2距离
-func HybridSimilarity(emb1, emb2 *Embedding, cosineWeight float32) (float32, error) {
-	// 计算余弦相似度
-	cosine, err := CosineSimilarity(emb1, emb2)
-	if err != nil {
-		return 0, fmt.Errorf("计算余弦相似度失败: %w", err)
+	if len(dst) != m.dim {
+		return 0, fmt.Errorf("输出缓冲区长度 %d 与嵌入向量维度 %d 不一致", len(dst), m.dim)
 	}
 
-	// 计算L2距离
-	l2, err := L2Distance(emb1, emb2)
-	if err != nil {
-		return 0, fmt.Errorf("计算L2距离失败: %w", err)
+	var cNormalize C.int
+	if normalize {
+		cNormalize = 1
 	}
+	var cNorm C.float
 
-	// 将L2距离转换为相似度得分（距离越小，相似度越高）
-	// 使用指数衰减函数将L2距离映射到[0,1]范围
-	l2Similarity := float32(math.Exp(-float64(l2)))
-
-	// 确保权重在有效范围内
-	if cosineWeight < 0 {
-		cosineWeight = 0
-	} else if cosineWeight > 1 {
-		cosineWeight = 1
+	// 调用C函数提取嵌入向量，结果直接写入Go分配的缓冲区
+	ret := C.ExtractEmbedding(
+		m.handle,
+		(*C.short)(unsafe.Pointer(&pcmData[0])),
+		C.int(len(pcmData)),
+		(*C.float)(unsafe.Pointer(&dst[0])),
+		C.int(len(dst)),
+		cNormalize,
+		&cNorm,
+	)
+	runtime.KeepAlive(m)
+
+	switch ret {
+	case 1:
+		return float32(cNorm), nil
+	case -1:
+		return 0, ErrZeroNorm
+	default:
+		return 0, errors.New("提取嵌入向量失败")
 	}
-
-	// 计算加权混合得分
-	hybridScore := cosineWeight*cosine + (1-cosineWeight)*l2Similarity
-
-	fmt.Printf("混合评分计算: 余弦相似度=%.4f, L2相似度=%.4f, 权重=%.2f, 最终得分=%.4f\n",
-		cosine, l2Similarity, cosineWeight, hybridScore)
-
-	return hybridScore, nil
 }
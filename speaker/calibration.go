@@ -0,0 +1,222 @@
+package speaker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+)
+
+// OperatingPoint 检测代价函数的工作点，决定贝叶斯最优判决阈值
+type OperatingPoint struct {
+	PTarget float64 `json:"p_target"` // 目标说话人（同一人）的先验概率
+	CMiss   float64 `json:"c_miss"`   // 漏检（拒绝同一人）的代价
+	CFA     float64 `json:"c_fa"`     // 虚警（接受冒认者）的代价
+}
+
+// DefaultOperatingPoint 默认工作点，与NIST SRE常用设置一致
+var DefaultOperatingPoint = OperatingPoint{PTarget: 0.01, CMiss: 1, CFA: 1}
+
+// BayesThreshold 返回该工作点下的贝叶斯最优对数似然比阈值，LLR高于该值时判为同一人
+func (op OperatingPoint) BayesThreshold() float64 {
+	return math.Log(op.CFA*(1-op.PTarget)) - math.Log(op.CMiss*op.PTarget)
+}
+
+// validate 校验工作点参数
+func (op OperatingPoint) validate() error {
+	if op.PTarget <= 0 || op.PTarget >= 1 {
+		return fmt.Errorf("目标先验概率必须在(0,1)内，实际为: %v", op.PTarget)
+	}
+	if op.CMiss <= 0 || op.CFA <= 0 {
+		return fmt.Errorf("代价必须大于0，实际为: CMiss=%v, CFA=%v", op.CMiss, op.CFA)
+	}
+	return nil
+}
+
+// Calibrator 将相似度分数线性映射为对数似然比（LLR）的校准器
+//
+//	LLR = Scale*score + Offset
+//
+// LLR表示"同一人"相对"不同人"的证据强度，与先验无关；结合先验即可得到后验概率。
+// 校准器只对训练时使用的分数类型有效（原始余弦或规整后的分数）
+type Calibrator struct {
+	Scale  float64 `json:"scale"`  // 分数的缩放系数
+	Offset float64 `json:"offset"` // 偏移量
+	Prior  float64 `json:"prior"`  // 计算后验概率时使用的目标先验概率
+}
+
+// 训练参数
+const (
+	calibMaxIter = 100
+	calibTol     = 1e-10
+	calibL2      = 1e-6 // 轻微的L2正则，避免训练数据完全可分时参数发散
+)
+
+// FitCalibrator 使用带先验加权的逻辑回归训练校准器
+//
+// 参数:
+//   - targets: 同一说话人试验的分数
+//   - nonTargets: 不同说话人试验的分数
+//   - prior: 训练时的有效目标先验概率，一般取0.5；返回的校准器也以此计算后验概率
+//
+// 返回:
+//   - 校准器和可能的错误
+func FitCalibrator(targets, nonTargets []float64, prior float64) (*Calibrator, error) {
+	if len(targets) == 0 || len(nonTargets) == 0 {
+		return nil, errors.New("训练校准器需要同一人和不同人的分数")
+	}
+	if prior <= 0 || prior >= 1 {
+		return nil, fmt.Errorf("先验概率必须在(0,1)内，实际为: %v", prior)
+	}
+
+	// 按先验加权，使两类试验的总权重分别为prior和1-prior
+	wt := prior / float64(len(targets))
+	wn := (1 - prior) / float64(len(nonTargets))
+	logitPrior := math.Log(prior / (1 - prior))
+
+	objective := func(a, b float64) float64 {
+		var loss float64
+		for _, s := range targets {
+			loss += wt * softplus(-(a*s + b + logitPrior))
+		}
+		for _, s := range nonTargets {
+			loss += wn * softplus(a*s+b+logitPrior)
+		}
+		return loss + calibL2*a*a/2
+	}
+
+	a, b := 1.0, 0.0
+	loss := objective(a, b)
+	for iter := 0; iter < calibMaxIter; iter++ {
+		// 计算梯度和Hessian矩阵
+		var ga, gb, haa, hab, hbb float64
+		accumulate := func(s, y, w float64) {
+			p := sigmoid(a*s + b + logitPrior)
+			d := w * (p - y)
+			h := w * p * (1 - p)
+			ga += d * s
+			gb += d
+			haa += h * s * s
+			hab += h * s
+			hbb += h
+		}
+		for _, s := range targets {
+			accumulate(s, 1, wt)
+		}
+		for _, s := range nonTargets {
+			accumulate(s, 0, wn)
+		}
+		ga += calibL2 * a
+		haa += calibL2
+		hbb += 1e-12
+
+		// 牛顿方向
+		det := haa*hbb - hab*hab
+		if det <= 0 {
+			break
+		}
+		da := (hbb*ga - hab*gb) / det
+		db := (haa*gb - hab*ga) / det
+
+		// 回溯线搜索，保证损失下降
+		step := 1.0
+		var newA, newB, newLoss float64
+		for ; step > 1e-8; step /= 2 {
+			newA, newB = a-step*da, b-step*db
+			newLoss = objective(newA, newB)
+			if newLoss <= loss {
+				break
+			}
+		}
+		if step <= 1e-8 {
+			break
+		}
+		a, b = newA, newB
+		if loss-newLoss < calibTol {
+			loss = newLoss
+			break
+		}
+		loss = newLoss
+	}
+
+	return &Calibrator{Scale: a, Offset: b, Prior: prior}, nil
+}
+
+// LLR 将分数转换为对数似然比
+func (c *Calibrator) LLR(score float64) float64 {
+	return c.Scale*score + c.Offset
+}
+
+// Calibrated 将分数转换为对数似然比和后验概率
+//
+// 参数:
+//   - score: 相似度分数
+//
+// 返回:
+//   - llr: 对数似然比，>0表示更可能是同一人
+//   - prob: 在校准器先验下为同一人的后验概率[0,1]
+func (c *Calibrator) Calibrated(score float64) (llr, prob float64) {
+	llr = c.LLR(score)
+	prior := c.Prior
+	if prior <= 0 || prior >= 1 {
+		prior = 0.5
+	}
+	return llr, sigmoid(llr + math.Log(prior/(1-prior)))
+}
+
+// Threshold 返回给定工作点下贝叶斯最优判决对应的原始分数阈值
+func (c *Calibrator) Threshold(op OperatingPoint) (float32, error) {
+	if err := op.validate(); err != nil {
+		return 0, err
+	}
+	if c.Scale <= 0 {
+		return 0, fmt.Errorf("校准器缩放系数必须大于0，实际为: %v", c.Scale)
+	}
+	return float32((op.BayesThreshold() - c.Offset) / c.Scale), nil
+}
+
+// Save 将校准器参数保存为JSON文件
+func (c *Calibrator) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "    ")
+	if err != nil {
+		return fmt.Errorf("序列化校准器失败: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("写入校准器文件失败: %w", err)
+	}
+	return nil
+}
+
+// LoadCalibrator 从JSON文件加载校准器参数
+func LoadCalibrator(path string) (*Calibrator, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取校准器文件失败: %w", err)
+	}
+	var c Calibrator
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("解析校准器文件失败: %w", err)
+	}
+	if c.Scale <= 0 {
+		return nil, fmt.Errorf("校准器缩放系数必须大于0，实际为: %v", c.Scale)
+	}
+	return &c, nil
+}
+
+// sigmoid 计算logistic函数
+func sigmoid(x float64) float64 {
+	if x >= 0 {
+		return 1 / (1 + math.Exp(-x))
+	}
+	e := math.Exp(x)
+	return e / (1 + e)
+}
+
+// softplus 数值稳定地计算log(1+exp(x))
+func softplus(x float64) float64 {
+	if x > 30 {
+		return x
+	}
+	return math.Log1p(math.Exp(x))
+}
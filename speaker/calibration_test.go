@@ -0,0 +1,59 @@
+package speaker
+
+import (
+	"math"
+	"math/rand"
+	"path/filepath"
+	"testing"
+)
+
+// TestFitCalibrator 测试在已知分布上训练的校准器接近理论LLR
+func TestFitCalibrator(t *testing.T) {
+	// 两类分数均为方差相同的正态分布时，理论LLR是分数的线性函数:
+	// LLR = (mt-mn)/var*s - (mt^2-mn^2)/(2*var)
+	rng := rand.New(rand.NewSource(1))
+	const mt, mn, sigma = 0.7, 0.2, 0.1
+	targets := make([]float64, 5000)
+	nonTargets := make([]float64, 20000)
+	for i := range targets {
+		targets[i] = mt + sigma*rng.NormFloat64()
+	}
+	for i := range nonTargets {
+		nonTargets[i] = mn + sigma*rng.NormFloat64()
+	}
+
+	c, err := FitCalibrator(targets, nonTargets, 0.5)
+	if err != nil {
+		t.Fatalf("训练校准器失败: %v", err)
+	}
+	wantScale := (mt - mn) / (sigma * sigma)
+	wantOffset := -(mt*mt - mn*mn) / (2 * sigma * sigma)
+	if math.Abs(c.Scale-wantScale) > 0.1*wantScale || math.Abs(c.Offset-wantOffset) > 0.1*math.Abs(wantOffset) {
+		t.Fatalf("校准器参数偏离理论值: 得到(%.3f, %.3f)，期望(%.3f, %.3f)", c.Scale, c.Offset, wantScale, wantOffset)
+	}
+
+	// 两类分布中点处LLR应接近0，后验概率接近0.5
+	llr, prob := c.Calibrated((mt + mn) / 2)
+	if math.Abs(llr) > 0.3 || math.Abs(prob-0.5) > 0.1 {
+		t.Fatalf("中点处校准结果不正确: llr=%.3f, prob=%.3f", llr, prob)
+	}
+
+	// 虚警代价越高，阈值应越高
+	low, _ := c.Threshold(OperatingPoint{PTarget: 0.5, CMiss: 1, CFA: 1})
+	high, _ := c.Threshold(OperatingPoint{PTarget: 0.01, CMiss: 1, CFA: 10})
+	if high <= low {
+		t.Fatalf("阈值应随虚警代价升高: %.3f vs %.3f", low, high)
+	}
+
+	path := filepath.Join(t.TempDir(), "calibrator.json")
+	if err := c.Save(path); err != nil {
+		t.Fatalf("保存校准器失败: %v", err)
+	}
+	loaded, err := LoadCalibrator(path)
+	if err != nil {
+		t.Fatalf("加载校准器失败: %v", err)
+	}
+	if *loaded != *c {
+		t.Fatalf("加载后的校准器不一致: %+v vs %+v", loaded, c)
+	}
+}
@@ -0,0 +1,249 @@
+package speaker
+
+/*
+#include "speaker_wrapper.h"
+*/
+import "C"
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"unsafe"
+)
+
+// FbankInfo FBANK特征的形状参数，由模型的特征配置决定
+type FbankInfo struct {
+	NumBins     int // 每帧特征维度（梅尔滤波器组数量）
+	FrameLength int // 帧长（样本数）
+	FrameShift  int // 帧移（样本数）
+}
+
+// NumFrames 返回给定样本数的音频能得到的帧数，不足一帧的末尾样本被丢弃
+func (i FbankInfo) NumFrames(samples int) int {
+	if samples < i.FrameLength || i.FrameShift <= 0 {
+		return 0
+	}
+	return 1 + (samples-i.FrameLength)/i.FrameShift
+}
+
+// Samples 返回n帧特征覆盖的样本数
+func (i FbankInfo) Samples(frames int) int {
+	if frames <= 0 {
+		return 0
+	}
+	return (frames-1)*i.FrameShift + i.FrameLength
+}
+
+// Features FBANK特征矩阵
+type Features struct {
+	Data    []float32 // 按帧连续存放的特征，长度为NumFrames()*NumBins
+	NumBins int       // 每帧特征维度
+}
+
+// NumFrames 返回帧数
+func (f *Features) NumFrames() int {
+	if f == nil || f.NumBins <= 0 {
+		return 0
+	}
+	return len(f.Data) / f.NumBins
+}
+
+// Frame 返回第i帧的特征，与Data共享内存
+func (f *Features) Frame(i int) []float32 {
+	return f.Data[i*f.NumBins : (i+1)*f.NumBins]
+}
+
+// Slice 返回第start到end帧（左闭右开）的特征，与Data共享内存
+func (f *Features) Slice(start, end int) *Features {
+	return &Features{Data: f.Data[start*f.NumBins : end*f.NumBins], NumBins: f.NumBins}
+}
+
+// FbankInfo 返回模型使用的FBANK特征形状参数
+func (m *ModelHandle) FbankInfo() FbankInfo {
+	return m.fbank
+}
+
+// ComputeFbank 计算一段音频的FBANK特征[必须是16khz单声道音频]，不足一帧的末尾样本被丢弃
+//
+// 参数:
+//   - pcmData: PCM数据（int16格式），长度不小于一帧
+//
+// 返回:
+//   - 特征矩阵和可能的错误
+func (m *ModelHandle) ComputeFbank(pcmData []int16) (*Features, error) {
+	if m.handle == nil {
+		return nil, errors.New("模型已关闭或未初始化")
+	}
+	frames := m.fbank.NumFrames(len(pcmData))
+	if frames == 0 {
+		return nil, fmt.Errorf("PCM数据长度 %d 不足一帧（%d个样本）", len(pcmData), m.fbank.FrameLength)
+	}
+	data := make([]float32, frames*m.fbank.NumBins)
+	ret := C.ComputeFbank(
+		m.handle,
+		(*C.short)(unsafe.Pointer(&pcmData[0])),
+		C.int(len(pcmData)),
+		(*C.float)(unsafe.Pointer(&data[0])),
+		C.int(frames),
+	)
+	runtime.KeepAlive(m)
+	if ret < 0 {
+		return nil, errors.New("计算FBANK特征失败")
+	}
+	return &Features{Data: data[:int(ret)*m.fbank.NumBins], NumBins: m.fbank.NumBins}, nil
+}
+
+// ExtractEmbeddingFromFeatures 从FBANK特征中提取L2归一化的说话人嵌入向量
+// 特征可以来自ComputeFbank或FbankStream，在同一段音频的特征上与ExtractEmbedding的结果一致
+func (m *ModelHandle) ExtractEmbeddingFromFeatures(f *Features) (*Embedding, error) {
+	return m.extractFeatures(f, true)
+}
+
+// extractFeatures 从特征中提取嵌入向量，normalize指定是否归一化
+func (m *ModelHandle) extractFeatures(f *Features, normalize bool) (*Embedding, error) {
+	if m.handle == nil {
+		return nil, errors.New("模型已关闭或未初始化")
+	}
+	if f.NumFrames() == 0 {
+		return nil, errors.New("特征为空")
+	}
+	if f.NumBins != m.fbank.NumBins {
+		return nil, fmt.Errorf("特征维度 %d 与模型配置 %d 不一致", f.NumBins, m.fbank.NumBins)
+	}
+
+	var cNormalize C.int
+	if normalize {
+		cNormalize = 1
+	}
+	var cNorm C.float
+	data := make([]float32, m.dim)
+	ret := C.ExtractEmbeddingFromFeatures(
+		m.handle,
+		(*C.float)(unsafe.Pointer(&f.Data[0])),
+		C.int(f.NumFrames()),
+		C.int(f.NumBins),
+		(*C.float)(unsafe.Pointer(&data[0])),
+		C.int(len(data)),
+		cNormalize,
+		&cNorm,
+	)
+	runtime.KeepAlive(m)
+
+	switch ret {
+	case 1:
+		return &Embedding{data: data, norm: float32(cNorm)}, nil
+	case -1:
+		return nil, ErrZeroNorm
+	default:
+		return nil, errors.New("从特征提取嵌入向量失败")
+	}
+}
+
+// FbankStream 流式FBANK特征提取器，按块输入PCM数据，只计算新产生的完整帧
+// 不足一帧的样本保留到下次输入；以任意分块输入同一段音频时，输出的帧与ComputeFbank一致。
+// 流式验证和在线说话人日志用它避免在重叠窗口上重复计算特征
+// 非并发安全
+type FbankStream struct {
+	handle C.FbankStreamHandle
+	info   FbankInfo
+	frames int
+}
+
+// NewFbankStream 创建使用模型特征配置的流式特征提取器，创建后与模型互不影响
+func (m *ModelHandle) NewFbankStream() (*FbankStream, error) {
+	if m.handle == nil {
+		return nil, errors.New("模型已关闭或未初始化")
+	}
+	handle := C.CreateFbankStream(m.handle)
+	runtime.KeepAlive(m)
+	if handle == nil {
+		return nil, errors.New("创建流式特征提取器失败")
+	}
+	fs := &FbankStream{handle: handle, info: m.fbank}
+	runtime.SetFinalizer(fs, freeFbankStream)
+	return fs, nil
+}
+
+// Info 返回特征的形状参数
+func (fs *FbankStream) Info() FbankInfo {
+	return fs.info
+}
+
+// NumFrames 返回到目前为止输出的帧数
+func (fs *FbankStream) NumFrames() int {
+	return fs.frames
+}
+
+// Accept 输入一块PCM数据[必须是16khz单声道音频]，返回本次新产生的帧，可能为0帧
+func (fs *FbankStream) Accept(pcmData []int16) (*Features, error) {
+	if fs.handle == nil {
+		return nil, errors.New("流式特征提取器已关闭")
+	}
+	out := &Features{NumBins: fs.info.NumBins}
+	if len(pcmData) == 0 {
+		return out, nil
+	}
+	// 保留的样本不足一帧，新产生的帧数不超过len(pcmData)/FrameShift+1
+	maxFrames := len(pcmData)/fs.info.FrameShift + 1
+	data := make([]float32, maxFrames*fs.info.NumBins)
+	ret := C.AcceptFbankStream(
+		fs.handle,
+		(*C.short)(unsafe.Pointer(&pcmData[0])),
+		C.int(len(pcmData)),
+		(*C.float)(unsafe.Pointer(&data[0])),
+		C.int(maxFrames),
+	)
+	runtime.KeepAlive(fs)
+	if ret < 0 {
+		return nil, errors.New("流式计算FBANK特征失败")
+	}
+	fs.frames += int(ret)
+	out.Data = data[:int(ret)*fs.info.NumBins]
+	return out, nil
+}
+
+// Reset 丢弃保留的样本，帧计数清零
+func (fs *FbankStream) Reset() {
+	if fs.handle != nil {
+		C.ResetFbankStream(fs.handle)
+	}
+	fs.frames = 0
+}
+
+// freeFbankStream 释放流式特征提取器资源
+func freeFbankStream(fs *FbankStream) {
+	if fs.handle != nil {
+		C.FreeFbankStream(fs.handle)
+		fs.handle = nil
+	}
+}
+
+// Close 手动释放流式特征提取器
+func (fs *FbankStream) Close() {
+	if fs.handle != nil {
+		C.FreeFbankStream(fs.handle)
+		fs.handle = nil
+		runtime.SetFinalizer(fs, nil)
+	}
+}
+
+// NewFbankStream 创建使用模型特征配置的流式特征提取器
+func (s *Speaker) NewFbankStream() (*FbankStream, error) {
+	if s.model == nil {
+		return nil, errors.New("Speaker实例已关闭或未初始化")
+	}
+	return s.model.NewFbankStream()
+}
+
+// ExtractEmbeddingFromFeatures 从FBANK特征中提取说话人嵌入向量
+// 与ExtractEmbedding一样遵循SetRawEmbedding的设置并应用嵌入向量变换
+func (s *Speaker) ExtractEmbeddingFromFeatures(f *Features) (*Embedding, error) {
+	if s.model == nil {
+		return nil, errors.New("Speaker实例已关闭或未初始化")
+	}
+	emb, err := s.model.extractFeatures(f, !s.raw)
+	if err != nil {
+		return nil, err
+	}
+	return s.applyTransform(emb)
+}
@@ -0,0 +1,119 @@
+package speaker
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// TestFbankStream 测试以任意分块流式计算的特征与整段计算一致
+func TestFbankStream(t *testing.T) {
+	// 此处需要根据实际情况设置模型路径和配置文件路径
+	modelPath := "../../onnxruntime/model.onnx"
+	configPath := "../../onnxruntime/assets/fbank_config.json"
+
+	model, err := LoadModel(modelPath, configPath)
+	if err != nil {
+		t.Skipf("跳过测试：无法加载模型: %v", err)
+		return
+	}
+	defer model.Close()
+
+	rng := rand.New(rand.NewSource(1))
+	pcmData := make([]int16, 2*16000+123)
+	for i := range pcmData {
+		pcmData[i] = int16(rng.Intn(20000) - 10000)
+	}
+	batch, err := model.ComputeFbank(pcmData)
+	if err != nil {
+		t.Fatalf("计算特征失败: %v", err)
+	}
+	if batch.NumFrames() != model.FbankInfo().NumFrames(len(pcmData)) {
+		t.Fatalf("帧数不正确: %d", batch.NumFrames())
+	}
+
+	for _, chunk := range []int{1, 7, 160, 399, 1234, 5000} {
+		fs, err := model.NewFbankStream()
+		if err != nil {
+			t.Fatalf("创建流式特征提取器失败: %v", err)
+		}
+		streamed := &Features{NumBins: batch.NumBins}
+		for start := 0; start < len(pcmData); start += chunk {
+			f, err := fs.Accept(pcmData[start:min(start+chunk, len(pcmData))])
+			if err != nil {
+				t.Fatalf("流式计算特征失败: %v", err)
+			}
+			streamed.Data = append(streamed.Data, f.Data...)
+		}
+		if streamed.NumFrames() != batch.NumFrames() || fs.NumFrames() != batch.NumFrames() {
+			t.Fatalf("分块%d: 帧数%d与整段计算的%d不一致", chunk, streamed.NumFrames(), batch.NumFrames())
+		}
+		for i, v := range batch.Data {
+			if math.Abs(float64(v-streamed.Data[i])) > 1e-5 {
+				t.Fatalf("分块%d: 第%d个值不一致: %v vs %v", chunk, i, streamed.Data[i], v)
+			}
+		}
+		fs.Close()
+	}
+
+	// 在特征上提取的嵌入向量与直接提取的一致
+	fromPCM, err := model.ExtractEmbedding(pcmData)
+	if err != nil {
+		t.Fatalf("提取嵌入向量失败: %v", err)
+	}
+	fromFeatures, err := model.ExtractEmbeddingFromFeatures(batch)
+	if err != nil {
+		t.Fatalf("从特征提取嵌入向量失败: %v", err)
+	}
+	if sim := Dot(fromPCM.GetData(), fromFeatures.GetData()); sim < 0.9999 {
+		t.Fatalf("两种方式提取的嵌入向量不一致: %v", sim)
+	}
+}
+
+// TestStreamFeatures 测试增量计算特征的流式验证与每次重新计算整个窗口的结果一致
+func TestStreamFeatures(t *testing.T) {
+	// 此处需要根据实际情况设置模型路径和配置文件路径
+	modelPath := "../../onnxruntime/model.onnx"
+	configPath := "../../onnxruntime/assets/fbank_config.json"
+
+	s, err := New(modelPath, configPath)
+	if err != nil {
+		t.Skipf("跳过测试：无法加载模型: %v", err)
+		return
+	}
+	defer s.Close()
+
+	rng := rand.New(rand.NewSource(2))
+	pcmData := make([]int16, 5*16000)
+	for i := range pcmData {
+		pcmData[i] = int16(rng.Intn(20000) - 10000)
+	}
+	cfg := DefaultStreamConfig()
+	incremental, err := s.NewStream(nil, cfg)
+	if err != nil {
+		t.Fatalf("创建流式验证失败: %v", err)
+	}
+	defer incremental.Close()
+	recompute := newStream(s.ExtractEmbedding, s.CompareEmbeddings, s.Threshold(), nil, cfg)
+
+	var got, want []StreamUpdate
+	incremental.OnUpdate(func(u StreamUpdate) { got = append(got, u) })
+	recompute.OnUpdate(func(u StreamUpdate) { want = append(want, u) })
+	for start := 0; start < len(pcmData); start += 1111 {
+		chunk := pcmData[start:min(start+1111, len(pcmData))]
+		if err := incremental.WriteSamples(chunk); err != nil {
+			t.Fatalf("写入失败: %v", err)
+		}
+		if err := recompute.WriteSamples(chunk); err != nil {
+			t.Fatalf("写入失败: %v", err)
+		}
+	}
+	if len(got) == 0 || len(got) != len(want) {
+		t.Fatalf("更新次数不一致: %d vs %d", len(got), len(want))
+	}
+	for i := range got {
+		if sim := Dot(got[i].Embedding.GetData(), want[i].Embedding.GetData()); sim < 0.9999 {
+			t.Fatalf("第%d次更新的嵌入向量不一致: %v", i, sim)
+		}
+	}
+}
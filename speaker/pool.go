@@ -0,0 +1,127 @@
+package speaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrPoolClosed 会话池已关闭
+var ErrPoolClosed = errors.New("Speaker会话池已关闭")
+
+// Pool Speaker会话池，持有多个独立加载的Speaker实例，可安全地并发使用
+// 每个实例同一时刻只借给一个goroutine，并发度等于池的大小
+type Pool struct {
+	idle chan *Speaker
+	all  []*Speaker
+
+	mu      sync.Mutex
+	closed  bool
+	pending sync.WaitGroup // 已借出尚未归还的实例
+}
+
+// NewPool 创建会话池
+//
+// 参数:
+//   - size: 实例数量，<=0时为1
+//   - newSpeaker: 创建一个实例的函数，可在其中设置变换、打分器、阈值等，各实例的设置应相同
+//
+// 返回:
+//   - 会话池和可能的错误，创建失败时已创建的实例会被关闭
+func NewPool(size int, newSpeaker func() (*Speaker, error)) (*Pool, error) {
+	size = max(size, 1)
+	p := &Pool{idle: make(chan *Speaker, size)}
+	for i := 0; i < size; i++ {
+		s, err := newSpeaker()
+		if err != nil {
+			p.closeAll()
+			return nil, fmt.Errorf("创建第%d个Speaker实例失败: %w", i+1, err)
+		}
+		if len(p.all) > 0 && s.Fingerprint() != p.all[0].Fingerprint() {
+			s.Close()
+			p.closeAll()
+			return nil, errors.New("会话池中各实例的模型指纹不一致")
+		}
+		p.all = append(p.all, s)
+		p.idle <- s
+	}
+	return p, nil
+}
+
+// NewPoolFromModel 加载size个模型会话组成会话池
+func NewPoolFromModel(onnxModelPath, fbankConfigPath string, size int) (*Pool, error) {
+	return NewPool(size, func() (*Speaker, error) {
+		return New(onnxModelPath, fbankConfigPath)
+	})
+}
+
+// Size 返回池中的实例数量
+func (p *Pool) Size() int {
+	return len(p.all)
+}
+
+// Fingerprint 返回池中模型的指纹
+func (p *Pool) Fingerprint() string {
+	return p.all[0].Fingerprint()
+}
+
+// Acquire 借出一个实例，没有空闲实例时等待，使用完毕后必须调用Release归还
+func (p *Pool) Acquire(ctx context.Context) (*Speaker, error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, ErrPoolClosed
+	}
+	p.pending.Add(1)
+	p.mu.Unlock()
+
+	select {
+	case s := <-p.idle:
+		return s, nil
+	case <-ctx.Done():
+		p.pending.Done()
+		return nil, ctx.Err()
+	}
+}
+
+// Release 归还Acquire借出的实例
+func (p *Pool) Release(s *Speaker) {
+	p.idle <- s
+	p.pending.Done()
+}
+
+// Do 借出一个实例执行fn，fn返回后自动归还
+func (p *Pool) Do(ctx context.Context, fn func(s *Speaker) error) error {
+	s, err := p.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer p.Release(s)
+	return fn(s)
+}
+
+// Close 等待所有借出的实例归还后关闭全部实例，之后Acquire返回ErrPoolClosed
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrPoolClosed
+	}
+	p.closed = true
+	p.mu.Unlock()
+
+	p.pending.Wait()
+	return p.closeAll()
+}
+
+// closeAll 关闭已创建的全部实例
+func (p *Pool) closeAll() error {
+	var errs []error
+	for _, s := range p.all {
+		if err := s.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,89 @@
+package speaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestPool 测试会话池的借出、归还、等待和关闭
+func TestPool(t *testing.T) {
+	pool, err := NewPool(2, func() (*Speaker, error) { return &Speaker{}, nil })
+	if err != nil {
+		t.Fatalf("创建会话池失败: %v", err)
+	}
+	if pool.Size() != 2 {
+		t.Fatalf("会话池大小应为2，实际为: %d", pool.Size())
+	}
+
+	// 并发使用时同时借出的实例不超过池的大小，且不会借出同一个实例
+	var mu sync.Mutex
+	inUse := make(map[*Speaker]bool)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := pool.Do(context.Background(), func(s *Speaker) error {
+				mu.Lock()
+				if inUse[s] || len(inUse) >= 2 {
+					mu.Unlock()
+					return errors.New("实例被重复借出")
+				}
+				inUse[s] = true
+				mu.Unlock()
+				time.Sleep(time.Millisecond)
+				mu.Lock()
+				delete(inUse, s)
+				mu.Unlock()
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 没有空闲实例时等待，ctx取消后返回
+	a, _ := pool.Acquire(context.Background())
+	b, _ := pool.Acquire(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("没有空闲实例时应等待到ctx超时，实际为: %v", err)
+	}
+
+	// Close等待借出的实例归还
+	closed := make(chan struct{})
+	go func() {
+		pool.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		t.Fatal("Close应等待借出的实例归还")
+	case <-time.After(10 * time.Millisecond):
+	}
+	pool.Release(a)
+	pool.Release(b)
+	<-closed
+	if _, err := pool.Acquire(context.Background()); !errors.Is(err, ErrPoolClosed) {
+		t.Fatalf("关闭后Acquire应返回ErrPoolClosed，实际为: %v", err)
+	}
+
+	// 创建失败时返回错误
+	calls := 0
+	_, err = NewPool(3, func() (*Speaker, error) {
+		calls++
+		if calls == 2 {
+			return nil, errors.New("加载失败")
+		}
+		return &Speaker{}, nil
+	})
+	if err == nil {
+		t.Fatal("创建实例失败时NewPool应返回错误")
+	}
+}
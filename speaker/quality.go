@@ -0,0 +1,288 @@
+package speaker
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/seastart/3dspeaker-onnx-go/audio"
+)
+
+// ErrLowQuality 嵌入向量质量低于所要求的最低值
+var ErrLowQuality = errors.New("嵌入向量质量过低")
+
+// Quality 嵌入向量的质量估计
+type Quality struct {
+	SpeechSeconds float64 `json:"speech_seconds"` // 语音活动检测得到的净语音时长（秒）
+	Norm          float32 `json:"norm"`           // 模型原始输出（归一化之前）的L2范数
+	SNR           float64 `json:"snr"`            // 估计的信噪比（分贝）
+	Dispersion    float64 `json:"dispersion"`     // 各窗口嵌入向量与其平均方向的余弦距离的平均值，窗口不足两个时为0
+	Windows       int     `json:"windows"`        // 参与离散度计算的窗口数
+	Score         float64 `json:"score"`          // 综合质量分数[0,1]，越大越可靠
+}
+
+// QualityConfig 质量估计配置
+// 各项指标分别线性映射到[0,1]，综合分数为各项的几何平均，任何一项很差都会显著拉低综合分数
+type QualityConfig struct {
+	VAD audio.VADConfig // 语音活动检测配置
+
+	MinSpeechSeconds    float64 // 净语音时长不超过该值时时长得分为0
+	TargetSpeechSeconds float64 // 净语音时长达到该值时时长得分为1
+	MinSNR              float64 // 信噪比不超过该值时信噪比得分为0
+	TargetSNR           float64 // 信噪比达到该值时信噪比得分为1
+
+	// NormReference 干净语音的典型原始范数，可取域内注册数据Norm的中位数；
+	// 范数达到该值时范数得分为1，为0时不考虑范数（原始范数的尺度与模型有关）
+	NormReference float32
+
+	WindowSeconds float64 // 计算离散度的窗口长度（秒）
+	HopSeconds    float64 // 窗口移动步长（秒）
+	MaxDispersion float64 // 离散度达到该值时离散度得分为0
+}
+
+// DefaultQualityConfig 返回默认的质量估计配置
+func DefaultQualityConfig() QualityConfig {
+	return QualityConfig{
+		VAD:                 audio.DefaultVADConfig(),
+		MinSpeechSeconds:    0.5,
+		TargetSpeechSeconds: 3,
+		MinSNR:              5,
+		TargetSNR:           25,
+		WindowSeconds:       1.5,
+		HopSeconds:          0.75,
+		MaxDispersion:       0.5,
+	}
+}
+
+// ramp 将x从[lo,hi]线性映射到[0,1]并截断
+func ramp(x, lo, hi float64) float64 {
+	if hi <= lo {
+		if x >= hi {
+			return 1
+		}
+		return 0
+	}
+	return math.Min(math.Max((x-lo)/(hi-lo), 0), 1)
+}
+
+// score 根据各项指标计算综合质量分数
+func (c QualityConfig) score(q *Quality) float64 {
+	parts := []float64{
+		ramp(q.SpeechSeconds, c.MinSpeechSeconds, c.TargetSpeechSeconds),
+		ramp(q.SNR, c.MinSNR, c.TargetSNR),
+	}
+	if c.NormReference > 0 {
+		parts = append(parts, ramp(float64(q.Norm), 0, float64(c.NormReference)))
+	}
+	if q.Windows >= 2 && c.MaxDispersion > 0 {
+		parts = append(parts, 1-ramp(q.Dispersion, 0, c.MaxDispersion))
+	}
+	logSum := 0.0
+	for _, p := range parts {
+		if p <= 0 {
+			return 0
+		}
+		logSum += math.Log(p)
+	}
+	return math.Exp(logSum / float64(len(parts)))
+}
+
+// dispersion 计算一组归一化嵌入向量与其平均方向的余弦距离的平均值
+func dispersion(windows [][]float32) float64 {
+	if len(windows) < 2 {
+		return 0
+	}
+	mean := make([]float32, len(windows[0]))
+	for _, w := range windows {
+		for i, v := range w {
+			mean[i] += v
+		}
+	}
+	n := norm(mean)
+	if n == 0 {
+		return 1
+	}
+	var sum float64
+	for _, w := range windows {
+		sum += 1 - float64(Dot(w, mean)/(n*norm(w)))
+	}
+	return sum / float64(len(windows))
+}
+
+// SetQualityConfig 设置质量估计配置，未设置时使用DefaultQualityConfig
+func (s *Speaker) SetQualityConfig(cfg QualityConfig) {
+	s.qualityConfig = &cfg
+}
+
+// QualityConfig 返回当前的质量估计配置
+func (s *Speaker) QualityConfig() QualityConfig {
+	if s.qualityConfig == nil {
+		return DefaultQualityConfig()
+	}
+	return *s.qualityConfig
+}
+
+// SetMinQuality 设置注册和验证所要求的最低质量分数，<=0表示不检查（默认）
+// 设置后Enroll、Verify、IsSameSpeaker和IsSameSpeakerAt会计算质量，低于该值时返回ErrLowQuality
+func (s *Speaker) SetMinQuality(score float64) {
+	s.minQuality = score
+}
+
+// MinQuality 返回注册和验证所要求的最低质量分数
+func (s *Speaker) MinQuality() float64 {
+	return s.minQuality
+}
+
+// Quality 返回嵌入向量的质量估计，未计算质量时返回nil
+// 只有ExtractEmbeddingWithQuality、Enroll等方法返回的嵌入向量带有质量估计
+func (e *Embedding) Quality() *Quality {
+	return e.quality
+}
+
+// ExtractEmbeddingWithQuality 提取嵌入向量并计算其质量估计[必须是16khz单声道音频]
+// 除整段音频外还需对每个窗口提取一次嵌入向量，开销明显高于ExtractEmbedding
+//
+// 参数:
+//   - pcmData: PCM音频数据，int16格式
+//
+// 返回:
+//   - 带有质量估计的嵌入向量和可能的错误
+func (s *Speaker) ExtractEmbeddingWithQuality(pcmData []int16) (*Embedding, error) {
+	emb, err := s.ExtractEmbedding(pcmData)
+	if err != nil {
+		return nil, err
+	}
+	q, err := s.assessQuality(pcmData, emb.norm)
+	if err != nil {
+		return nil, fmt.Errorf("估计嵌入向量质量失败: %w", err)
+	}
+	emb.quality = q
+	return emb, nil
+}
+
+// assessQuality 计算一段音频的质量估计，norm为整段音频的原始嵌入向量范数
+func (s *Speaker) assessQuality(pcmData []int16, norm float32) (*Quality, error) {
+	cfg := s.QualityConfig()
+	segs := audio.DetectSpeech(pcmData, audio.SampleRate, cfg.VAD)
+	q := &Quality{
+		SpeechSeconds: audio.SpeechSeconds(segs, audio.SampleRate),
+		Norm:          norm,
+		SNR:           audio.EstimateSNR(pcmData, audio.SampleRate, segs),
+	}
+
+	// 在拼接后的语音上按窗口提取嵌入向量，计算离散度
+	speech := audio.ExtractSegments(pcmData, segs)
+	win := int(cfg.WindowSeconds * audio.SampleRate)
+	hop := int(cfg.HopSeconds * audio.SampleRate)
+	if win > 0 && hop > 0 {
+		var windows [][]float32
+		for start := 0; start+win <= len(speech); start += hop {
+			data := make([]float32, s.model.Dimension())
+			_, err := s.model.ExtractEmbeddingInto(speech[start:start+win], data, true)
+			if errors.Is(err, ErrZeroNorm) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			windows = append(windows, data)
+		}
+		q.Windows = len(windows)
+		q.Dispersion = dispersion(windows)
+	}
+	q.Score = cfg.score(q)
+	return q, nil
+}
+
+// CheckQuality 检查嵌入向量的质量是否满足SetMinQuality设置的最低要求，不满足时返回ErrLowQuality
+// 未设置最低质量或嵌入向量未计算质量（例如由ExtractEmbedding提取）时不做检查；设置了最低质量而嵌入向量为nil时返回错误
+func (s *Speaker) CheckQuality(emb *Embedding) error {
+	if s.minQuality <= 0 {
+		return nil
+	}
+	if emb == nil {
+		return errors.New("嵌入向量为空")
+	}
+	if emb.quality == nil {
+		return nil
+	}
+	if emb.quality.Score < s.minQuality {
+		return fmt.Errorf("%w: %.3f < %.3f", ErrLowQuality, emb.quality.Score, s.minQuality)
+	}
+	return nil
+}
+
+// extractChecked 提取用于注册或验证的嵌入向量，设置了最低质量时计算并检查质量
+func (s *Speaker) extractChecked(pcmData []int16) (*Embedding, error) {
+	if s.minQuality <= 0 {
+		return s.ExtractEmbedding(pcmData)
+	}
+	emb, err := s.ExtractEmbeddingWithQuality(pcmData)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.CheckQuality(emb); err != nil {
+		return nil, err
+	}
+	return emb, nil
+}
+
+// Enroll 提取用于注册的嵌入向量[必须是16khz单声道音频]
+// 总是计算质量估计；设置了最低质量且质量不满足时返回ErrLowQuality
+//
+// 参数:
+//   - pcmData: PCM音频数据，int16格式
+//
+// 返回:
+//   - 带有质量估计的嵌入向量和可能的错误
+func (s *Speaker) Enroll(pcmData []int16) (*Embedding, error) {
+	if s.model == nil {
+		return nil, errors.New("Speaker实例已关闭或未初始化")
+	}
+	emb, err := s.ExtractEmbeddingWithQuality(pcmData)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.CheckQuality(emb); err != nil {
+		return nil, fmt.Errorf("注册音频不满足质量要求: %w", err)
+	}
+	return emb, nil
+}
+
+// Verify 判断一段音频是否来自已注册的说话人[必须是16khz单声道音频]
+// 设置了最低质量时，测试音频和带有质量估计的注册向量都需要满足要求
+//
+// 参数:
+//   - enroll: 注册嵌入向量
+//   - pcmData: 测试音频的PCM数据
+//   - threshold: 判断阈值，<=0时使用配置的阈值；阈值可能为0或负数时请使用VerifyWithThreshold
+//
+// 返回:
+//   - 是否为同一说话人
+//   - 当前打分器给出的分数
+//   - 可能的错误
+func (s *Speaker) Verify(enroll *Embedding, pcmData []int16, threshold float32) (bool, float32, error) {
+	if threshold <= 0 {
+		threshold = s.Threshold()
+	}
+	return s.VerifyWithThreshold(enroll, pcmData, threshold)
+}
+
+// VerifyWithThreshold 与Verify相同，但阈值按原值使用，可以为0或负数（例如对数似然比）
+func (s *Speaker) VerifyWithThreshold(enroll *Embedding, pcmData []int16, threshold float32) (bool, float32, error) {
+	if s.model == nil {
+		return false, 0, errors.New("Speaker实例已关闭或未初始化")
+	}
+	if err := s.CheckQuality(enroll); err != nil {
+		return false, 0, fmt.Errorf("注册向量不满足质量要求: %w", err)
+	}
+	test, err := s.extractChecked(pcmData)
+	if err != nil {
+		return false, 0, fmt.Errorf("提取测试音频嵌入向量失败: %w", err)
+	}
+	score, err := s.CompareEmbeddings(enroll, test)
+	if err != nil {
+		return false, 0, err
+	}
+	return score >= threshold, score, nil
+}
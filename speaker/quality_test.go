@@ -0,0 +1,65 @@
+package speaker
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// TestQualityScore 测试质量分数的组合和离散度计算
+func TestQualityScore(t *testing.T) {
+	cfg := DefaultQualityConfig()
+	good := &Quality{SpeechSeconds: 5, SNR: 30, Norm: 25, Windows: 5, Dispersion: 0.05}
+	// 时长和信噪比得分为1，离散度得分为0.9，综合分数为三者的几何平均
+	if s := cfg.score(good); math.Abs(s-math.Cbrt(0.9)) > 1e-9 {
+		t.Fatalf("质量分数不正确: %v", s)
+	}
+	short := *good
+	short.SpeechSeconds = 1
+	noisy := *good
+	noisy.SNR = 8
+	for _, q := range []*Quality{&short, &noisy} {
+		if cfg.score(q) >= cfg.score(good) {
+			t.Fatalf("时长更短或噪声更大时质量分数应降低: %+v", q)
+		}
+	}
+	short.SpeechSeconds = 0.3
+	if s := cfg.score(&short); s != 0 {
+		t.Fatalf("语音过短时质量分数应为0: %v", s)
+	}
+
+	// 设置参考范数后范数偏小也会降低分数
+	cfg.NormReference = 20
+	weak := *good
+	weak.Norm = 5
+	if cfg.score(&weak) >= cfg.score(good) {
+		t.Fatal("范数偏小时质量分数应降低")
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	base := unitEmbedding(rng, 32).data
+	var same, mixed [][]float32
+	for i := 0; i < 6; i++ {
+		same = append(same, base)
+		mixed = append(mixed, unitEmbedding(rng, 32).data)
+	}
+	if d := dispersion(same); math.Abs(d) > 1e-6 {
+		t.Fatalf("相同向量的离散度应为0: %v", d)
+	}
+	if dispersion(mixed) < 0.3 {
+		t.Fatalf("随机向量的离散度应较大: %v", dispersion(mixed))
+	}
+
+	s := &Speaker{}
+	s.SetMinQuality(0.5)
+	if err := s.CheckQuality(&Embedding{quality: &short}); !errors.Is(err, ErrLowQuality) {
+		t.Fatalf("质量过低时应返回ErrLowQuality: %v", err)
+	}
+	if err := s.CheckQuality(&Embedding{}); err != nil {
+		t.Fatalf("未计算质量的嵌入向量不应检查: %v", err)
+	}
+	if err := s.CheckQuality(nil); err == nil {
+		t.Fatal("设置了最低质量时空嵌入向量应返回错误")
+	}
+}
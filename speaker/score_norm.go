@@ -0,0 +1,213 @@
+package speaker
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// NormMethod 分数规整方法
+type NormMethod int
+
+const (
+	// ZNorm 使用注册向量与冒认者集合的分数统计量进行规整
+	ZNorm NormMethod = iota
+	// TNorm 使用测试向量与冒认者集合的分数统计量进行规整
+	TNorm
+	// SNorm 对称规整，取ZNorm和TNorm的平均值
+	SNorm
+	// ASNorm 自适应对称规整，仅使用与注册/测试向量最相似的前N个冒认者计算统计量
+	ASNorm
+)
+
+// String 返回规整方法名称
+func (m NormMethod) String() string {
+	switch m {
+	case ZNorm:
+		return "z-norm"
+	case TNorm:
+		return "t-norm"
+	case SNorm:
+		return "s-norm"
+	case ASNorm:
+		return "as-norm"
+	default:
+		return fmt.Sprintf("NormMethod(%d)", int(m))
+	}
+}
+
+// ParseNormMethod 根据名称解析规整方法
+func ParseNormMethod(name string) (NormMethod, error) {
+	for _, m := range []NormMethod{ZNorm, TNorm, SNorm, ASNorm} {
+		if m.String() == name {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("未知的分数规整方法: %s", name)
+}
+
+// 自适应规整默认使用的冒认者数量
+const defaultTopN = 300
+
+// 标准差下限，避免冒认者分数过于集中时除以0
+const minCohortStd = 1e-6
+
+// ScoreNormalizer 基于冒认者集合的分数规整器
+// 原始余弦分数会随信道、语种等条件漂移，规整后的分数表示目标分数偏离冒认者分数分布的程度，
+// 同一阈值在不同部署环境下更具可比性。注意规整后的分数不在[-1,1]范围内，阈值需要重新选择
+type ScoreNormalizer struct {
+	method NormMethod
+	topN   int
+	cohort []*Embedding
+	base   Scorer
+}
+
+// NewScoreNormalizer 创建分数规整器
+//
+// 参数:
+//   - cohort: 冒认者嵌入向量集合，应来自与目标说话人无关的人，一般数百到数千个
+//   - method: 规整方法
+//   - topN: 自适应规整使用的冒认者数量，<=0时使用默认值300，仅对ASNorm有效
+//
+// 返回:
+//   - 分数规整器和可能的错误
+func NewScoreNormalizer(cohort []*Embedding, method NormMethod, topN int) (*ScoreNormalizer, error) {
+	if len(cohort) < 2 {
+		return nil, errors.New("冒认者集合至少需要2个嵌入向量")
+	}
+	if method < ZNorm || method > ASNorm {
+		return nil, fmt.Errorf("未知的分数规整方法: %d", method)
+	}
+	if topN <= 0 {
+		topN = defaultTopN
+	}
+
+	dim := 0
+	for i, emb := range cohort {
+		if emb == nil || len(emb.data) == 0 {
+			return nil, fmt.Errorf("第%d个冒认者嵌入向量为空", i)
+		}
+		if i == 0 {
+			dim = len(emb.data)
+		}
+		if len(emb.data) != dim {
+			return nil, fmt.Errorf("第%d个冒认者嵌入向量维度不匹配: %d vs %d", i, len(emb.data), dim)
+		}
+	}
+
+	return &ScoreNormalizer{
+		method: method,
+		topN:   topN,
+		cohort: append([]*Embedding(nil), cohort...),
+		base:   CosineScorer{},
+	}, nil
+}
+
+// SetBase 设置计算原始分数的打分器，默认为余弦打分，例如可设置为PLDA以对PLDA分数做规整
+// 传入nil恢复为余弦打分
+func (n *ScoreNormalizer) SetBase(base Scorer) {
+	if base == nil {
+		base = CosineScorer{}
+	}
+	n.base = base
+}
+
+// Method 返回规整方法
+func (n *ScoreNormalizer) Method() NormMethod {
+	return n.method
+}
+
+// Cohort 返回冒认者集合，用于持久化
+func (n *ScoreNormalizer) Cohort() []*Embedding {
+	return append([]*Embedding(nil), n.cohort...)
+}
+
+// Score 使用基础打分器计算两个嵌入向量的原始分数并进行规整，实现Scorer接口
+//
+// 参数:
+//   - enroll: 注册嵌入向量
+//   - test: 测试嵌入向量
+//
+// 返回:
+//   - 规整后的分数，越大表示越相似
+//   - 可能的错误
+func (n *ScoreNormalizer) Score(enroll, test *Embedding) (float32, error) {
+	score, err := n.base.Score(enroll, test)
+	if err != nil {
+		return 0, err
+	}
+	return n.Normalize(enroll, test, score)
+}
+
+// Normalize 规整一个原始分数
+//
+// 参数:
+//   - enroll: 注册嵌入向量
+//   - test: 测试嵌入向量
+//   - score: 二者的原始分数，须由基础打分器计算
+//
+// 返回:
+//   - 规整后的分数和可能的错误
+func (n *ScoreNormalizer) Normalize(enroll, test *Embedding, score float32) (float32, error) {
+	switch n.method {
+	case ZNorm:
+		mean, std, err := n.cohortStats(enroll, len(n.cohort))
+		if err != nil {
+			return 0, err
+		}
+		return (score - mean) / std, nil
+	case TNorm:
+		mean, std, err := n.cohortStats(test, len(n.cohort))
+		if err != nil {
+			return 0, err
+		}
+		return (score - mean) / std, nil
+	default:
+		topN := len(n.cohort)
+		if n.method == ASNorm {
+			topN = n.topN
+		}
+		enrollMean, enrollStd, err := n.cohortStats(enroll, topN)
+		if err != nil {
+			return 0, err
+		}
+		testMean, testStd, err := n.cohortStats(test, topN)
+		if err != nil {
+			return 0, err
+		}
+		return ((score-enrollMean)/enrollStd + (score-testMean)/testStd) / 2, nil
+	}
+}
+
+// cohortStats 计算嵌入向量与冒认者集合中最相似的topN个分数的均值和标准差
+func (n *ScoreNormalizer) cohortStats(emb *Embedding, topN int) (float32, float32, error) {
+	scores := make([]float64, len(n.cohort))
+	for i, c := range n.cohort {
+		s, err := n.base.Score(emb, c)
+		if err != nil {
+			return 0, 0, fmt.Errorf("计算冒认者分数失败: %w", err)
+		}
+		scores[i] = float64(s)
+	}
+	if topN < len(scores) {
+		sort.Sort(sort.Reverse(sort.Float64Slice(scores)))
+		scores = scores[:topN]
+	}
+
+	var mean float64
+	for _, s := range scores {
+		mean += s
+	}
+	mean /= float64(len(scores))
+
+	var variance float64
+	for _, s := range scores {
+		variance += (s - mean) * (s - mean)
+	}
+	std := math.Sqrt(variance / float64(len(scores)))
+	if std < minCohortStd {
+		std = minCohortStd
+	}
+	return float32(mean), float32(std), nil
+}
@@ -0,0 +1,66 @@
+package speaker
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// randomEmbedding 生成随机嵌入向量
+func randomEmbedding(rng *rand.Rand, dim int) *Embedding {
+	data := make([]float32, dim)
+	for i := range data {
+		data[i] = float32(rng.NormFloat64())
+	}
+	return NewEmbedding(data)
+}
+
+// TestScoreNormalizer 测试各规整方法的基本性质
+func TestScoreNormalizer(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	cohort := make([]*Embedding, 200)
+	for i := range cohort {
+		cohort[i] = randomEmbedding(rng, 16)
+	}
+	enroll := randomEmbedding(rng, 16)
+	test := randomEmbedding(rng, 16)
+
+	for _, method := range []NormMethod{ZNorm, TNorm, SNorm, ASNorm} {
+		n, err := NewScoreNormalizer(cohort, method, 50)
+		if err != nil {
+			t.Fatalf("创建%v规整器失败: %v", method, err)
+		}
+
+		// 与注册向量完全相同的测试向量应远高于冒认者分布
+		same, err := n.Score(enroll, enroll)
+		if err != nil {
+			t.Fatalf("%v规整失败: %v", method, err)
+		}
+		diff, err := n.Score(enroll, test)
+		if err != nil {
+			t.Fatalf("%v规整失败: %v", method, err)
+		}
+		if same < 3 || same <= diff {
+			t.Fatalf("%v规整分数不合理: 同一说话人=%.3f, 不同说话人=%.3f", method, same, diff)
+		}
+	}
+
+	// S-norm应为Z-norm和T-norm的平均值
+	z, _ := NewScoreNormalizer(cohort, ZNorm, 0)
+	tn, _ := NewScoreNormalizer(cohort, TNorm, 0)
+	s, _ := NewScoreNormalizer(cohort, SNorm, 0)
+	zs, _ := z.Normalize(enroll, test, 0.5)
+	ts, _ := tn.Normalize(enroll, test, 0.5)
+	ss, _ := s.Normalize(enroll, test, 0.5)
+	if math.Abs(float64(ss-(zs+ts)/2)) > 1e-4 {
+		t.Fatalf("S-norm分数应为Z-norm和T-norm的平均值: %.4f vs %.4f", ss, (zs+ts)/2)
+	}
+
+	if _, err := NewScoreNormalizer(cohort[:1], ZNorm, 0); err == nil {
+		t.Fatal("冒认者过少时应返回错误")
+	}
+	if _, err := NewScoreNormalizer([]*Embedding{nil, cohort[0]}, ZNorm, 0); err == nil || !strings.Contains(err.Error(), "第0个冒认者嵌入向量为空") {
+		t.Fatalf("第一个冒认者为空时应返回错误: %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package speaker
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Scorer 计算注册嵌入向量与测试嵌入向量之间的说话人相似度分数，分数越大越可能是同一人
+//
+// 内置实现有CosineScorer、L2Scorer、CalibratedScorer和ScoreNormalizer，
+// plda包提供的PLDA后端也实现了该接口。打分器可以嵌套，例如对PLDA分数做AS-norm后再校准
+type Scorer interface {
+	Score(enroll, test *Embedding) (float32, error)
+}
+
+// CosineScorer 余弦相似度打分器，是Speaker未设置打分器时的默认打分方式
+type CosineScorer struct{}
+
+// Score 返回两个嵌入向量的余弦相似度
+func (CosineScorer) Score(enroll, test *Embedding) (float32, error) {
+	return CosineSimilarity(enroll, test)
+}
+
+// L2Scorer L2距离打分器，分数为负的L2距离，使分数越大越相似
+// 对于归一化的嵌入向量，L2距离与余弦相似度满足d²=2-2cos，两者排序一致
+type L2Scorer struct{}
+
+// Score 返回两个嵌入向量的负L2距离
+func (L2Scorer) Score(enroll, test *Embedding) (float32, error) {
+	distance, err := L2Distance(enroll, test)
+	if err != nil {
+		return 0, err
+	}
+	return -distance, nil
+}
+
+// CalibratedScorer 将基础打分器的分数经校准器转换为对数似然比
+type CalibratedScorer struct {
+	base       Scorer
+	calibrator *Calibrator
+}
+
+// NewCalibratedScorer 创建校准打分器
+//
+// 参数:
+//   - base: 基础打分器，nil表示余弦打分；校准器需使用该打分器的分数训练
+//   - calibrator: 分数校准器
+//
+// 返回:
+//   - 校准打分器和可能的错误
+func NewCalibratedScorer(base Scorer, calibrator *Calibrator) (*CalibratedScorer, error) {
+	if calibrator == nil {
+		return nil, errors.New("校准器为空")
+	}
+	if base == nil {
+		base = CosineScorer{}
+	}
+	return &CalibratedScorer{base: base, calibrator: calibrator}, nil
+}
+
+// Score 返回两个嵌入向量的对数似然比
+func (c *CalibratedScorer) Score(enroll, test *Embedding) (float32, error) {
+	score, err := c.base.Score(enroll, test)
+	if err != nil {
+		return 0, err
+	}
+	return float32(c.calibrator.LLR(float64(score))), nil
+}
+
+// ScorerName 返回打分器的名称，例如cosine、s-norm(cosine)，用于记录阈值和校准器所针对的打分器
+// 未知的打分器返回其类型名
+func ScorerName(scorer Scorer) string {
+	switch sc := scorer.(type) {
+	case nil, CosineScorer:
+		return "cosine"
+	case L2Scorer:
+		return "l2"
+	case *CalibratedScorer:
+		return "calibrated(" + ScorerName(sc.base) + ")"
+	case *ScoreNormalizer:
+		return sc.method.String() + "(" + ScorerName(sc.base) + ")"
+	default:
+		return fmt.Sprintf("%T", scorer)
+	}
+}
+
+// SetScorer 设置打分器，CompareSpeakers、CompareEmbeddings、IsSameSpeaker等比较方法都通过该打分器计算分数，
+// 传入nil恢复为余弦打分
+//
+// 注意: 不同打分器的分数范围不同（例如PLDA和CalibratedScorer输出对数似然比），阈值和校准器需要针对所用打分器重新选择
+func (s *Speaker) SetScorer(scorer Scorer) {
+	s.scorer = scorer
+}
+
+// Scorer 返回当前使用的打分器
+func (s *Speaker) Scorer() Scorer {
+	if s.scorer == nil {
+		return CosineScorer{}
+	}
+	return s.scorer
+}
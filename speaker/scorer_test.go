@@ -0,0 +1,93 @@
+package speaker
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// unitEmbedding 生成L2归一化的随机嵌入向量
+func unitEmbedding(rng *rand.Rand, dim int) *Embedding {
+	emb := randomEmbedding(rng, dim)
+	var norm float64
+	for _, v := range emb.data {
+		norm += float64(v) * float64(v)
+	}
+	for i := range emb.data {
+		emb.data[i] /= float32(math.Sqrt(norm))
+	}
+	return emb
+}
+
+// TestScorers 测试各打分器的一致性以及Speaker的打分器配置
+func TestScorers(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	a, b := unitEmbedding(rng, 16), unitEmbedding(rng, 16)
+
+	cosine, err := CosineScorer{}.Score(a, b)
+	if err != nil {
+		t.Fatalf("余弦打分失败: %v", err)
+	}
+	l2, err := L2Scorer{}.Score(a, b)
+	if err != nil {
+		t.Fatalf("L2打分失败: %v", err)
+	}
+	// 单位向量满足d²=2-2cos
+	if math.Abs(float64(l2*l2-(2-2*cosine))) > 1e-4 {
+		t.Fatalf("L2分数与余弦分数不一致: l2=%v cos=%v", l2, cosine)
+	}
+	// L2Scorer的分数为负L2距离，混合评分按exp(-d)将距离映射为相似度
+	hybrid, err := HybridSimilarity(a, b, 0.3)
+	if want := 0.3*float64(cosine) + 0.7*math.Exp(float64(l2)); err != nil || math.Abs(float64(hybrid)-want) > 1e-4 {
+		t.Fatalf("混合分数应为%v，实际为%v (%v)", want, hybrid, err)
+	}
+
+	cal := &Calibrator{Scale: 10, Offset: -5}
+	calibrated, err := NewCalibratedScorer(nil, cal)
+	if err != nil {
+		t.Fatalf("创建校准打分器失败: %v", err)
+	}
+	llr, _ := calibrated.Score(a, b)
+	if math.Abs(float64(llr)-cal.LLR(float64(cosine))) > 1e-4 {
+		t.Fatalf("校准分数不正确: %v", llr)
+	}
+	if _, err := NewCalibratedScorer(nil, nil); err == nil {
+		t.Fatal("校准器为空时应返回错误")
+	}
+
+	// 规整器可以基于其他打分器
+	cohort := make([]*Embedding, 50)
+	for i := range cohort {
+		cohort[i] = unitEmbedding(rng, 16)
+	}
+	n, err := NewScoreNormalizer(cohort, ZNorm, 0)
+	if err != nil {
+		t.Fatalf("创建规整器失败: %v", err)
+	}
+	n.SetBase(L2Scorer{})
+	if _, err := n.Score(a, b); err != nil {
+		t.Fatalf("基于L2的规整打分失败: %v", err)
+	}
+
+	// Speaker的比较方法通过所配置的打分器计算
+	s := &Speaker{}
+	if _, ok := s.Scorer().(CosineScorer); !ok {
+		t.Fatal("默认打分器应为余弦打分")
+	}
+	s.SetScorer(calibrated)
+	if score, _ := s.CompareEmbeddings(a, b); score != llr {
+		t.Fatalf("CompareEmbeddings未使用所配置的打分器: %v vs %v", score, llr)
+	}
+	// 打分器已输出对数似然比时，同时设置的校准器不再重复校准
+	s.SetCalibrator(cal)
+	if got := s.llr(llr); got != float64(llr) {
+		t.Fatalf("校准打分器的分数被重复校准: %v vs %v", got, llr)
+	}
+	s.SetNormalizer(nil)
+	if _, ok := s.Scorer().(CosineScorer); !ok {
+		t.Fatal("SetNormalizer(nil)应恢复为余弦打分")
+	}
+	if got := s.llr(cosine); got != cal.LLR(float64(cosine)) {
+		t.Fatalf("余弦分数应经校准器转换: %v", got)
+	}
+}
@@ -8,7 +8,15 @@ import (
 
 // Speaker 提供了说话人识别的高级API
 type Speaker struct {
-	model *ModelHandle
+	model      *ModelHandle
+	transform  Transform
+	scorer     Scorer
+	calibrator *Calibrator
+	threshold  *float32
+	raw        bool
+
+	qualityConfig *QualityConfig
+	minQuality    float64
 }
 
 // New 创建一个新的Speaker实例
@@ -37,7 +45,40 @@ func (s *Speaker) Close() error {
 	return nil
 }
 
+// SetNormalizer 设置分数规整器，等价于SetScorer(n)，传入nil恢复为余弦打分
+//
+// 注意: 规整后的分数不在[-1,1]范围内，IsSameSpeaker需要传入针对规整分数选择的阈值
+func (s *Speaker) SetNormalizer(n *ScoreNormalizer) {
+	if n == nil {
+		s.scorer = nil
+		return
+	}
+	s.scorer = n
+}
+
+// SetCalibrator 设置分数校准器，用于IsSameSpeakerAt按贝叶斯最优阈值判决
+// 校准器需使用当前打分器输出的分数训练
+func (s *Speaker) SetCalibrator(c *Calibrator) {
+	s.calibrator = c
+}
+
+// Fingerprint 返回所加载模型的指纹，用于校验持久化的嵌入向量是否与当前模型兼容
+func (s *Speaker) Fingerprint() string {
+	if s.model == nil {
+		return ""
+	}
+	return s.model.Fingerprint()
+}
+
+// SetRawEmbedding 设置ExtractEmbedding是否输出未归一化的模型原始输出，默认输出L2归一化的向量
+// 原始范数在两种模式下都可以通过Embedding.Norm获取
+func (s *Speaker) SetRawEmbedding(raw bool) {
+	s.raw = raw
+}
+
 // ExtractEmbedding 从PCM音频数据中提取说话人嵌入向量[必须是16khz单声道音频]
+// 默认输出L2归一化的向量，SetRawEmbedding(true)时输出模型原始输出；设置了嵌入向量变换时返回变换后的向量。
+// 模型输出范数为0（例如纯静音）时返回ErrZeroNorm
 //
 // 参数:
 //   - pcmData: PCM音频数据，int16格式
@@ -48,7 +89,23 @@ func (s *Speaker) ExtractEmbedding(pcmData []int16) (*Embedding, error) {
 	if s.model == nil {
 		return nil, errors.New("Speaker实例已关闭或未初始化")
 	}
-	return s.model.ExtractEmbedding(pcmData)
+	emb, err := s.model.extract(pcmData, !s.raw)
+	if err != nil {
+		return nil, err
+	}
+	return s.applyTransform(emb)
+}
+
+// applyTransform 对提取的嵌入向量应用变换，未设置变换时原样返回
+func (s *Speaker) applyTransform(emb *Embedding) (*Embedding, error) {
+	if s.transform == nil {
+		return emb, nil
+	}
+	emb, err := s.transform.Apply(emb)
+	if err != nil {
+		return nil, fmt.Errorf("嵌入向量变换失败: %w", err)
+	}
+	return emb, nil
 }
 
 // CompareSpeakers 比较两段音频的说话人相似度[必须是16khz单声道音频]
@@ -58,7 +115,7 @@ func (s *Speaker) ExtractEmbedding(pcmData []int16) (*Embedding, error) {
 //   - pcm2: 第二段PCM音频数据
 //
 // 返回:
-//   - 相似度[-1,1]，越接近1表示越相似
+//   - 当前打分器给出的分数，越大表示越相似；默认为余弦相似度[-1,1]
 //   - 可能的错误
 func (s *Speaker) CompareSpeakers(pcm1, pcm2 []int16) (float32, error) {
 	if s.model == nil {
@@ -77,13 +134,40 @@ func (s *Speaker) CompareSpeakers(pcm1, pcm2 []int16) (float32, error) {
 		return 0, fmt.Errorf("提取第二段音频嵌入向量失败: %w", err)
 	}
 
-	// 计算余弦相似度
-	similarity, err := CosineSimilarity(emb1, emb2)
+	return s.CompareEmbeddings(emb1, emb2)
+}
+
+// compareChecked 与CompareSpeakers相同，但设置了最低质量时检查两段音频的质量
+func (s *Speaker) compareChecked(pcm1, pcm2 []int16) (float32, error) {
+	if s.model == nil {
+		return 0, errors.New("Speaker实例已关闭或未初始化")
+	}
+	emb1, err := s.extractChecked(pcm1)
+	if err != nil {
+		return 0, fmt.Errorf("提取第一段音频嵌入向量失败: %w", err)
+	}
+	emb2, err := s.extractChecked(pcm2)
 	if err != nil {
-		return 0, fmt.Errorf("计算余弦相似度失败: %w", err)
+		return 0, fmt.Errorf("提取第二段音频嵌入向量失败: %w", err)
 	}
+	return s.CompareEmbeddings(emb1, emb2)
+}
 
-	return similarity, nil
+// CompareEmbeddings 使用当前打分器比较两个嵌入向量的说话人相似度
+//
+// 参数:
+//   - enroll: 注册（参考）嵌入向量
+//   - test: 测试嵌入向量
+//
+// 返回:
+//   - 当前打分器给出的分数，越大表示越相似；默认为余弦相似度[-1,1]
+//   - 可能的错误
+func (s *Speaker) CompareEmbeddings(enroll, test *Embedding) (float32, error) {
+	score, err := s.Scorer().Score(enroll, test)
+	if err != nil {
+		return 0, fmt.Errorf("计算分数失败: %w", err)
+	}
+	return score, nil
 }
 
 // IsSameSpeaker 判断两段音频是否来自同一说话人[必须是16khz单声道音频]
@@ -91,31 +175,78 @@ func (s *Speaker) CompareSpeakers(pcm1, pcm2 []int16) (float32, error) {
 // 参数:
 //   - pcm1: 第一段PCM音频数据
 //   - pcm2: 第二段PCM音频数据
-//   - threshold: 判断阈值，默认为0.70
+//   - threshold: 判断阈值，<=0时使用SetThreshold或LoadThreshold配置的阈值，未配置时为0.70；
+//     规整、PLDA或校准打分的阈值可能为0或负数，此时请使用IsSameSpeakerWithThreshold
+//
+// 设置了最低质量（SetMinQuality）时，任一段音频质量不满足要求都会返回ErrLowQuality
 //
 // 返回:
 //   - 是否为同一说话人
 //   - 相似度分数
 //   - 可能的错误
 func (s *Speaker) IsSameSpeaker(pcm1, pcm2 []int16, threshold float32) (bool, float32, error) {
-	// 如果未指定阈值，使用默认值0.70
+	// 如果未指定阈值，使用配置的阈值
 	if threshold <= 0 {
-		threshold = 0.70
+		threshold = s.Threshold()
 	}
+	return s.IsSameSpeakerWithThreshold(pcm1, pcm2, threshold)
+}
 
-	// 直接使用余弦相似度计算
-	similarity, err := s.CompareSpeakers(pcm1, pcm2)
+// IsSameSpeakerWithThreshold 与IsSameSpeaker相同，但阈值按原值使用，可以为0或负数（例如对数似然比）
+func (s *Speaker) IsSameSpeakerWithThreshold(pcm1, pcm2 []int16, threshold float32) (bool, float32, error) {
+	similarity, err := s.compareChecked(pcm1, pcm2)
 	if err != nil {
 		return false, 0, err
 	}
 
-	// 输出调试信息
-	// fmt.Printf("使用纯余弦相似度计算，得分=%.4f\n", similarity)
-
 	// 判断是否相似
 	return similarity >= threshold, similarity, nil
 }
 
+// IsSameSpeakerAt 在给定工作点下按贝叶斯最优阈值判断两段音频是否来自同一说话人[必须是16khz单声道音频]
+// 需要先通过SetCalibrator设置校准器，或者使用CalibratedScorer作为打分器（此时忽略SetCalibrator设置的校准器）；
+// 设置了最低质量时同样检查两段音频的质量
+//
+// 参数:
+//   - pcm1: 第一段PCM音频数据
+//   - pcm2: 第二段PCM音频数据
+//   - op: 工作点（目标先验概率、漏检代价、虚警代价）
+//
+// 返回:
+//   - 是否为同一说话人
+//   - 对数似然比
+//   - 可能的错误
+func (s *Speaker) IsSameSpeakerAt(pcm1, pcm2 []int16, op OperatingPoint) (bool, float64, error) {
+	if s.calibrator == nil && !s.scorerCalibrated() {
+		return false, 0, errors.New("未设置分数校准器")
+	}
+	if err := op.validate(); err != nil {
+		return false, 0, err
+	}
+
+	score, err := s.compareChecked(pcm1, pcm2)
+	if err != nil {
+		return false, 0, err
+	}
+
+	llr := s.llr(score)
+	return llr >= op.BayesThreshold(), llr, nil
+}
+
+// scorerCalibrated 返回当前打分器是否已输出对数似然比
+func (s *Speaker) scorerCalibrated() bool {
+	_, ok := s.scorer.(*CalibratedScorer)
+	return ok
+}
+
+// llr 将当前打分器的分数转换为对数似然比，打分器已输出对数似然比时不再重复校准
+func (s *Speaker) llr(score float32) float64 {
+	if s.calibrator == nil || s.scorerCalibrated() {
+		return float64(score)
+	}
+	return s.calibrator.LLR(float64(score))
+}
+
 // CompareHybrid 使用混合相似度（余弦+L2距离）比较两段音频
 //
 // 参数:
@@ -126,6 +257,9 @@ func (s *Speaker) IsSameSpeaker(pcm1, pcm2 []int16, threshold float32) (bool, fl
 // 返回:
 //   - 混合相似度评分（值越高越相似）
 //   - 可能的错误
+//
+// Deprecated: 嵌入向量已做L2归一化，L2距离与余弦相似度一一对应，混合评分不包含额外信息。
+// 请使用CompareSpeakers，需要L2打分时使用SetScorer(L2Scorer{})
 func (s *Speaker) CompareHybrid(pcm1, pcm2 []int16, cosineWeight float32) (float32, error) {
 	if s.model == nil {
 		return 0, errors.New("Speaker实例已关闭或未初始化")
@@ -0,0 +1,407 @@
+package speaker
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// 流式处理的采样率
+const streamSampleRate = 16000
+
+// StreamConfig 流式验证配置
+type StreamConfig struct {
+	WindowSeconds   float64 // 环形缓冲区保存的最近音频时长（秒），每次在这段音频上提取嵌入向量
+	IntervalSeconds float64 // 每输入这么长的新音频更新一次（秒）
+	MinSeconds      float64 // 缓冲区中至少有这么长的音频才开始更新（秒）
+}
+
+// DefaultStreamConfig 返回默认的流式验证配置
+func DefaultStreamConfig() StreamConfig {
+	return StreamConfig{WindowSeconds: 3, IntervalSeconds: 0.5, MinSeconds: 1}
+}
+
+// ErrInvalidStreamConfig 流式验证配置无效
+var ErrInvalidStreamConfig = errors.New("无效的流式验证配置")
+
+// Validate 检查配置：更新间隔和窗口至少为一个样本，MinSeconds在0到窗口长度之间。
+// NewStream还会检查窗口至少为一帧
+func (c StreamConfig) Validate() error {
+	for _, v := range []float64{c.WindowSeconds, c.IntervalSeconds, c.MinSeconds} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("%w: %+v", ErrInvalidStreamConfig, c)
+		}
+	}
+	if int(c.IntervalSeconds*streamSampleRate) < 1 {
+		return fmt.Errorf("%w: 更新间隔%v秒不足一个样本", ErrInvalidStreamConfig, c.IntervalSeconds)
+	}
+	if int(c.WindowSeconds*streamSampleRate) < 1 {
+		return fmt.Errorf("%w: 窗口长度%v秒不足一个样本", ErrInvalidStreamConfig, c.WindowSeconds)
+	}
+	if c.MinSeconds < 0 || c.MinSeconds > c.WindowSeconds {
+		return fmt.Errorf("%w: 最短音频时长%v秒必须在0到窗口长度%v秒之间", ErrInvalidStreamConfig, c.MinSeconds, c.WindowSeconds)
+	}
+	return nil
+}
+
+// Clamp 返回限制后的配置：窗口长度不超过maxWindow（<=0时不限制），更新间隔和MinSeconds不超过窗口长度。
+// 服务用它限制客户端覆盖的窗口长度和更新间隔，限制后仍需Validate
+func (c StreamConfig) Clamp(maxWindow float64) StreamConfig {
+	if maxWindow > 0 {
+		c.WindowSeconds = min(c.WindowSeconds, maxWindow)
+	}
+	c.IntervalSeconds = min(c.IntervalSeconds, c.WindowSeconds)
+	c.MinSeconds = min(c.MinSeconds, c.WindowSeconds)
+	return c
+}
+
+// StreamUpdate 流式验证的一次更新
+type StreamUpdate struct {
+	Time      float64    // 到目前为止输入的音频时长（秒）
+	Embedding *Embedding // 最近WindowSeconds音频的嵌入向量
+	// Running 到目前为止所有更新的嵌入向量的归一化平均，累积了整个流的证据
+	Running *Embedding
+	// Score 最近窗口与目标声纹的分数，未设置目标时为0
+	Score float32
+	// RunningScore 累积嵌入向量与目标声纹的分数，未设置目标时为0
+	RunningScore float32
+	// Accepted 最近窗口的分数是否达到Speaker的阈值
+	Accepted bool
+}
+
+// ringBuffer 固定容量的int16环形缓冲区，写满后覆盖最早的样本
+type ringBuffer struct {
+	data []int16
+	head int // 下一个写入位置
+	size int
+}
+
+func newRingBuffer(capacity int) *ringBuffer {
+	return &ringBuffer{data: make([]int16, capacity)}
+}
+
+// write 写入样本，超出容量的部分覆盖最早的样本
+func (r *ringBuffer) write(pcm []int16) {
+	if len(pcm) >= len(r.data) {
+		copy(r.data, pcm[len(pcm)-len(r.data):])
+		r.head, r.size = 0, len(r.data)
+		return
+	}
+	n := copy(r.data[r.head:], pcm)
+	copy(r.data, pcm[n:])
+	r.head = (r.head + len(pcm)) % len(r.data)
+	r.size = min(r.size+len(pcm), len(r.data))
+}
+
+// snapshot 按时间顺序返回缓冲区内容的副本
+func (r *ringBuffer) snapshot() []int16 {
+	out := make([]int16, 0, r.size)
+	start := (r.head - r.size + len(r.data)) % len(r.data)
+	if start+r.size <= len(r.data) {
+		return append(out, r.data[start:start+r.size]...)
+	}
+	out = append(out, r.data[start:]...)
+	return append(out, r.data[:r.head]...)
+}
+
+// frameRing 固定容量的特征帧环形缓冲区，写满后覆盖最早的帧
+type frameRing struct {
+	data    []float32
+	numBins int
+	head    int // 下一个写入的帧位置
+	size    int // 已保存的帧数
+}
+
+func newFrameRing(capacity, numBins int) *frameRing {
+	return &frameRing{data: make([]float32, capacity*numBins), numBins: numBins}
+}
+
+// write 写入特征帧，超出容量的部分覆盖最早的帧
+func (r *frameRing) write(f *Features) {
+	capacity := len(r.data) / r.numBins
+	for i := max(f.NumFrames()-capacity, 0); i < f.NumFrames(); i++ {
+		copy(r.data[r.head*r.numBins:], f.Frame(i))
+		r.head = (r.head + 1) % capacity
+		r.size = min(r.size+1, capacity)
+	}
+}
+
+// snapshot 按时间顺序返回缓冲区内容的副本
+func (r *frameRing) snapshot() *Features {
+	capacity := len(r.data) / r.numBins
+	out := &Features{Data: make([]float32, 0, r.size*r.numBins), NumBins: r.numBins}
+	for i := 0; i < r.size; i++ {
+		k := (r.head - r.size + i + capacity) % capacity
+		out.Data = append(out.Data, r.data[k*r.numBins:(k+1)*r.numBins]...)
+	}
+	return out
+}
+
+// streamWindow 保存最近WindowSeconds的输入，并在其上提取嵌入向量
+type streamWindow interface {
+	write(pcm []int16) error
+	// ready 判断窗口内的音频是否至少有minSamples个样本，可以提取嵌入向量
+	ready(minSamples int) bool
+	embed() (*Embedding, error)
+	close()
+}
+
+// pcmWindow 保存最近的PCM样本，每次更新都在整个窗口上重新计算特征
+type pcmWindow struct {
+	ring    *ringBuffer
+	extract func(pcm []int16) (*Embedding, error)
+}
+
+func (w *pcmWindow) write(pcm []int16) error {
+	w.ring.write(pcm)
+	return nil
+}
+
+func (w *pcmWindow) ready(minSamples int) bool {
+	return w.ring.size > 0 && w.ring.size >= minSamples
+}
+
+func (w *pcmWindow) embed() (*Embedding, error) {
+	return w.extract(w.ring.snapshot())
+}
+
+func (w *pcmWindow) close() {}
+
+// featureWindow 增量计算FBANK特征，只保存最近窗口的特征帧，更新时直接在特征上提取嵌入向量，
+// 每个样本的特征只计算一次。窗口长度和更新间隔为帧移的整数倍时，结果与pcmWindow一致
+type featureWindow struct {
+	fbank   *FbankStream
+	info    FbankInfo
+	frames  *frameRing
+	extract func(f *Features) (*Embedding, error)
+}
+
+func (w *featureWindow) write(pcm []int16) error {
+	f, err := w.fbank.Accept(pcm)
+	if err != nil {
+		return err
+	}
+	w.frames.write(f)
+	return nil
+}
+
+// ready 按已缓存的帧数判断：minSamples个样本整段计算时得到的帧数都已缓存
+func (w *featureWindow) ready(minSamples int) bool {
+	return w.frames.size > 0 && w.frames.size >= w.info.NumFrames(minSamples)
+}
+
+func (w *featureWindow) embed() (*Embedding, error) {
+	return w.extract(w.frames.snapshot())
+}
+
+func (w *featureWindow) close() {
+	w.fbank.Close()
+}
+
+// Stream 流式说话人验证，用于通话过程中的持续身份认证
+// 通过Write（小端int16字节流，实现io.Writer）、WriteSamples或ReadFrom（实现io.ReaderFrom）输入16kHz单声道音频，
+// 每输入IntervalSeconds的新音频，就在最近WindowSeconds的音频上提取嵌入向量，并与目标声纹打分。
+// FBANK特征随输入增量计算，每次更新只在缓存的特征上运行模型
+// 非并发安全
+type Stream struct {
+	score     func(enroll, test *Embedding) (float32, error)
+	threshold float32
+	target    *Embedding
+	cfg       StreamConfig
+
+	window   streamWindow
+	odd      []byte // Write时不足一个样本的剩余字节
+	total    int    // 已输入的样本数
+	since    int    // 上次更新后输入的样本数
+	running  []float32
+	latest   *StreamUpdate
+	onUpdate func(StreamUpdate)
+}
+
+// NewStream 创建流式验证，target为目标声纹，为nil时只输出嵌入向量
+// 分数使用Speaker当前的打分器和嵌入向量变换，判决使用Speaker的阈值
+func (s *Speaker) NewStream(target *Embedding, cfg StreamConfig) (*Stream, error) {
+	if s.model == nil {
+		return nil, errors.New("Speaker实例已关闭或未初始化")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	// 窗口内的样本整段计算时得到的帧数
+	info := s.model.FbankInfo()
+	frames := info.NumFrames(int(cfg.WindowSeconds * streamSampleRate))
+	if frames == 0 {
+		return nil, fmt.Errorf("%w: 窗口长度%v秒不足一帧", ErrInvalidStreamConfig, cfg.WindowSeconds)
+	}
+	fbank, err := s.model.NewFbankStream()
+	if err != nil {
+		return nil, fmt.Errorf("创建流式验证失败: %w", err)
+	}
+	window := &featureWindow{
+		fbank:   fbank,
+		info:    info,
+		frames:  newFrameRing(frames, info.NumBins),
+		extract: s.ExtractEmbeddingFromFeatures,
+	}
+	return newStreamWithWindow(window, s.CompareEmbeddings, s.Threshold(), target, cfg), nil
+}
+
+// newStream 使用给定的提取和打分函数创建流式验证，每次更新在PCM窗口上提取嵌入向量，便于在没有模型时测试
+func newStream(extract func([]int16) (*Embedding, error), score func(enroll, test *Embedding) (float32, error),
+	threshold float32, target *Embedding, cfg StreamConfig) *Stream {
+	window := &pcmWindow{ring: newRingBuffer(int(cfg.WindowSeconds * streamSampleRate)), extract: extract}
+	return newStreamWithWindow(window, score, threshold, target, cfg)
+}
+
+// newStreamWithWindow 使用给定的窗口创建流式验证
+func newStreamWithWindow(window streamWindow, score func(enroll, test *Embedding) (float32, error),
+	threshold float32, target *Embedding, cfg StreamConfig) *Stream {
+	return &Stream{
+		score:     score,
+		threshold: threshold,
+		target:    target,
+		cfg:       cfg,
+		window:    window,
+	}
+}
+
+// Close 释放流式特征提取器，之后不能再输入音频
+func (st *Stream) Close() {
+	st.window.close()
+}
+
+// OnUpdate 设置每次更新时调用的回调函数，回调在Write/WriteSamples/ReadFrom的调用方goroutine中执行
+func (st *Stream) OnUpdate(fn func(StreamUpdate)) {
+	st.onUpdate = fn
+}
+
+// Latest 返回最近一次更新，尚未更新时返回false
+func (st *Stream) Latest() (StreamUpdate, bool) {
+	if st.latest == nil {
+		return StreamUpdate{}, false
+	}
+	return *st.latest, true
+}
+
+// Write 输入小端int16字节流，实现io.Writer；奇数长度的末尾字节会保留到下次写入
+func (st *Stream) Write(p []byte) (int, error) {
+	n := len(p)
+	if len(st.odd) > 0 {
+		p = append(st.odd, p...)
+		st.odd = nil
+	}
+	if len(p)%2 == 1 {
+		st.odd = []byte{p[len(p)-1]}
+		p = p[:len(p)-1]
+	}
+	pcm := make([]int16, len(p)/2)
+	for i := range pcm {
+		pcm[i] = int16(binary.LittleEndian.Uint16(p[2*i:]))
+	}
+	if err := st.WriteSamples(pcm); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// ReadFrom 从r读取小端int16字节流直到EOF，实现io.ReaderFrom
+func (st *Stream) ReadFrom(r io.Reader) (int64, error) {
+	buf := make([]byte, int(st.cfg.IntervalSeconds*streamSampleRate)*2)
+	var total int64
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			total += int64(n)
+			if _, werr := st.Write(buf[:n]); werr != nil {
+				return total, werr
+			}
+		}
+		if err == io.EOF {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+}
+
+// WriteSamples 输入PCM样本，按更新间隔计算嵌入向量和分数
+func (st *Stream) WriteSamples(pcm []int16) error {
+	interval := int(st.cfg.IntervalSeconds * streamSampleRate)
+	minSamples := int(st.cfg.MinSeconds * streamSampleRate)
+	for len(pcm) > 0 {
+		// 按更新间隔切分，保证每个更新点都能看到对应时刻的缓冲区
+		n := min(len(pcm), interval-st.since)
+		if err := st.window.write(pcm[:n]); err != nil {
+			return fmt.Errorf("流式计算特征失败: %w", err)
+		}
+		st.total += n
+		st.since += n
+		pcm = pcm[n:]
+		if st.since < interval {
+			continue
+		}
+		st.since = 0
+		if !st.window.ready(minSamples) {
+			continue
+		}
+		if err := st.update(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// update 在缓冲区内容上提取嵌入向量并打分
+func (st *Stream) update() error {
+	emb, err := st.window.embed()
+	if errors.Is(err, ErrZeroNorm) {
+		// 静音不产生更新
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("流式提取嵌入向量失败: %w", err)
+	}
+
+	// 累积嵌入向量为各次更新的归一化向量之和
+	if st.running == nil {
+		st.running = make([]float32, len(emb.data))
+	}
+	if len(st.running) != len(emb.data) {
+		return fmt.Errorf("嵌入向量维度不匹配: %d vs %d", len(emb.data), len(st.running))
+	}
+	if n := norm(emb.data); n > 0 {
+		for i, v := range emb.data {
+			st.running[i] += v / n
+		}
+	}
+	mean := append([]float32(nil), st.running...)
+	if n := norm(mean); n > 0 {
+		for i := range mean {
+			mean[i] /= n
+		}
+	}
+	running := NewEmbedding(mean)
+
+	u := StreamUpdate{
+		Time:      float64(st.total) / streamSampleRate,
+		Embedding: emb,
+		Running:   running,
+	}
+	if st.target != nil {
+		if u.Score, err = st.score(st.target, emb); err != nil {
+			return fmt.Errorf("流式打分失败: %w", err)
+		}
+		if u.RunningScore, err = st.score(st.target, running); err != nil {
+			return fmt.Errorf("流式打分失败: %w", err)
+		}
+		u.Accepted = u.Score >= st.threshold
+	}
+	st.latest = &u
+	if st.onUpdate != nil {
+		st.onUpdate(u)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package speaker
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+)
+
+// TestRingBuffer 测试环形缓冲区的覆盖和顺序
+func TestRingBuffer(t *testing.T) {
+	r := newRingBuffer(5)
+	r.write([]int16{1, 2, 3})
+	r.write([]int16{4, 5, 6, 7})
+	if got := r.snapshot(); len(got) != 5 || got[0] != 3 || got[4] != 7 {
+		t.Fatalf("缓冲区内容不正确: %v", got)
+	}
+	r.write([]int16{8, 9, 10, 11, 12, 13})
+	if got := r.snapshot(); len(got) != 5 || got[0] != 9 || got[4] != 13 {
+		t.Fatalf("写入超过容量后缓冲区内容不正确: %v", got)
+	}
+}
+
+// TestFrameRing 测试特征帧环形缓冲区的覆盖和顺序
+func TestFrameRing(t *testing.T) {
+	frames := func(values ...float32) *Features {
+		f := &Features{NumBins: 2}
+		for _, v := range values {
+			f.Data = append(f.Data, v, -v)
+		}
+		return f
+	}
+	r := newFrameRing(3, 2)
+	r.write(frames(1, 2))
+	r.write(frames(3, 4))
+	if got := r.snapshot(); got.NumFrames() != 3 || got.Frame(0)[0] != 2 || got.Frame(2)[1] != -4 {
+		t.Fatalf("缓冲区内容不正确: %v", got.Data)
+	}
+	r.write(frames(5, 6, 7, 8, 9))
+	if got := r.snapshot(); got.NumFrames() != 3 || got.Frame(0)[0] != 7 || got.Frame(2)[0] != 9 {
+		t.Fatalf("写入超过容量后缓冲区内容不正确: %v", got.Data)
+	}
+
+	info := FbankInfo{NumBins: 80, FrameLength: 400, FrameShift: 160}
+	if info.NumFrames(399) != 0 || info.NumFrames(400) != 1 || info.NumFrames(16000) != 98 {
+		t.Fatalf("帧数计算不正确")
+	}
+	if info.Samples(info.NumFrames(16000)) != 15920 {
+		t.Fatalf("帧覆盖的样本数不正确: %d", info.Samples(98))
+	}
+}
+
+// TestStream 测试流式输入的更新节奏和打分
+func TestStream(t *testing.T) {
+	// 嵌入向量为缓冲区的[样本数, 第一个样本]，便于检查每次更新看到的音频
+	var seen []int
+	extract := func(pcm []int16) (*Embedding, error) {
+		seen = append(seen, len(pcm))
+		if pcm[len(pcm)-1] == 0 {
+			return nil, ErrZeroNorm
+		}
+		return NewEmbedding([]float32{1, float32(pcm[0]) / 1000}), nil
+	}
+	target := NewEmbedding([]float32{1, 0})
+	cfg := StreamConfig{WindowSeconds: 1, IntervalSeconds: 0.25, MinSeconds: 0.5}
+	st := newStream(extract, CosineScorer{}.Score, 0.9, target, cfg)
+	var updates []StreamUpdate
+	st.OnUpdate(func(u StreamUpdate) { updates = append(updates, u) })
+
+	// 2秒音频，第i个样本值为i/16
+	var buf bytes.Buffer
+	for i := 0; i < 2*streamSampleRate; i++ {
+		binary.Write(&buf, binary.LittleEndian, int16(i/16+1))
+	}
+	// 以奇数长度分块写入
+	data := buf.Bytes()
+	for len(data) > 0 {
+		n := min(len(data), 777)
+		if _, err := st.Write(data[:n]); err != nil {
+			t.Fatalf("写入失败: %v", err)
+		}
+		data = data[n:]
+	}
+
+	// 0.5秒开始更新，每0.25秒一次，共7次
+	if len(updates) != 7 {
+		t.Fatalf("更新次数应为7，实际为%d", len(updates))
+	}
+	if seen[0] != streamSampleRate/2 || seen[len(seen)-1] != streamSampleRate {
+		t.Fatalf("更新使用的音频长度不正确: %v", seen)
+	}
+	last, ok := st.Latest()
+	if !ok || last.Time != 2 || last != updates[6] {
+		t.Fatalf("最近一次更新不正确: %+v", last)
+	}
+	// 最后一次的窗口从第1秒开始，与目标的相似度较低；累积向量介于各次更新之间
+	if last.Accepted || updates[0].Score < last.Score || last.RunningScore < last.Score {
+		t.Fatalf("分数不正确: %+v", last)
+	}
+	if math.Abs(float64(last.Running.Norm())-1) > 1e-5 {
+		t.Fatalf("累积嵌入向量应为单位向量: %v", last.Running.Norm())
+	}
+
+	// 静音不产生更新
+	st.WriteSamples(make([]int16, streamSampleRate))
+	if len(updates) != 7 {
+		t.Fatalf("静音不应产生更新: %d", len(updates))
+	}
+	if _, err := st.ReadFrom(bytes.NewReader(make([]byte, 100))); err != nil {
+		t.Fatalf("ReadFrom失败: %v", err)
+	}
+}
+
+// TestStreamConfigValidate 测试拒绝不足一个样本的更新间隔和窗口
+func TestStreamConfigValidate(t *testing.T) {
+	if err := DefaultStreamConfig().Validate(); err != nil {
+		t.Fatalf("默认配置应有效: %v", err)
+	}
+	for _, cfg := range []StreamConfig{
+		{WindowSeconds: 3, IntervalSeconds: 1e-5},
+		{WindowSeconds: 1e-5, IntervalSeconds: 0.5},
+		{WindowSeconds: 3, IntervalSeconds: math.NaN()},
+		{WindowSeconds: math.Inf(1), IntervalSeconds: 0.5},
+		{WindowSeconds: 1, IntervalSeconds: 0.5, MinSeconds: 2},
+	} {
+		if err := cfg.Validate(); !errors.Is(err, ErrInvalidStreamConfig) {
+			t.Errorf("%+v 应返回ErrInvalidStreamConfig，实际为%v", cfg, err)
+		}
+	}
+
+	// 窗口长度被限制在上限内，更新间隔和MinSeconds不超过窗口长度
+	cfg := StreamConfig{WindowSeconds: 1e12, IntervalSeconds: 1e12, MinSeconds: 1e12}.Clamp(30)
+	if cfg != (StreamConfig{WindowSeconds: 30, IntervalSeconds: 30, MinSeconds: 30}) || cfg.Validate() != nil {
+		t.Fatalf("限制后的配置不正确: %+v", cfg)
+	}
+	if cfg := DefaultStreamConfig().Clamp(0); cfg != DefaultStreamConfig() {
+		t.Fatalf("不超过上限的配置不应改变: %+v", cfg)
+	}
+}
+
+// TestFeatureWindowReady 测试按已缓存的帧数而不是已输入的样本数判断是否开始更新
+func TestFeatureWindowReady(t *testing.T) {
+	info := FbankInfo{NumBins: 2, FrameLength: 400, FrameShift: 160}
+	w := &featureWindow{info: info, frames: newFrameRing(info.NumFrames(16000), 2)}
+	if w.ready(0) {
+		t.Fatal("没有帧时不应开始更新")
+	}
+	// 8000个样本整段计算得到48帧
+	w.frames.write(&Features{Data: make([]float32, 47*2), NumBins: 2})
+	if w.ready(8000) {
+		t.Fatal("帧数不足时不应开始更新")
+	}
+	w.frames.write(&Features{Data: make([]float32, 2), NumBins: 2})
+	if !w.ready(8000) {
+		t.Fatal("帧数足够时应开始更新")
+	}
+}
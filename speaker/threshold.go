@@ -0,0 +1,95 @@
+package speaker
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+)
+
+// DefaultThreshold 未配置阈值时IsSameSpeaker使用的默认余弦相似度阈值
+const DefaultThreshold float32 = 0.70
+
+// ThresholdConfig 判决阈值配置，一般由spk threshold根据带标签数据生成
+type ThresholdConfig struct {
+	Threshold   float32 `json:"threshold"`             // 判决阈值，分数不低于该值时判为同一人
+	Lower       float32 `json:"lower,omitempty"`       // 阈值置信区间下限
+	Upper       float32 `json:"upper,omitempty"`       // 阈值置信区间上限
+	Confidence  float64 `json:"confidence,omitempty"`  // 置信水平
+	Criterion   string  `json:"criterion,omitempty"`   // 阈值选择准则（far/eer/mindcf）
+	FAR         float64 `json:"far"`                   // 该阈值在标注数据上的虚警率
+	FRR         float64 `json:"frr"`                   // 该阈值在标注数据上的漏检率
+	Trials      int     `json:"trials,omitempty"`      // 标注数据的试验数
+	Fingerprint string  `json:"fingerprint,omitempty"` // 生成阈值时使用的模型指纹
+	Scorer      string  `json:"scorer,omitempty"`      // 生成阈值时使用的打分器（ScorerName）
+}
+
+// Save 将阈值配置保存为JSON文件
+func (c *ThresholdConfig) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "    ")
+	if err != nil {
+		return fmt.Errorf("序列化阈值配置失败: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("写入阈值配置失败: %w", err)
+	}
+	return nil
+}
+
+// LoadThresholdConfig 从JSON文件加载阈值配置
+func LoadThresholdConfig(path string) (*ThresholdConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取阈值配置失败: %w", err)
+	}
+	var c ThresholdConfig
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("解析阈值配置失败: %w", err)
+	}
+	return &c, nil
+}
+
+// SetThreshold 设置IsSameSpeaker和Verify在阈值参数<=0时使用的默认阈值，阈值本身可以为0或负数；传入NaN恢复为DefaultThreshold
+func (s *Speaker) SetThreshold(threshold float32) {
+	if math.IsNaN(float64(threshold)) {
+		s.threshold = nil
+		return
+	}
+	s.threshold = &threshold
+}
+
+// Threshold 返回IsSameSpeaker和Verify在阈值参数<=0时使用的默认阈值
+func (s *Speaker) Threshold() float32 {
+	if s.threshold == nil {
+		return DefaultThreshold
+	}
+	return *s.threshold
+}
+
+// ResolveThreshold 按优先级返回第一个非nil的阈值，都为nil时返回Threshold()
+// 服务按“请求 > 服务配置 > Speaker配置”选择阈值，阈值可以为0或负数，未指定以nil表示
+func (s *Speaker) ResolveThreshold(thresholds ...*float32) float32 {
+	for _, t := range thresholds {
+		if t != nil {
+			return *t
+		}
+	}
+	return s.Threshold()
+}
+
+// LoadThreshold 从阈值配置文件加载默认阈值
+// 配置中记录了模型指纹或打分器时，会校验是否与当前模型和打分器一致，因此需要先设置打分器
+func (s *Speaker) LoadThreshold(path string) error {
+	c, err := LoadThresholdConfig(path)
+	if err != nil {
+		return err
+	}
+	if c.Fingerprint != "" && c.Fingerprint != s.Fingerprint() {
+		return fmt.Errorf("阈值配置的模型指纹 %s 与当前模型 %s 不一致", c.Fingerprint, s.Fingerprint())
+	}
+	if c.Scorer != "" && c.Scorer != ScorerName(s.Scorer()) {
+		return fmt.Errorf("阈值配置针对打分器 %s，当前打分器为 %s", c.Scorer, ScorerName(s.Scorer()))
+	}
+	s.SetThreshold(c.Threshold)
+	return nil
+}
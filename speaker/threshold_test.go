@@ -0,0 +1,69 @@
+package speaker
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+// TestThreshold 测试默认阈值的配置，配置的阈值可以为0或负数
+func TestThreshold(t *testing.T) {
+	s := &Speaker{}
+	if s.Threshold() != DefaultThreshold {
+		t.Fatalf("未配置时应使用默认阈值: %v", s.Threshold())
+	}
+
+	// 对数似然比等分数的阈值可以为0或负数
+	for _, threshold := range []float32{0, -1.5} {
+		s.SetThreshold(threshold)
+		if s.Threshold() != threshold {
+			t.Fatalf("配置的阈值应为%v，实际为%v", threshold, s.Threshold())
+		}
+	}
+
+	s.SetThreshold(float32(math.NaN()))
+	if s.Threshold() != DefaultThreshold {
+		t.Fatalf("传入NaN应恢复为默认阈值: %v", s.Threshold())
+	}
+}
+
+// TestResolveThreshold 测试阈值的优先级，以及0和负数阈值不被当作未指定
+func TestResolveThreshold(t *testing.T) {
+	s := &Speaker{}
+	if got := s.ResolveThreshold(nil, nil); got != DefaultThreshold {
+		t.Fatalf("都未指定时应使用Speaker的阈值: %v", got)
+	}
+	zero, negative := float32(0), float32(-2)
+	if got := s.ResolveThreshold(nil, &zero, &negative); got != 0 {
+		t.Fatalf("应使用第一个指定的阈值0: %v", got)
+	}
+	if got := s.ResolveThreshold(&negative, &zero); got != -2 {
+		t.Fatalf("应使用第一个指定的阈值-2: %v", got)
+	}
+}
+
+// TestLoadThreshold 测试阈值配置记录的打分器与当前打分器不一致时拒绝加载
+func TestLoadThreshold(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "threshold.json")
+	if err := (&ThresholdConfig{Threshold: 0.4, Scorer: ScorerName(CosineScorer{})}).Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Speaker{}
+	if err := s.LoadThreshold(path); err != nil || s.Threshold() != 0.4 {
+		t.Fatalf("加载余弦打分的阈值失败: %v %v", err, s.Threshold())
+	}
+
+	calibrated, err := NewCalibratedScorer(nil, &Calibrator{Scale: 10, Offset: -5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s = &Speaker{}
+	s.SetScorer(calibrated)
+	if err := s.LoadThreshold(path); err == nil || s.Threshold() != DefaultThreshold {
+		t.Fatalf("余弦打分的阈值不应用于对数似然比: %v", err)
+	}
+	if name := ScorerName(calibrated); name != "calibrated(cosine)" {
+		t.Fatalf("打分器名称错误: %s", name)
+	}
+}
@@ -0,0 +1,469 @@
+package speaker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/seastart/3dspeaker-onnx-go/internal/mat"
+)
+
+// Transform 嵌入向量变换，通过SetTransform设置后在ExtractEmbedding之后应用
+// 用于将部署信道（例如电话）的嵌入向量映射回与训练域相近的分布
+type Transform interface {
+	Apply(emb *Embedding) (*Embedding, error)
+}
+
+// MeanSubtraction 全局均值减除
+type MeanSubtraction struct {
+	Mean []float32 `json:"mean"`
+}
+
+// FitMeanSubtraction 使用域内嵌入向量（无需标签）估计全局均值
+func FitMeanSubtraction(embeddings []*Embedding) (*MeanSubtraction, error) {
+	x, err := embeddingsToFloat64(embeddings)
+	if err != nil {
+		return nil, err
+	}
+	return &MeanSubtraction{Mean: toFloat32(mat.Mean(x))}, nil
+}
+
+// Apply 减去全局均值
+func (t *MeanSubtraction) Apply(emb *Embedding) (*Embedding, error) {
+	if err := checkDim(emb, len(t.Mean)); err != nil {
+		return nil, err
+	}
+	out := make([]float32, len(t.Mean))
+	for i, v := range emb.data {
+		out[i] = v - t.Mean[i]
+	}
+	return &Embedding{data: out, norm: emb.norm}, nil
+}
+
+// WhitenMethod 白化方法
+type WhitenMethod int
+
+const (
+	// NoWhitening 不做白化
+	NoWhitening WhitenMethod = iota
+	// PCAWhitening PCA白化，输出各维按方差从大到小排列
+	PCAWhitening
+	// ZCAWhitening ZCA白化，白化后的向量与原向量最接近
+	ZCAWhitening
+)
+
+// String 返回白化方法名称
+func (m WhitenMethod) String() string {
+	switch m {
+	case NoWhitening:
+		return "none"
+	case PCAWhitening:
+		return "pca"
+	case ZCAWhitening:
+		return "zca"
+	default:
+		return fmt.Sprintf("WhitenMethod(%d)", int(m))
+	}
+}
+
+// ParseWhitenMethod 根据名称解析白化方法
+func ParseWhitenMethod(name string) (WhitenMethod, error) {
+	for _, m := range []WhitenMethod{NoWhitening, PCAWhitening, ZCAWhitening} {
+		if m.String() == name {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("未知的白化方法: %s", name)
+}
+
+// Whitening 白化变换y=Matrix*(x-Mean)，使域内嵌入向量的协方差为单位矩阵
+type Whitening struct {
+	Method WhitenMethod `json:"method"`
+	Mean   []float32    `json:"mean"`
+	Matrix [][]float32  `json:"matrix"`
+}
+
+// FitWhitening 使用域内嵌入向量（无需标签）估计白化变换
+func FitWhitening(embeddings []*Embedding, method WhitenMethod) (*Whitening, error) {
+	if method != PCAWhitening && method != ZCAWhitening {
+		return nil, fmt.Errorf("不支持的白化方法: %s", method)
+	}
+	x, err := embeddingsToFloat64(embeddings)
+	if err != nil {
+		return nil, err
+	}
+	if len(x) < 2 {
+		return nil, errors.New("估计白化变换至少需要2个嵌入向量")
+	}
+	mean := mat.Mean(x)
+	w := mat.Whitening(mat.Covariance(x, mean), method == ZCAWhitening)
+	return &Whitening{Method: method, Mean: toFloat32(mean), Matrix: denseToRows(w)}, nil
+}
+
+// Apply 对嵌入向量做白化
+func (t *Whitening) Apply(emb *Embedding) (*Embedding, error) {
+	return project(emb, t.Mean, t.Matrix)
+}
+
+// LDA 线性判别分析投影y=Matrix*(x-Mean)，在降维的同时保留最能区分说话人的方向
+type LDA struct {
+	Mean   []float32   `json:"mean"`
+	Matrix [][]float32 `json:"matrix"`
+}
+
+// FitLDA 使用带说话人标签的域内嵌入向量估计LDA投影
+//
+// 参数:
+//   - embeddings: 域内嵌入向量
+//   - labels: 与embeddings一一对应的说话人标签
+//   - dim: 投影后的维度，不能超过说话人数-1
+//
+// 返回:
+//   - LDA变换和可能的错误
+func FitLDA(embeddings []*Embedding, labels []string, dim int) (*LDA, error) {
+	if len(embeddings) != len(labels) {
+		return nil, fmt.Errorf("嵌入向量数 %d 与标签数 %d 不一致", len(embeddings), len(labels))
+	}
+	x, err := embeddingsToFloat64(embeddings)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, len(labels))
+	index := make(map[string]int)
+	for i, label := range labels {
+		id, ok := index[label]
+		if !ok {
+			id = len(index)
+			index[label] = id
+		}
+		ids[i] = id
+	}
+	if dim >= len(index) {
+		return nil, fmt.Errorf("LDA维度 %d 不能超过说话人数-1(%d)", dim, len(index)-1)
+	}
+
+	mean := mat.Mean(x)
+	centered := make([][]float64, len(x))
+	for i, v := range x {
+		centered[i] = make([]float64, len(v))
+		for j := range v {
+			centered[i][j] = v[j] - mean[j]
+		}
+	}
+	p, err := mat.FitLDA(centered, ids, dim)
+	if err != nil {
+		return nil, fmt.Errorf("训练LDA失败: %w", err)
+	}
+	return &LDA{Mean: toFloat32(mean), Matrix: denseToRows(p)}, nil
+}
+
+// Apply 对嵌入向量做LDA投影
+func (t *LDA) Apply(emb *Embedding) (*Embedding, error) {
+	return project(emb, t.Mean, t.Matrix)
+}
+
+// LengthNorm 长度规整，将嵌入向量缩放为单位长度
+type LengthNorm struct{}
+
+// Apply 将嵌入向量缩放为单位长度
+func (LengthNorm) Apply(emb *Embedding) (*Embedding, error) {
+	if emb == nil || len(emb.data) == 0 {
+		return nil, errors.New("嵌入向量为空")
+	}
+	var sum float64
+	for _, v := range emb.data {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return nil, errors.New("嵌入向量长度为0，无法规整")
+	}
+	scale := float32(1 / math.Sqrt(sum))
+	out := make([]float32, len(emb.data))
+	for i, v := range emb.data {
+		out[i] = v * scale
+	}
+	return &Embedding{data: out, norm: emb.norm}, nil
+}
+
+// Pipeline 按顺序应用的变换序列，可连同模型指纹一起保存
+type Pipeline struct {
+	stages      []Transform
+	fingerprint string
+}
+
+// NewPipeline 创建变换序列
+func NewPipeline(stages ...Transform) *Pipeline {
+	return &Pipeline{stages: append([]Transform(nil), stages...)}
+}
+
+// Stages 返回各个变换
+func (p *Pipeline) Stages() []Transform {
+	return append([]Transform(nil), p.stages...)
+}
+
+// Fingerprint 返回拟合变换时所用模型的指纹，未设置时为空
+func (p *Pipeline) Fingerprint() string {
+	return p.fingerprint
+}
+
+// SetFingerprint 记录拟合变换时所用模型的指纹，随变换一同保存
+func (p *Pipeline) SetFingerprint(fingerprint string) {
+	p.fingerprint = fingerprint
+}
+
+// Apply 依次应用各个变换
+func (p *Pipeline) Apply(emb *Embedding) (*Embedding, error) {
+	for i, stage := range p.stages {
+		var err error
+		if emb, err = stage.Apply(emb); err != nil {
+			return nil, fmt.Errorf("第%d个变换失败: %w", i+1, err)
+		}
+	}
+	return emb, nil
+}
+
+// TransformConfig FitPipeline的配置，各变换按均值减除、LDA、白化、长度规整的顺序拟合
+type TransformConfig struct {
+	MeanSubtraction bool         // 是否减去全局均值
+	LDADim          int          // LDA投影维度，0表示不做LDA，否则需要说话人标签
+	Whitening       WhitenMethod // 白化方法
+	LengthNorm      bool         // 是否做长度规整
+}
+
+// FitPipeline 使用域内嵌入向量依次拟合各个变换，每个变换都在前面变换的输出上拟合
+//
+// 参数:
+//   - embeddings: 域内嵌入向量
+//   - labels: 说话人标签，仅LDA需要，不做LDA时可为nil
+//   - cfg: 变换配置
+//
+// 返回:
+//   - 变换序列和可能的错误
+func FitPipeline(embeddings []*Embedding, labels []string, cfg TransformConfig) (*Pipeline, error) {
+	if cfg.LDADim > 0 && labels == nil {
+		return nil, errors.New("LDA需要说话人标签")
+	}
+
+	p := NewPipeline()
+	current := embeddings
+	add := func(t Transform) error {
+		p.stages = append(p.stages, t)
+		next := make([]*Embedding, len(current))
+		for i, emb := range current {
+			var err error
+			if next[i], err = t.Apply(emb); err != nil {
+				return err
+			}
+		}
+		current = next
+		return nil
+	}
+
+	if cfg.MeanSubtraction {
+		t, err := FitMeanSubtraction(current)
+		if err != nil {
+			return nil, fmt.Errorf("拟合均值减除失败: %w", err)
+		}
+		if err := add(t); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.LDADim > 0 {
+		t, err := FitLDA(current, labels, cfg.LDADim)
+		if err != nil {
+			return nil, err
+		}
+		if err := add(t); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Whitening != NoWhitening {
+		t, err := FitWhitening(current, cfg.Whitening)
+		if err != nil {
+			return nil, fmt.Errorf("拟合白化变换失败: %w", err)
+		}
+		if err := add(t); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.LengthNorm {
+		p.stages = append(p.stages, LengthNorm{})
+	}
+	return p, nil
+}
+
+// pipelineFile 变换序列的JSON文件格式
+type pipelineFile struct {
+	Fingerprint string          `json:"fingerprint,omitempty"`
+	Stages      []pipelineStage `json:"stages"`
+}
+
+// pipelineStage 带类型标记的单个变换
+type pipelineStage struct {
+	Type   string          `json:"type"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// Save 将变换序列保存为JSON文件
+func (p *Pipeline) Save(path string) error {
+	f := pipelineFile{Fingerprint: p.fingerprint, Stages: make([]pipelineStage, len(p.stages))}
+	for i, stage := range p.stages {
+		var typ string
+		switch stage.(type) {
+		case *MeanSubtraction:
+			typ = "mean"
+		case *Whitening:
+			typ = "whitening"
+		case *LDA:
+			typ = "lda"
+		case LengthNorm, *LengthNorm:
+			typ = "length-norm"
+		default:
+			return fmt.Errorf("第%d个变换 %T 不支持保存", i+1, stage)
+		}
+		params, err := json.Marshal(stage)
+		if err != nil {
+			return fmt.Errorf("序列化第%d个变换失败: %w", i+1, err)
+		}
+		f.Stages[i] = pipelineStage{Type: typ, Params: params}
+	}
+
+	data, err := json.MarshalIndent(&f, "", "    ")
+	if err != nil {
+		return fmt.Errorf("序列化变换序列失败: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("写入变换序列失败: %w", err)
+	}
+	return nil
+}
+
+// LoadPipeline 从JSON文件加载变换序列
+func LoadPipeline(path string) (*Pipeline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取变换序列失败: %w", err)
+	}
+	var f pipelineFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("解析变换序列失败: %w", err)
+	}
+
+	p := &Pipeline{fingerprint: f.Fingerprint}
+	for i, s := range f.Stages {
+		var stage Transform
+		switch s.Type {
+		case "mean":
+			stage = &MeanSubtraction{}
+		case "whitening":
+			stage = &Whitening{}
+		case "lda":
+			stage = &LDA{}
+		case "length-norm":
+			p.stages = append(p.stages, LengthNorm{})
+			continue
+		default:
+			return nil, fmt.Errorf("第%d个变换类型未知: %s", i+1, s.Type)
+		}
+		if err := json.Unmarshal(s.Params, stage); err != nil {
+			return nil, fmt.Errorf("解析第%d个变换失败: %w", i+1, err)
+		}
+		p.stages = append(p.stages, stage)
+	}
+	return p, nil
+}
+
+// SetTransform 设置嵌入向量变换，设置后ExtractEmbedding返回变换后的嵌入向量，传入nil取消变换
+// 变换改变了嵌入空间，已注册的嵌入向量、阈值和打分器需在同一变换下重新生成
+func (s *Speaker) SetTransform(t Transform) {
+	s.transform = t
+}
+
+// LoadTransform 从文件加载变换序列并设置为嵌入向量变换
+// 文件中记录了模型指纹时，会校验是否与当前模型一致
+func (s *Speaker) LoadTransform(path string) error {
+	p, err := LoadPipeline(path)
+	if err != nil {
+		return err
+	}
+	if p.Fingerprint() != "" && p.Fingerprint() != s.Fingerprint() {
+		return fmt.Errorf("变换序列的模型指纹 %s 与当前模型 %s 不一致", p.Fingerprint(), s.Fingerprint())
+	}
+	s.transform = p
+	return nil
+}
+
+// project 计算y=matrix*(x-mean)
+func project(emb *Embedding, mean []float32, matrix [][]float32) (*Embedding, error) {
+	if err := checkDim(emb, len(mean)); err != nil {
+		return nil, err
+	}
+	centered := make([]float32, len(mean))
+	for i, v := range emb.data {
+		centered[i] = v - mean[i]
+	}
+	out := make([]float32, len(matrix))
+	for i, row := range matrix {
+		if len(row) != len(centered) {
+			return nil, fmt.Errorf("变换矩阵第%d行维度 %d 与输入维度 %d 不一致", i, len(row), len(centered))
+		}
+		var sum float32
+		for j, w := range row {
+			sum += w * centered[j]
+		}
+		out[i] = sum
+	}
+	return &Embedding{data: out, norm: emb.norm}, nil
+}
+
+// checkDim 检查嵌入向量维度
+func checkDim(emb *Embedding, dim int) error {
+	if emb == nil || len(emb.data) == 0 {
+		return errors.New("嵌入向量为空")
+	}
+	if len(emb.data) != dim {
+		return fmt.Errorf("嵌入向量维度 %d 与变换维度 %d 不一致", len(emb.data), dim)
+	}
+	return nil
+}
+
+// embeddingsToFloat64 将嵌入向量集合转换为float64切片并校验维度一致
+func embeddingsToFloat64(embeddings []*Embedding) ([][]float64, error) {
+	if len(embeddings) == 0 {
+		return nil, errors.New("嵌入向量集合为空")
+	}
+	x := make([][]float64, len(embeddings))
+	for i, emb := range embeddings {
+		if emb == nil || len(emb.data) == 0 {
+			return nil, fmt.Errorf("第%d个嵌入向量为空", i)
+		}
+		if len(emb.data) != len(embeddings[0].data) {
+			return nil, fmt.Errorf("第%d个嵌入向量维度不匹配: %d vs %d", i, len(emb.data), len(embeddings[0].data))
+		}
+		x[i] = make([]float64, len(emb.data))
+		for j, v := range emb.data {
+			x[i][j] = float64(v)
+		}
+	}
+	return x, nil
+}
+
+// toFloat32 将float64切片转换为float32
+func toFloat32(v []float64) []float32 {
+	out := make([]float32, len(v))
+	for i, e := range v {
+		out[i] = float32(e)
+	}
+	return out
+}
+
+// denseToRows 将矩阵转换为按行存储的float32切片
+func denseToRows(m *mat.Dense) [][]float32 {
+	rows := make([][]float32, m.Rows)
+	for i := range rows {
+		rows[i] = toFloat32(m.Row(i))
+	}
+	return rows
+}
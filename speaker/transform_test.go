@@ -0,0 +1,131 @@
+package speaker
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"testing"
+)
+
+// TestWhitening 测试白化后域内数据的协方差为单位矩阵
+func TestWhitening(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	embeddings := make([]*Embedding, 500)
+	for i := range embeddings {
+		emb := randomEmbedding(rng, 8)
+		// 引入相关性和偏移
+		emb.data[1] += 3 * emb.data[0]
+		emb.data[2] += 5
+		embeddings[i] = emb
+	}
+
+	for _, method := range []WhitenMethod{PCAWhitening, ZCAWhitening} {
+		w, err := FitWhitening(embeddings, method)
+		if err != nil {
+			t.Fatalf("%s: 拟合白化变换失败: %v", method, err)
+		}
+		x := make([][]float64, len(embeddings))
+		for i, emb := range embeddings {
+			out, err := w.Apply(emb)
+			if err != nil {
+				t.Fatalf("%s: 白化失败: %v", method, err)
+			}
+			x[i] = make([]float64, len(out.data))
+			for j, v := range out.data {
+				x[i][j] = float64(v)
+			}
+		}
+		for i := 0; i < 8; i++ {
+			for j := 0; j < 8; j++ {
+				var cov float64
+				for _, v := range x {
+					cov += v[i] * v[j]
+				}
+				cov /= float64(len(x))
+				want := 0.0
+				if i == j {
+					want = 1
+				}
+				if math.Abs(cov-want) > 1e-3 {
+					t.Fatalf("%s: 白化后协方差(%d,%d)=%.4f，应为%v", method, i, j, cov, want)
+				}
+			}
+		}
+	}
+
+	if _, err := FitWhitening(embeddings, NoWhitening); err == nil {
+		t.Fatal("NoWhitening应返回错误")
+	}
+}
+
+// TestPipeline 测试变换序列的拟合、应用和持久化
+func TestPipeline(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	var embeddings []*Embedding
+	var labels []string
+	for s := 0; s < 20; s++ {
+		center := randomEmbedding(rng, 16)
+		for i := 0; i < 5; i++ {
+			emb := randomEmbedding(rng, 16)
+			for j := range emb.data {
+				emb.data[j] = 2*center.data[j] + 0.5*emb.data[j] + 1
+			}
+			embeddings = append(embeddings, emb)
+			labels = append(labels, fmt.Sprint(s))
+		}
+	}
+
+	cfg := TransformConfig{MeanSubtraction: true, LDADim: 8, Whitening: ZCAWhitening, LengthNorm: true}
+	p, err := FitPipeline(embeddings, labels, cfg)
+	if err != nil {
+		t.Fatalf("拟合变换序列失败: %v", err)
+	}
+	if len(p.Stages()) != 4 {
+		t.Fatalf("变换数应为4，实际为%d", len(p.Stages()))
+	}
+	out, err := p.Apply(embeddings[0])
+	if err != nil {
+		t.Fatalf("应用变换失败: %v", err)
+	}
+	if out.Norm() != embeddings[0].Norm() || out.Norm() <= 0 {
+		t.Fatalf("变换后应保留原始范数: %v vs %v", out.Norm(), embeddings[0].Norm())
+	}
+	if out.GetEmbeddingDimension() != 8 {
+		t.Fatalf("LDA后维度应为8，实际为%d", out.GetEmbeddingDimension())
+	}
+	var norm float64
+	for _, v := range out.data {
+		norm += float64(v) * float64(v)
+	}
+	if math.Abs(norm-1) > 1e-5 {
+		t.Fatalf("长度规整后应为单位向量，实际长度平方为%v", norm)
+	}
+
+	path := filepath.Join(t.TempDir(), "transform.json")
+	p.SetFingerprint("fp")
+	if err := p.Save(path); err != nil {
+		t.Fatalf("保存变换序列失败: %v", err)
+	}
+	loaded, err := LoadPipeline(path)
+	if err != nil {
+		t.Fatalf("加载变换序列失败: %v", err)
+	}
+	out2, err := loaded.Apply(embeddings[0])
+	if err != nil {
+		t.Fatalf("应用加载的变换失败: %v", err)
+	}
+	for i := range out.data {
+		if out.data[i] != out2.data[i] {
+			t.Fatalf("加载前后变换结果不一致: %v vs %v", out.data, out2.data)
+		}
+	}
+
+	// 模型指纹不一致时拒绝加载
+	if err := (&Speaker{}).LoadTransform(path); err == nil {
+		t.Fatal("模型指纹不一致时应返回错误")
+	}
+	if _, err := FitPipeline(embeddings, nil, cfg); err == nil {
+		t.Fatal("LDA缺少标签时应返回错误")
+	}
+}
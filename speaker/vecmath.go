@@ -0,0 +1,238 @@
+package speaker
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+)
+
+// 相似度计算全部在Go中完成，避免每次比较都跨越cgo边界；
+// 循环按8路展开并使用4个独立累加器，编译器可以更好地利用指令级并行
+
+// Dot 计算两个等长向量的点积
+func Dot(a, b []float32) float32 {
+	b = b[:len(a)]
+	var s0, s1, s2, s3 float32
+	for len(a) >= 8 {
+		x, y := a[:8:8], b[:8:8]
+		s0 += x[0]*y[0] + x[4]*y[4]
+		s1 += x[1]*y[1] + x[5]*y[5]
+		s2 += x[2]*y[2] + x[6]*y[6]
+		s3 += x[3]*y[3] + x[7]*y[7]
+		a, b = a[8:], b[8:]
+	}
+	for i := range a {
+		s0 += a[i] * b[i]
+	}
+	return (s0 + s1) + (s2 + s3)
+}
+
+// squaredL2 计算两个等长向量的欧氏距离平方
+func squaredL2(a, b []float32) float32 {
+	b = b[:len(a)]
+	var s0, s1, s2, s3 float32
+	for len(a) >= 4 {
+		x, y := a[:4:4], b[:4:4]
+		d0, d1, d2, d3 := x[0]-y[0], x[1]-y[1], x[2]-y[2], x[3]-y[3]
+		s0 += d0 * d0
+		s1 += d1 * d1
+		s2 += d2 * d2
+		s3 += d3 * d3
+		a, b = a[4:], b[4:]
+	}
+	for i := range a {
+		d := a[i] - b[i]
+		s0 += d * d
+	}
+	return (s0 + s1) + (s2 + s3)
+}
+
+// norm 计算向量的L2范数
+func norm(a []float32) float32 {
+	return float32(math.Sqrt(float64(Dot(a, a))))
+}
+
+// checkPair 检查两个嵌入向量是否可以比较
+func checkPair(emb1, emb2 *Embedding) error {
+	if emb1 == nil || emb2 == nil {
+		return errors.New("嵌入向量为空")
+	}
+	if len(emb1.data) == 0 || len(emb2.data) == 0 {
+		return errors.New("嵌入向量数据为空")
+	}
+	if len(emb1.data) != len(emb2.data) {
+		return fmt.Errorf("嵌入向量维度不匹配: %d vs %d", len(emb1.data), len(emb2.data))
+	}
+	return nil
+}
+
+// CosineSimilarity 计算两个嵌入向量的余弦相似度，任一向量长度为0时返回0
+func CosineSimilarity(emb1, emb2 *Embedding) (float32, error) {
+	if err := checkPair(emb1, emb2); err != nil {
+		return 0, err
+	}
+	n1, n2 := norm(emb1.data), norm(emb2.data)
+	if n1 == 0 || n2 == 0 {
+		return 0, nil
+	}
+	return Dot(emb1.data, emb2.data) / (n1 * n2), nil
+}
+
+// L2Distance 计算两个嵌入向量的L2距离
+func L2Distance(emb1, emb2 *Embedding) (float32, error) {
+	if err := checkPair(emb1, emb2); err != nil {
+		return -1, err
+	}
+	return float32(math.Sqrt(float64(squaredL2(emb1.data, emb2.data)))), nil
+}
+
+const (
+	// 分数矩阵的元素数超过该值时并行计算
+	parallelScoreThreshold = 1 << 14
+	// 并行计算时每个任务处理的参考向量数
+	scoreBlockSize = 1024
+)
+
+// ScoreMatrix 批量计算每个查询向量与每个参考向量的余弦相似度，结果的第i行第j列为queries[i]与refs[j]的分数
+// 各向量的范数只计算一次；为空或与第一个参考向量维度不一致的向量对应的分数为NaN
+func ScoreMatrix(queries, refs []*Embedding) [][]float32 {
+	dim := -1
+	for _, r := range refs {
+		if r != nil && len(r.data) > 0 {
+			dim = len(r.data)
+			break
+		}
+	}
+	invNorms := func(embs []*Embedding) []float32 {
+		inv := make([]float32, len(embs))
+		for i, e := range embs {
+			if e == nil || len(e.data) != dim {
+				inv[i] = float32(math.NaN())
+				continue
+			}
+			if n := norm(e.data); n > 0 {
+				inv[i] = 1 / n
+			}
+		}
+		return inv
+	}
+	queryInv, refInv := invNorms(queries), invNorms(refs)
+
+	scores := make([][]float32, len(queries))
+	for i := range scores {
+		scores[i] = make([]float32, len(refs))
+	}
+	// 计算第i个查询向量与refs[lo:hi]的分数
+	block := func(i, lo, hi int) {
+		q, out := queries[i], scores[i]
+		for j := lo; j < hi; j++ {
+			if math.IsNaN(float64(queryInv[i])) || math.IsNaN(float64(refInv[j])) {
+				out[j] = float32(math.NaN())
+				continue
+			}
+			out[j] = Dot(q.data, refs[j].data) * queryInv[i] * refInv[j]
+		}
+	}
+
+	workers := runtime.GOMAXPROCS(0)
+	if len(queries)*len(refs) < parallelScoreThreshold || workers == 1 {
+		for i := range queries {
+			block(i, 0, len(refs))
+		}
+		return scores
+	}
+
+	// 按(查询向量, 参考向量分块)划分任务，单个查询向量对大量参考向量时也能并行
+	type task struct{ i, lo, hi int }
+	var wg sync.WaitGroup
+	tasks := make(chan task)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range tasks {
+				block(t.i, t.lo, t.hi)
+			}
+		}()
+	}
+	for i := range queries {
+		for lo := 0; lo < len(refs); lo += scoreBlockSize {
+			tasks <- task{i, lo, min(lo+scoreBlockSize, len(refs))}
+		}
+	}
+	close(tasks)
+	wg.Wait()
+	return scores
+}
+
+// AverageEmbeddings 将多个嵌入向量（例如同一说话人的多段注册音频）合成一个声纹：
+// 各向量归一化后取平均，结果再次归一化；Norm为各输入Norm的平均值，仍可作为可靠程度的参考
+func AverageEmbeddings(embs []*Embedding) (*Embedding, error) {
+	if len(embs) == 0 {
+		return nil, errors.New("没有可平均的嵌入向量")
+	}
+	var sum []float32
+	var rawNorm float64
+	for i, emb := range embs {
+		if emb == nil || len(emb.data) == 0 {
+			return nil, fmt.Errorf("第%d个嵌入向量为空", i)
+		}
+		if sum == nil {
+			sum = make([]float32, len(emb.data))
+		}
+		if len(emb.data) != len(sum) {
+			return nil, fmt.Errorf("第%d个嵌入向量维度不匹配: %d vs %d", i, len(emb.data), len(sum))
+		}
+		n := norm(emb.data)
+		if n == 0 {
+			return nil, fmt.Errorf("第%d个嵌入向量: %w", i, ErrZeroNorm)
+		}
+		for j, v := range emb.data {
+			sum[j] += v / n
+		}
+		rawNorm += float64(emb.norm)
+	}
+	n := norm(sum)
+	if n == 0 {
+		return nil, fmt.Errorf("平均向量: %w", ErrZeroNorm)
+	}
+	for j := range sum {
+		sum[j] /= n
+	}
+	return &Embedding{data: sum, norm: float32(rawNorm / float64(len(embs)))}, nil
+}
+
+// HybridSimilarity 结合余弦相似度和L2距离的混合评分
+// 权重范围[0,1]，值越大表示越重视余弦相似度，越小表示越重视L2距离
+//
+// Deprecated: 嵌入向量已做L2归一化，L2距离与余弦相似度一一对应，混合评分不包含额外信息。
+// 请使用Scorer接口（CosineScorer或L2Scorer）
+func HybridSimilarity(emb1, emb2 *Embedding, cosineWeight float32) (float32, error) {
+	// 计算余弦相似度
+	cosine, err := CosineSimilarity(emb1, emb2)
+	if err != nil {
+		return 0, fmt.Errorf("计算余弦相似度失败: %w", err)
+	}
+
+	// 计算L2距离
+	l2, err := L2Distance(emb1, emb2)
+	if err != nil {
+		return 0, fmt.Errorf("计算L2距离失败: %w", err)
+	}
+
+	// 将L2距离转换为相似度得分（距离越小，相似度越高）
+	// 使用指数衰减函数将L2距离映射到[0,1]范围
+	l2Similarity := float32(math.Exp(-float64(l2)))
+
+	// 确保权重在有效范围内
+	if cosineWeight < 0 {
+		cosineWeight = 0
+	} else if cosineWeight > 1 {
+		cosineWeight = 1
+	}
+
+	// 计算加权混合得分
+	return cosineWeight*cosine + (1-cosineWeight)*l2Similarity, nil
+}
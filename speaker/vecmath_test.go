@@ -0,0 +1,118 @@
+package speaker
+
+import (
+	"math"
+	"math/rand"
+	"runtime"
+	"testing"
+)
+
+// TestKernels 测试展开后的向量运算与朴素实现一致
+func TestKernels(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for n := 0; n <= 21; n++ {
+		a, b := randomEmbedding(rng, n).data, randomEmbedding(rng, n).data
+		var wantDot, wantL2 float64
+		for i := range a {
+			wantDot += float64(a[i]) * float64(b[i])
+			wantL2 += float64(a[i]-b[i]) * float64(a[i]-b[i])
+		}
+		if math.Abs(float64(Dot(a, b))-wantDot) > 1e-4 {
+			t.Fatalf("n=%d 点积不正确: %v vs %v", n, Dot(a, b), wantDot)
+		}
+		if math.Abs(float64(squaredL2(a, b))-wantL2) > 1e-4 {
+			t.Fatalf("n=%d 距离平方不正确: %v vs %v", n, squaredL2(a, b), wantL2)
+		}
+	}
+
+	zero := NewEmbedding(make([]float32, 4))
+	if s, err := CosineSimilarity(zero, NewEmbedding([]float32{1, 2, 3, 4})); err != nil || s != 0 {
+		t.Fatalf("零向量的余弦相似度应为0: %v %v", s, err)
+	}
+	if _, err := L2Distance(zero, NewEmbedding([]float32{1})); err == nil {
+		t.Fatal("维度不匹配时应返回错误")
+	}
+}
+
+// TestScoreMatrix 测试批量打分与逐对打分一致
+func TestScoreMatrix(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	// 确保覆盖并行路径
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	for _, size := range [][2]int{{3, 5}, {40, 600}} {
+		queries := make([]*Embedding, size[0])
+		for i := range queries {
+			queries[i] = randomEmbedding(rng, 32)
+		}
+		refs := make([]*Embedding, size[1])
+		for i := range refs {
+			refs[i] = randomEmbedding(rng, 32)
+		}
+		refs[1] = NewEmbedding([]float32{1, 2})
+
+		scores := ScoreMatrix(queries, refs)
+		if len(scores) != len(queries) {
+			t.Fatalf("分数矩阵行数不正确: %d", len(scores))
+		}
+		for i, q := range queries {
+			for j, r := range refs {
+				if j == 1 {
+					if !math.IsNaN(float64(scores[i][j])) {
+						t.Fatalf("维度不一致的向量分数应为NaN: %v", scores[i][j])
+					}
+					continue
+				}
+				want, _ := CosineSimilarity(q, r)
+				if math.Abs(float64(scores[i][j]-want)) > 1e-5 {
+					t.Fatalf("(%d,%d) 分数不一致: %v vs %v", i, j, scores[i][j], want)
+				}
+			}
+		}
+	}
+}
+
+// TestAverageEmbeddings 测试平均后的声纹已归一化，并保留各输入原始范数的平均值
+func TestAverageEmbeddings(t *testing.T) {
+	a := NewEmbeddingWithNorm([]float32{1, 0}, 10)
+	b := NewEmbeddingWithNorm([]float32{0, 1}, 20)
+	emb, err := AverageEmbeddings([]*Embedding{a, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := float32(math.Sqrt(0.5))
+	if d := emb.GetData(); math.Abs(float64(d[0]-want)) > 1e-6 || math.Abs(float64(d[1]-want)) > 1e-6 {
+		t.Fatalf("平均方向不正确: %v", d)
+	}
+	if emb.Norm() != 15 {
+		t.Fatalf("Norm应为输入原始范数的平均值15，实际为%v", emb.Norm())
+	}
+
+	for _, embs := range [][]*Embedding{nil, {a, nil}, {a, NewEmbedding([]float32{1, 2, 3})}, {a, NewEmbedding([]float32{-1, 0})}} {
+		if _, err := AverageEmbeddings(embs); err == nil {
+			t.Fatalf("%v 应返回错误", embs)
+		}
+	}
+}
+
+// BenchmarkScoreMatrix 一个查询向量与1万个参考向量打分
+func BenchmarkScoreMatrix(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	query := []*Embedding{randomEmbedding(rng, 192)}
+	refs := make([]*Embedding, 10000)
+	for i := range refs {
+		refs[i] = randomEmbedding(rng, 192)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ScoreMatrix(query, refs)
+	}
+}
+
+// BenchmarkCosineSimilarity 单对192维向量的余弦相似度
+func BenchmarkCosineSimilarity(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	e1, e2 := randomEmbedding(rng, 192), randomEmbedding(rng, 192)
+	for i := 0; i < b.N; i++ {
+		CosineSimilarity(e1, e2)
+	}
+}